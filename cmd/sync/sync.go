@@ -14,6 +14,25 @@ func main() {
 	app.Commands = []cli.Command{
 		commands.NewDeltaCommand(),
 		commands.NewSignatureCommand(),
+		commands.NewPatchCommand(),
+		commands.NewServeCommand(),
+		commands.NewPushCommand(),
+		commands.NewPullCommand(),
+		commands.NewHTTPCommand(),
+		commands.NewFetchCommand(),
+		commands.NewGRPCCommand(),
+		commands.NewInspectCommand(),
+		commands.NewBackupCommand(),
+		commands.NewRestoreCommand(),
+		commands.NewLsCommand(),
+		commands.NewForgetCommand(),
+		commands.NewGCCommand(),
+		commands.NewCheckCommand(),
+		commands.NewCommitCommand(),
+		commands.NewLogCommand(),
+		commands.NewCheckoutCommand(),
+		commands.NewSquashCommand(),
+		commands.NewSigdiffCommand(),
 	}
 
 	app.Name = "App for calculating hashes and deltas of files"
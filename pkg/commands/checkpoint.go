@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/urfave/cli"
+)
+
+// how many bytes have to be processed before next checkpoint is saved
+const checkpointInterval = 1024 * 1024
+
+func checkpointFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:     "checkpointFile",
+			Usage:    "File to which progress is saved, so interrupted command can be resumed",
+			Required: false,
+		},
+		cli.BoolFlag{
+			Name:  "resume",
+			Usage: "Continue from checkpointFile instead of starting from the beginning",
+		},
+	}
+}
+
+func loadCheckpoint(path string, inputSize int64, sourceDigest []byte) (sync.Checkpoint, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return sync.Checkpoint{}, fmt.Errorf("unable to read checkpoint file '%s'. %w", path, err)
+	}
+	defer file.Close()
+
+	checkpoint, err := sync.DeserializeCheckpoint(file)
+	if err != nil {
+		return checkpoint, fmt.Errorf("unable to deserialize checkpoint file. %w", err)
+	}
+
+	if err := checkpoint.Validate(inputSize, sourceDigest); err != nil {
+		return checkpoint, fmt.Errorf("unable to resume. %w", err)
+	}
+
+	return checkpoint, nil
+}
+
+// saveCheckpoint writes checkpoint to temporary file first,
+// so interruption while saving does not corrupt previous checkpoint
+func saveCheckpoint(path string, checkpoint sync.Checkpoint) error {
+	serialized, err := sync.SerializeCheckpoint(checkpoint)
+	if err != nil {
+		return fmt.Errorf("unable to serialize checkpoint. %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(tmpFile, serialized); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+func fileSize(file *os.File) (int64, error) {
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	return stat.Size(), nil
+}
+
+// fileDigest calculates digest of whole file and rewinds it afterwards
+func fileDigest(file *os.File) ([]byte, error) {
+	digest, err := sync.Digest(file)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return digest, err
+}
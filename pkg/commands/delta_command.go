@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 
@@ -13,42 +16,172 @@ func NewDeltaCommand() cli.Command {
 	return cli.Command{
 		Name:  "delta",
 		Usage: "Creates delta file with list of changes based in inputFile and signatureFile",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.StringFlag{
 				Name:     "inputFile",
-				Usage:    "File path for which delta should be calculated",
-				Required: true,
+				Usage:    "File path for which delta should be calculated, '-' reads stdin",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "inputDir",
+				Usage:    "Directory for which delta should be calculated, used instead of inputFile",
+				Required: false,
 			},
 			cli.StringFlag{
 				Name:     "signatureFile",
-				Usage:    "Path to signature file which was calculated for previous version",
+				Usage:    "Path to signature file which was calculated for previous version, '-' reads stdin",
 				Required: true,
 			},
 			cli.StringFlag{
 				Name:     "deltaFile",
-				Usage:    "File to which delta will be saved, if not provider it will be printed out",
+				Usage:    "File to which delta will be saved, if not provided or '-' it is written to stdout",
 				Required: false,
 			},
-		},
+			cli.StringSliceFlag{
+				Name:  "extraSignatureFile",
+				Usage: "Signature of additional basis file (basis 1, 2, ... in order of flags), delta can copy chunks from it, can be repeated",
+			},
+			cli.StringFlag{
+				Name:  "basisFile",
+				Usage: "Local copy of file for which signature was calculated, matches are extended with equal bytes around them",
+			},
+			cli.BoolFlag{
+				Name:  "matchTarget",
+				Usage: "Copy data repeated within new file from its earlier part instead of sending it again",
+			},
+			cli.StringFlag{
+				Name:  "compress",
+				Usage: "Compression of literal data: none, deflate or zstd",
+				Value: "none",
+			},
+		}, append(append(checkpointFlags(), filterFlags()...), formatFlag())...),
 		Action: func(c *cli.Context) error {
-			file, err := getFile(c, "inputFile")
+			if err := requireOneOf(c, "inputFile", "inputDir"); err != nil {
+				return err
+			}
+
+			if err := requireSingleStdin(c, "inputFile", "signatureFile", "basisFile"); err != nil {
+				return err
+			}
+
+			format, err := fileFormat(c)
+			if err != nil {
+				return err
+			}
+
+			extraSignatures := c.StringSlice("extraSignatureFile")
+			if len(extraSignatures) > 0 && (format != nativeFormat || c.IsSet("inputDir") || c.IsSet("checkpointFile")) {
+				return fmt.Errorf("extra signatures are supported only for single file in native format without checkpoints")
+			}
+
+			if c.Bool("matchTarget") && (format != nativeFormat || c.IsSet("inputDir") || c.IsSet("checkpointFile")) {
+				return fmt.Errorf("target matching is supported only for single file in native format without checkpoints")
+			}
+
+			if c.IsSet("basisFile") && (format != nativeFormat || c.IsSet("inputDir") || c.IsSet("checkpointFile")) {
+				return fmt.Errorf("match extension is supported only for single file in native format without checkpoints")
+			}
+
+			if c.IsSet("compress") && format != nativeFormat {
+				return fmt.Errorf("compression is supported only in native format")
+			}
+
+			switch format {
+			case rdiffFormat:
+				return rdiffDelta(c)
+			case vcdiffFormat:
+				return vcdiffDelta(c)
+			}
+
+			codec, err := sync.ParseCodec(c.String("compress"))
+			if err != nil {
+				return err
+			}
+
+			if codec != sync.NoCompression && (c.IsSet("inputDir") || c.IsSet("checkpointFile")) {
+				return fmt.Errorf("compression is supported only for single file without checkpoints")
+			}
+
+			if c.IsSet("inputDir") {
+				if c.IsSet("checkpointFile") {
+					return fmt.Errorf("checkpoints are not supported for directories")
+				}
+				return treeDelta(c)
+			}
+
+			// compression reads preceding data again and checkpoints seek input
+			getInput := getFile
+			if codec != sync.NoCompression || c.IsSet("checkpointFile") {
+				getInput = getSeekableFile
+			}
+
+			file, err := getInput(c, "inputFile")
 			if err != nil {
 				return err
 			}
 			defer file.Close()
 
-			sigFile, err := getFile(c, "signatureFile")
+			sigFile, err := getInput(c, "signatureFile")
 			if err != nil {
 				return err
 			}
 			defer sigFile.Close()
 
+			if c.IsSet("checkpointFile") {
+				return deltaWithCheckpoints(c, file, sigFile)
+			}
+
+			if c.Bool("resume") {
+				return fmt.Errorf("resume requires checkpointFile")
+			}
+
 			s := sync.New()
+			s.SetTargetMatching(c.Bool("matchTarget"))
 
 			deltas := []sync.Delta{}
-			err = s.Delta(file, sigFile, func(d sync.Delta) {
+			handleDeltas := func(d sync.Delta) {
 				deltas = append(deltas, d)
-			})
+			}
+
+			var compressor *sync.Compressor
+			if codec != sync.NoCompression {
+				inputSize, err := fileSize(file)
+				if err != nil {
+					return fmt.Errorf("unable to read input file size. %w", err)
+				}
+
+				compressor = sync.NewCompressor(codec, file, inputSize, handleDeltas)
+				handleDeltas = compressor.Handle
+			}
+
+			var extender *sync.MatchExtender
+			if c.IsSet("basisFile") {
+				basis, err := getSeekableFile(c, "basisFile")
+				if err != nil {
+					return err
+				}
+				defer basis.Close()
+
+				basisSize, err := fileSize(basis)
+				if err != nil {
+					return fmt.Errorf("unable to read basis file size. %w", err)
+				}
+
+				extender = sync.NewMatchExtender(basis, basisSize, handleDeltas)
+				handleDeltas = extender.Handle
+			}
+
+			if len(extraSignatures) > 0 {
+				err = deltaFromBases(c, file, sigFile, extraSignatures, handleDeltas)
+			} else {
+				err = s.Delta(file, sigFile, handleDeltas)
+			}
+			if err == nil && extender != nil {
+				err = extender.Close()
+			}
+			if err == nil && compressor != nil {
+				err = compressor.Close()
+			}
 
 			if err != nil {
 				return fmt.Errorf("error while calculating delta. %w", err)
@@ -64,13 +197,179 @@ func NewDeltaCommand() cli.Command {
 				return fmt.Errorf("unable to read serialized deltas chunks. %w", err)
 			}
 
-			if c.IsSet("deltaFile") {
-				outputFile := c.String("deltaFile")
-				return os.WriteFile(outputFile, []byte(serializedDeltas), os.ModePerm)
+			if codec != sync.NoCompression {
+				header := bytes.Buffer{}
+				if err := sync.WriteDeltaHeader(&header, sync.NewDeltaHeader(codec)); err != nil {
+					return err
+				}
+				serializedDeltas = append(header.Bytes(), serializedDeltas...)
 			}
 
-			fmt.Printf("%+v", serializedDeltas)
-			return nil
+			return writeOutput(c, "deltaFile", serializedDeltas)
 		},
 	}
 }
+
+// deltaFromBases calculates delta against signature and extra signatures, which are bases 1, 2, ... in order
+func deltaFromBases(c *cli.Context, file io.Reader, sigFile io.Reader, extraSignatures []string, handleDeltas sync.DeltaHandler) error {
+	chunks, err := sync.DeserializeChunks(sigFile)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize signature file. %w", err)
+	}
+
+	bases := []sync.BasisSignature{{Basis: 0, Chunks: chunks}}
+	for i, path := range extraSignatures {
+		chunks, err := readChunksFile(path)
+		if err != nil {
+			return err
+		}
+
+		bases = append(bases, sync.BasisSignature{Basis: uint32(i + 1), Chunks: chunks})
+	}
+
+	s := sync.New()
+	s.SetTargetMatching(c.Bool("matchTarget"))
+	return s.DeltaFromBases(file, bases, handleDeltas)
+}
+
+func readChunksFile(path string) ([]sync.Chunk, error) {
+	if path == stdio {
+		return nil, fmt.Errorf("extra signature cannot be read from stdin")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open signature file '%s'. %w", path, err)
+	}
+	defer file.Close()
+
+	chunks, err := sync.DeserializeChunks(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize signature file '%s'. %w", path, err)
+	}
+
+	return chunks, nil
+}
+
+// deltaWithCheckpoints stores calculated deltas in batches in "<deltaFile>.part" file,
+// after each batch checkpoint is saved, so calculation can be resumed from it.
+// When whole input is processed batches are merged into deltaFile.
+func deltaWithCheckpoints(c *cli.Context, file *os.File, sigFile *os.File) error {
+	if isStdout(c, "deltaFile") {
+		return fmt.Errorf("checkpoints require deltaFile")
+	}
+
+	deltaPath := c.String("deltaFile")
+	partPath := deltaPath + ".part"
+	checkpointPath := c.String("checkpointFile")
+
+	inputSize, err := fileSize(file)
+	if err != nil {
+		return fmt.Errorf("unable to read input file size. %w", err)
+	}
+
+	sigDigest, err := fileDigest(sigFile)
+	if err != nil {
+		return fmt.Errorf("unable to calculate signature file digest. %w", err)
+	}
+
+	from := sync.Checkpoint{}
+	if c.Bool("resume") {
+		from, err = loadCheckpoint(checkpointPath, inputSize, sigDigest)
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Seek(from.InputOffset, io.SeekStart); err != nil {
+			return fmt.Errorf("unable to seek input file. %w", err)
+		}
+	}
+
+	part, err := os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open partial delta file. %w", err)
+	}
+	defer part.Close()
+
+	// drop deltas which were written after last checkpoint
+	if err := part.Truncate(from.OutputOffset); err != nil {
+		return fmt.Errorf("unable to truncate partial delta file. %w", err)
+	}
+
+	if _, err := part.Seek(from.OutputOffset, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek partial delta file. %w", err)
+	}
+
+	pending := []sync.Delta{}
+	outputOffset := from.OutputOffset
+	flush := func() error {
+		if len(pending) == 0 {
+			return nil
+		}
+
+		batch, err := sync.SerializeDeltasBatch(pending)
+		if err != nil {
+			return err
+		}
+
+		n, err := part.Write(batch)
+		outputOffset += int64(n)
+		if err != nil {
+			return err
+		}
+
+		pending = pending[:0]
+		return part.Sync()
+	}
+
+	s := sync.New()
+	s.SetTargetMatching(c.Bool("matchTarget"))
+	lastSaved := from.InputOffset
+	err = s.DeltaFrom(file, sigFile, from, func(d sync.Delta) {
+		pending = append(pending, d)
+	}, func(checkpoint sync.Checkpoint) error {
+		if checkpoint.InputOffset-lastSaved < checkpointInterval {
+			return nil
+		}
+
+		if err := flush(); err != nil {
+			return err
+		}
+
+		checkpoint.InputSize = inputSize
+		checkpoint.SourceDigest = sigDigest
+		checkpoint.OutputOffset = outputOffset
+		lastSaved = checkpoint.InputOffset
+
+		return saveCheckpoint(checkpointPath, checkpoint)
+	})
+
+	if err != nil {
+		return fmt.Errorf("error while calculating delta. %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return fmt.Errorf("unable to write partial delta file. %w", err)
+	}
+
+	if _, err := part.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("unable to seek partial delta file. %w", err)
+	}
+
+	// batches are copied one by one, so whole delta is never kept in memory
+	err = writeFile(deltaPath, func(out *bufio.Writer) error {
+		enc := sync.NewStreamEncoder[sync.Delta](out)
+		if err := sync.DecodeDeltasBatches(part, enc.Encode); err != nil {
+			return fmt.Errorf("unable to read partial delta file. %w", err)
+		}
+
+		return enc.Flush()
+	})
+	if err != nil {
+		return err
+	}
+
+	os.Remove(partPath)
+	os.Remove(checkpointPath)
+	return nil
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/fetch"
+	"github.com/urfave/cli"
+)
+
+func NewFetchCommand() cli.Command {
+	return cli.Command{
+		Name:  "fetch",
+		Usage: "Downloads file published with its signature, fetching only parts which are missing in basisFile",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:     "url",
+				Usage:    "Url of file to download, server has to support Range requests",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:     "signatureUrl",
+				Usage:    "Url of signature of file, by default url with .sig suffix",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "basisFile",
+				Usage:    "Path to local previous version of file, if not provided whole file is downloaded",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "outputFile",
+				Usage:    "File to which downloaded file will be saved, it can be the same as basisFile, '-' writes to stdout",
+				Required: true,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			signatureURL := c.String("signatureUrl")
+			if signatureURL == "" {
+				signatureURL = c.String("url") + ".sig"
+			}
+
+			var basis io.ReadSeeker
+			if c.IsSet("basisFile") {
+				file, err := getSeekableFile(c, "basisFile")
+				if err != nil {
+					return err
+				}
+				defer file.Close()
+				basis = file
+			}
+
+			var stats fetch.Stats
+			err := writeFetched(c.String("outputFile"), func(out io.Writer) error {
+				var err error
+				stats, err = fetch.New().Fetch(c.String("url"), signatureURL, basis, out)
+				if err != nil {
+					return fmt.Errorf("unable to fetch file. %w", err)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			log.Printf("reused %d bytes, downloaded %d bytes in %d requests", stats.LocalBytes, stats.FetchedBytes, stats.Requests)
+			return nil
+		},
+	}
+}
+
+// writeFetched writes to temporary file which replaces outputPath when fetch succeeds,
+// so outputPath can be the same as basisFile. Stdio output is written directly to stdout
+func writeFetched(outputPath string, write func(out io.Writer) error) error {
+	if outputPath == stdio {
+		writer := bufio.NewWriter(os.Stdout)
+		if err := write(writer); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}
+
+	output, err := os.CreateTemp(filepath.Dir(outputPath), filepath.Base(outputPath)+".fetch-*")
+	if err != nil {
+		return fmt.Errorf("unable to create output file. %w", err)
+	}
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	writer := bufio.NewWriter(output)
+	if err := write(writer); err != nil {
+		return err
+	}
+
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("unable to write output file. %w", err)
+	}
+
+	if err := output.Close(); err != nil {
+		return fmt.Errorf("unable to write output file. %w", err)
+	}
+
+	if err := os.Rename(output.Name(), outputPath); err != nil {
+		return fmt.Errorf("unable to save output file. %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	nativeFormat = "native"
+	rdiffFormat  = "rdiff"
+	vcdiffFormat = "vcdiff"
+)
+
+func formatFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:  "format",
+		Usage: "Format of signature and delta files: native, rdiff (compatible with librsync rdiff) or vcdiff (RFC 3284 delta with native signature)",
+		Value: nativeFormat,
+	}
+}
+
+// fileFormat returns format from flag, formats other than native are supported only for single files
+func fileFormat(c *cli.Context) (string, error) {
+	format := c.String("format")
+
+	switch format {
+	case nativeFormat:
+		return format, nil
+	case rdiffFormat, vcdiffFormat:
+		if c.IsSet("inputDir") || c.IsSet("basisDir") || c.IsSet("checkpointFile") {
+			return format, fmt.Errorf("%s format supports only single files without checkpoints", format)
+		}
+		return format, nil
+	}
+
+	return format, fmt.Errorf("unknown format '%s'", format)
+}
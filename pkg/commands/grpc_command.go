@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi"
+	"github.com/urfave/cli"
+	"google.golang.org/grpc"
+)
+
+func NewGRPCCommand() cli.Command {
+	return cli.Command{
+		Name:  "grpc",
+		Usage: "Runs gRPC service for computing signatures, deltas and patches",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address on which service listens",
+				Value: ":9090",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			listener, err := net.Listen("tcp", c.String("listen"))
+			if err != nil {
+				return fmt.Errorf("unable to listen. %w", err)
+			}
+			defer listener.Close()
+
+			server := grpc.NewServer()
+			grpcapi.NewServer().Register(server)
+
+			log.Printf("serving gRPC on %s", listener.Addr())
+			return server.Serve(listener)
+		},
+	}
+}
@@ -2,13 +2,21 @@ package commands
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/urfave/cli"
 )
 
+// stdio is path which means stdin for inputs and stdout for outputs
+const stdio = "-"
+
 func getFile(c *cli.Context, name string) (*os.File, error) {
 	inputFile := c.String(name)
+	if inputFile == stdio {
+		return os.Stdin, nil
+	}
+
 	file, err := os.Open(inputFile)
 	if err != nil {
 		return nil, fmt.Errorf("unable to read input file '%s'. %w", name, err)
@@ -16,3 +24,82 @@ func getFile(c *cli.Context, name string) (*os.File, error) {
 
 	return file, nil
 }
+
+// getSeekableFile opens file like getFile, but stdin which is not regular file (e.g. pipe)
+// is first copied to temporary file, so it can be read more than once
+func getSeekableFile(c *cli.Context, name string) (*os.File, error) {
+	file, err := getFile(c, name)
+	if err != nil || file != os.Stdin {
+		return file, err
+	}
+
+	if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+		return file, nil
+	}
+
+	spooled, err := os.CreateTemp("", "sync-stdin-*")
+	if err != nil {
+		return nil, fmt.Errorf("unable to create temporary file for stdin. %w", err)
+	}
+	// file stays readable until it is closed
+	os.Remove(spooled.Name())
+
+	_, err = io.Copy(spooled, file)
+	if err == nil {
+		_, err = spooled.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		spooled.Close()
+		return nil, fmt.Errorf("unable to read stdin. %w", err)
+	}
+
+	return spooled, nil
+}
+
+// isStdout checks if output flag is missing or set to stdio
+func isStdout(c *cli.Context, name string) bool {
+	return !c.IsSet(name) || c.String(name) == stdio
+}
+
+// writeOutput saves data to file from flag, or writes it to stdout when flag is missing or set to stdio
+func writeOutput(c *cli.Context, name string, data []byte) error {
+	if isStdout(c, name) {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(c.String(name), data, os.ModePerm)
+}
+
+// requireSingleStdin checks that stdin is used by at most one of flags
+func requireSingleStdin(c *cli.Context, names ...string) error {
+	used := 0
+	for _, name := range names {
+		if c.String(name) == stdio {
+			used++
+		}
+	}
+
+	if used > 1 {
+		return fmt.Errorf("only one of flags %v can read from stdin", names)
+	}
+
+	return nil
+}
+
+// requireOneOf checks that exactly one of mutually exclusive flags was provided
+func requireOneOf(c *cli.Context, names ...string) error {
+	set := 0
+	for _, name := range names {
+		if c.IsSet(name) {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of flags %v is required", names)
+	}
+
+	return nil
+}
@@ -0,0 +1,118 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/history"
+	"github.com/urfave/cli"
+)
+
+func historyFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:     "history",
+		Usage:    "Directory with versions of file",
+		Required: true,
+	}
+}
+
+func NewCommitCommand() cli.Command {
+	return cli.Command{
+		Name:  "commit",
+		Usage: "Stores inputFile as new version, as delta against previous version or as new base",
+		Flags: []cli.Flag{
+			historyFlag(),
+			cli.StringFlag{
+				Name:     "inputFile",
+				Usage:    "New version of file, '-' reads stdin",
+				Required: true,
+			},
+			cli.IntFlag{
+				Name:  "maxChain",
+				Usage: "Number of deltas after which version is stored as new base",
+				Value: history.DefaultMaxChain,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			file, err := getSeekableFile(c, "inputFile")
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			h, err := history.Open(c.String("history"))
+			if err != nil {
+				return err
+			}
+
+			version, err := h.Commit(file, c.Int("maxChain"))
+			if err != nil {
+				return err
+			}
+
+			log.Printf("committed version %d, stored %d of %d bytes", version.Id, version.StoredSize, version.Size)
+			return nil
+		},
+	}
+}
+
+func NewLogCommand() cli.Command {
+	return cli.Command{
+		Name:  "log",
+		Usage: "Lists versions with their sizes",
+		Flags: []cli.Flag{
+			historyFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			h, err := history.Open(c.String("history"))
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "VERSION\tTIME\tTYPE\tSIZE\tSTORED")
+			for _, version := range h.Versions() {
+				kind := "delta"
+				if version.Base {
+					kind = "base"
+				}
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\n", version.Id, version.Time.Format(time.RFC3339),
+					kind, version.Size, version.StoredSize)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func NewCheckoutCommand() cli.Command {
+	return cli.Command{
+		Name:  "checkout",
+		Usage: "Recreates version of file by applying deltas to its base",
+		Flags: []cli.Flag{
+			historyFlag(),
+			cli.UintFlag{
+				Name:     "version",
+				Usage:    "Version which should be recreated",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "outputFile",
+				Usage: "File to which version will be saved, if not provided or '-' it is written to stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			h, err := history.Open(c.String("history"))
+			if err != nil {
+				return err
+			}
+
+			return writeFile(c.String("outputFile"), func(out *bufio.Writer) error {
+				return h.Checkout(uint32(c.Uint("version")), out)
+			})
+		},
+	}
+}
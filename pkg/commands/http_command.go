@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/httpapi"
+	"github.com/urfave/cli"
+)
+
+func NewHTTPCommand() cli.Command {
+	return cli.Command{
+		Name:  "http",
+		Usage: "Runs HTTP API for computing signatures and deltas, and patching basis files kept in root directory",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:     "root",
+				Usage:    "Directory with basis files uploaded to API",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address on which API listens",
+				Value: ":8080",
+			},
+			cli.StringFlag{
+				Name:  "tlsCert",
+				Usage: "Certificate file, with tlsKey API is served over HTTPS and HTTP/2, which streams requests too",
+			},
+			cli.StringFlag{
+				Name:  "tlsKey",
+				Usage: "Private key file of tlsCert",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.IsSet("tlsCert") != c.IsSet("tlsKey") {
+				return fmt.Errorf("tlsCert and tlsKey have to be provided together")
+			}
+
+			handler := httpapi.NewHandler(c.String("root"))
+			log.Printf("serving HTTP API for '%s' on %s", c.String("root"), c.String("listen"))
+			if c.IsSet("tlsCert") {
+				return http.ListenAndServeTLS(c.String("listen"), c.String("tlsCert"), c.String("tlsKey"), handler)
+			}
+
+			return http.ListenAndServe(c.String("listen"), handler)
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/inspect"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/urfave/cli"
+)
+
+func NewInspectCommand() cli.Command {
+	return cli.Command{
+		Name:  "inspect",
+		Usage: "Prints summary or full listing of signature or delta file",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "signatureFile",
+				Usage: "Signature file to inspect",
+			},
+			cli.StringFlag{
+				Name:  "deltaFile",
+				Usage: "Delta file to inspect, used instead of signatureFile",
+			},
+			cli.StringFlag{
+				Name:  "newFile",
+				Usage: "New file from which delta was calculated, used for compression ratio of compressed deltas",
+			},
+			cli.BoolFlag{
+				Name:  "list",
+				Usage: "Print every chunk or delta instead of summary",
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print output as JSON",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := requireOneOf(c, "signatureFile", "deltaFile"); err != nil {
+				return err
+			}
+
+			if err := requireSingleStdin(c, "deltaFile", "newFile"); err != nil {
+				return err
+			}
+
+			if c.IsSet("signatureFile") {
+				return inspectSignature(c, os.Stdout)
+			}
+
+			return inspectDelta(c, os.Stdout)
+		},
+	}
+}
+
+func inspectSignature(c *cli.Context, out io.Writer) error {
+	file, err := getFile(c, "signatureFile")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	chunks, err := sync.DeserializeChunks(file)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize signature file. %w", err)
+	}
+
+	if c.Bool("list") {
+		entries := inspect.ListChunks(chunks)
+		if c.Bool("json") {
+			return inspect.WriteJSON(out, entries)
+		}
+		return inspect.WriteChunks(out, entries)
+	}
+
+	summary := inspect.SummarizeSignature(chunks)
+	if c.Bool("json") {
+		return inspect.WriteJSON(out, summary)
+	}
+	return inspect.WriteSignatureSummary(out, summary)
+}
+
+func inspectDelta(c *cli.Context, out io.Writer) error {
+	file, err := getFile(c, "deltaFile")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// size is counted while reading, so it also works for stdin
+	counter := &countingReader{r: file}
+	header, rest, err := sync.ReadDeltaHeader(counter)
+	if err != nil {
+		return fmt.Errorf("unable to read delta header. %w", err)
+	}
+
+	deltas, err := sync.DeserializeDelta(rest)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize delta file. %w", err)
+	}
+
+	if c.Bool("list") {
+		entries := inspect.ListDeltas(deltas)
+		if c.Bool("json") {
+			return inspect.WriteJSON(out, entries)
+		}
+		return inspect.WriteDeltas(out, entries)
+	}
+
+	var newSize int64
+	if c.IsSet("newFile") {
+		newFile, err := getFile(c, "newFile")
+		if err != nil {
+			return err
+		}
+		defer newFile.Close()
+
+		newSize, err = fileSize(newFile)
+		if err != nil {
+			return fmt.Errorf("unable to read new file size. %w", err)
+		}
+	}
+
+	summary := inspect.SummarizeDelta(header, deltas, counter.n, newSize)
+	if c.Bool("json") {
+		return inspect.WriteJSON(out, summary)
+	}
+	return inspect.WriteDeltaSummary(out, summary)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
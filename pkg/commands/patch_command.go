@@ -0,0 +1,322 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/urfave/cli"
+)
+
+func NewPatchCommand() cli.Command {
+	return cli.Command{
+		Name:  "patch",
+		Usage: "Recreates new version of file based on basisFile and deltaFile",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:     "basisFile",
+				Usage:    "Path to previous version of file, for which signature was calculated, '-' reads stdin",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "basisDir",
+				Usage:    "Directory with previous version of files, it is updated in place. Used instead of basisFile",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "deltaFile",
+				Usage:    "Path to delta file calculated for new version of file, '-' reads stdin",
+				Required: true,
+			},
+			cli.BoolFlag{
+				Name:  "noOwner",
+				Usage: "Do not restore owner of files with basisDir, needed when not running as root",
+			},
+			cli.BoolFlag{
+				Name:  "noXattrs",
+				Usage: "Do not restore extended attributes of files with basisDir",
+			},
+			cli.StringSliceFlag{
+				Name:  "extraBasisFile",
+				Usage: "Additional basis file (basis 1, 2, ... in order of flags) for delta calculated with extra signatures, can be repeated",
+			},
+			cli.StringFlag{
+				Name:  "reverseDeltaFile",
+				Usage: "File to which delta turning new version back into basis will be saved",
+			},
+			cli.StringFlag{
+				Name:  "basisSignatureFile",
+				Usage: "Signature of basis, with newSignatureFile hashes of chunks copied from basis are reused",
+			},
+			cli.StringFlag{
+				Name:  "newSignatureFile",
+				Usage: "File to which signature of new version of file will be saved, requires basisSignatureFile",
+			},
+			cli.StringFlag{
+				Name:     "outputFile",
+				Usage:    "File to which new version of file will be saved, if not provided or '-' it is written to stdout",
+				Required: false,
+			},
+		}, append(checkpointFlags(), formatFlag())...),
+		Action: func(c *cli.Context) error {
+			if err := requireOneOf(c, "basisFile", "basisDir"); err != nil {
+				return err
+			}
+
+			format, err := fileFormat(c)
+			if err != nil {
+				return err
+			}
+
+			extraBases := c.StringSlice("extraBasisFile")
+			if len(extraBases) > 0 && (format != nativeFormat || c.IsSet("basisDir") || c.IsSet("checkpointFile") ||
+				c.IsSet("reverseDeltaFile") || c.IsSet("newSignatureFile")) {
+				return fmt.Errorf("extra basis files are supported only for single file in native format without checkpoints, reverse delta and new signature")
+			}
+
+			if c.IsSet("basisDir") {
+				if c.IsSet("checkpointFile") {
+					return fmt.Errorf("checkpoints are not supported for directories")
+				}
+				return treePatch(c)
+			}
+
+			if err := requireSingleStdin(c, "basisFile", "deltaFile", "basisSignatureFile"); err != nil {
+				return err
+			}
+
+			switch format {
+			case rdiffFormat:
+				return rdiffPatch(c)
+			case vcdiffFormat:
+				return vcdiffPatch(c)
+			}
+
+			basis, err := getSeekableFile(c, "basisFile")
+			if err != nil {
+				return err
+			}
+			defer basis.Close()
+
+			deltaFile, err := getFile(c, "deltaFile")
+			if err != nil {
+				return err
+			}
+			defer deltaFile.Close()
+
+			useCheckpoints := c.IsSet("checkpointFile")
+			if c.Bool("resume") && !useCheckpoints {
+				return fmt.Errorf("resume requires checkpointFile")
+			}
+
+			if useCheckpoints && (isStdout(c, "outputFile") || c.String("deltaFile") == stdio) {
+				return fmt.Errorf("checkpoints require deltaFile and outputFile which are not stdin or stdout")
+			}
+
+			withReverse := c.IsSet("reverseDeltaFile")
+			if withReverse && useCheckpoints {
+				return fmt.Errorf("reverse delta is not supported with checkpoints")
+			}
+
+			if withReverse && isStdout(c, "outputFile") && isStdout(c, "reverseDeltaFile") {
+				return fmt.Errorf("outputFile and reverseDeltaFile cannot be both written to stdout")
+			}
+
+			withSignature := c.IsSet("newSignatureFile")
+			if withSignature != c.IsSet("basisSignatureFile") {
+				return fmt.Errorf("newSignatureFile and basisSignatureFile have to be used together")
+			}
+
+			if withSignature && (useCheckpoints || withReverse) {
+				return fmt.Errorf("new signature is not supported with checkpoints and reverse delta")
+			}
+
+			if withSignature && isStdout(c, "outputFile") && isStdout(c, "newSignatureFile") {
+				return fmt.Errorf("outputFile and newSignatureFile cannot be both written to stdout")
+			}
+
+			var basisChunks []sync.Chunk
+			if withSignature {
+				sigFile, err := getFile(c, "basisSignatureFile")
+				if err != nil {
+					return err
+				}
+				defer sigFile.Close()
+
+				basisChunks, err = sync.DeserializeChunks(sigFile)
+				if err != nil {
+					return fmt.Errorf("unable to deserialize basis signature file. %w", err)
+				}
+			}
+
+			var basisSize int64
+			var deltaDigest []byte
+			if useCheckpoints {
+				basisSize, err = fileSize(basis)
+				if err != nil {
+					return fmt.Errorf("unable to read basis file size. %w", err)
+				}
+
+				deltaDigest, err = fileDigest(deltaFile)
+				if err != nil {
+					return fmt.Errorf("unable to calculate delta file digest. %w", err)
+				}
+			}
+
+			deltas, err := sync.DeserializeDelta(deltaFile)
+			if err != nil {
+				return fmt.Errorf("unable to deserialize delta file. %w", err)
+			}
+
+			if useCheckpoints && copiesWrittenData(deltas) {
+				return fmt.Errorf("checkpoints are not supported for compressed delta and delta with target copies")
+			}
+
+			from := sync.Checkpoint{}
+			checkpointPath := c.String("checkpointFile")
+			if c.Bool("resume") {
+				from, err = loadCheckpoint(checkpointPath, basisSize, deltaDigest)
+				if err != nil {
+					return err
+				}
+			}
+
+			out := os.Stdout
+			if !isStdout(c, "outputFile") {
+				out, err = os.OpenFile(c.String("outputFile"), os.O_WRONLY|os.O_CREATE, 0644)
+				if err != nil {
+					return fmt.Errorf("unable to open output file. %w", err)
+				}
+				defer out.Close()
+
+				// drop data which was written after last checkpoint
+				if err := out.Truncate(from.OutputOffset); err != nil {
+					return fmt.Errorf("unable to truncate output file. %w", err)
+				}
+
+				if _, err := out.Seek(from.OutputOffset, io.SeekStart); err != nil {
+					return fmt.Errorf("unable to seek output file. %w", err)
+				}
+			}
+
+			// size of new file is kept in header of new signature
+			counter := &countingWriter{w: out}
+			writer := bufio.NewWriter(counter)
+			var handleCheckpoint sync.CheckpointHandler
+			if useCheckpoints {
+				lastSaved := from.OutputOffset
+				handleCheckpoint = func(checkpoint sync.Checkpoint) error {
+					if checkpoint.OutputOffset-lastSaved < checkpointInterval {
+						return nil
+					}
+
+					if err := writer.Flush(); err != nil {
+						return err
+					}
+
+					if err := out.Sync(); err != nil {
+						return err
+					}
+
+					checkpoint.InputSize = basisSize
+					checkpoint.SourceDigest = deltaDigest
+					lastSaved = checkpoint.OutputOffset
+
+					return saveCheckpoint(checkpointPath, checkpoint)
+				}
+			}
+
+			s := sync.New()
+			reverse := []sync.Delta{}
+			chunks := []sync.Chunk{}
+			if withReverse {
+				err = s.PatchWithReverse(basis, deltas, writer, func(d sync.Delta) {
+					reverse = append(reverse, d)
+				})
+			} else if len(extraBases) > 0 {
+				err = patchFromBases(basis, extraBases, deltas, writer)
+			} else if withSignature {
+				err = s.PatchWithSignature(basis, basisChunks, deltas, writer, func(c sync.Chunk) {
+					chunks = append(chunks, c)
+				})
+			} else {
+				err = s.PatchFrom(basis, deltas, from, writer, handleCheckpoint)
+			}
+
+			if err != nil {
+				return fmt.Errorf("error while applying delta. %w", err)
+			}
+
+			if err := writer.Flush(); err != nil {
+				return fmt.Errorf("unable to write output file. %w", err)
+			}
+
+			if withReverse {
+				serializedDeltaReader, err := sync.SerializeDeltas(reverse)
+				if err != nil {
+					return fmt.Errorf("unable to serialize reverse deltas. %w", err)
+				}
+
+				serializedDeltas, err := io.ReadAll(serializedDeltaReader)
+				if err != nil {
+					return fmt.Errorf("unable to read serialized reverse deltas. %w", err)
+				}
+
+				if err := writeOutput(c, "reverseDeltaFile", serializedDeltas); err != nil {
+					return fmt.Errorf("unable to write reverse delta file. %w", err)
+				}
+			}
+
+			if withSignature {
+				serializedChunks, err := serializeSignature(chunks, counter.n)
+				if err != nil {
+					return fmt.Errorf("unable to serialize new signature. %w", err)
+				}
+
+				if err := writeOutput(c, "newSignatureFile", serializedChunks); err != nil {
+					return fmt.Errorf("unable to write new signature file. %w", err)
+				}
+			}
+
+			if useCheckpoints {
+				os.Remove(checkpointPath)
+			}
+
+			return nil
+		},
+	}
+}
+
+// patchFromBases applies multi-basis delta, basis is basis 0 and extra bases are bases 1, 2, ... in order
+func patchFromBases(basis io.ReadSeeker, extraBases []string, deltas []sync.Delta, out io.Writer) error {
+	bases := map[uint32]io.ReadSeeker{0: basis}
+	for i, path := range extraBases {
+		if path == stdio {
+			return fmt.Errorf("extra basis file cannot be read from stdin")
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("unable to open basis file '%s'. %w", path, err)
+		}
+		defer file.Close()
+
+		bases[uint32(i+1)] = file
+	}
+
+	s := sync.New()
+	return s.PatchFromBases(bases, deltas, out)
+}
+
+// copiesWrittenData checks if patching depends on previously written data
+func copiesWrittenData(deltas []sync.Delta) bool {
+	for _, d := range deltas {
+		if d.Operation == sync.CompressedData || d.Operation == sync.TargetCopy {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/remote"
+	"github.com/urfave/cli"
+)
+
+func NewPullCommand() cli.Command {
+	return cli.Command{
+		Name:  "pull",
+		Usage: "Updates outputFile with file from daemon, only changes against local version are transferred",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:     "remoteFile",
+				Usage:    "Path relative to daemon root of file which should be downloaded",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:     "outputFile",
+				Usage:    "Local file which is updated, it is created if it does not exist",
+				Required: true,
+			},
+		}, remoteFlags()...),
+		Action: func(c *cli.Context) error {
+			if c.String("outputFile") == stdio {
+				return fmt.Errorf("outputFile is used as basis, so it cannot be stdout")
+			}
+
+			conn, err := connect(c)
+			if err != nil {
+				return err
+			}
+
+			err = remote.Pull(conn, c.String("remoteFile"), c.String("outputFile"))
+			return closeConnection(conn, err)
+		},
+	}
+}
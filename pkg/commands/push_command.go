@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/remote"
+	"github.com/urfave/cli"
+)
+
+func NewPushCommand() cli.Command {
+	return cli.Command{
+		Name:  "push",
+		Usage: "Sends inputFile to daemon, only changes against daemon version are transferred",
+		Flags: append([]cli.Flag{
+			cli.StringFlag{
+				Name:     "inputFile",
+				Usage:    "File which should be sent, '-' reads stdin",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:     "remoteFile",
+				Usage:    "Path relative to daemon root under which file is saved",
+				Required: true,
+			},
+		}, remoteFlags()...),
+		Action: func(c *cli.Context) error {
+			conn, err := connect(c)
+			if err != nil {
+				return err
+			}
+
+			if c.String("inputFile") == stdio {
+				err = remote.PushData(conn, os.Stdin, c.String("remoteFile"))
+			} else {
+				err = remote.Push(conn, c.String("inputFile"), c.String("remoteFile"))
+			}
+			return closeConnection(conn, err)
+		},
+	}
+}
@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/librsync"
+	"github.com/urfave/cli"
+)
+
+var rdiffSignatureTypes = map[string]librsync.Magic{
+	"md4":       librsync.MD4SigMagic,
+	"blake2":    librsync.Blake2SigMagic,
+	"rk-md4":    librsync.RabinKarpMD4SigMagic,
+	"rk-blake2": librsync.RabinKarpBlake2SigMagic,
+}
+
+func rdiffSignatureFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "rdiffSignature",
+			Usage: "Checksums used by rdiff signature: md4, blake2, rk-md4 or rk-blake2",
+			Value: "rk-blake2",
+		},
+		cli.UintFlag{
+			Name:  "blockLen",
+			Usage: "Block length of rdiff signature",
+			Value: librsync.DefaultBlockLen,
+		},
+		cli.UintFlag{
+			Name:  "strongLen",
+			Usage: "Length of strong checksum of rdiff signature, 0 means full length",
+		},
+	}
+}
+
+func rdiffSignature(c *cli.Context) error {
+	magic, ok := rdiffSignatureTypes[c.String("rdiffSignature")]
+	if !ok {
+		return fmt.Errorf("unknown rdiff signature type '%s'", c.String("rdiffSignature"))
+	}
+
+	file, err := getFile(c, "inputFile")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	signature, err := librsync.NewSignature(bufio.NewReader(file), magic, uint32(c.Uint("blockLen")), uint32(c.Uint("strongLen")))
+	if err != nil {
+		return fmt.Errorf("error while calculating signature. %w", err)
+	}
+
+	return writeFile(c.String("signatureFile"), func(out *bufio.Writer) error {
+		_, err := signature.WriteTo(out)
+		return err
+	})
+}
+
+func rdiffDelta(c *cli.Context) error {
+	sigFile, err := getFile(c, "signatureFile")
+	if err != nil {
+		return err
+	}
+	defer sigFile.Close()
+
+	signature, err := librsync.ReadSignature(sigFile)
+	if err != nil {
+		return fmt.Errorf("unable to read signature file. %w", err)
+	}
+
+	file, err := getFile(c, "inputFile")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return writeFile(c.String("deltaFile"), func(out *bufio.Writer) error {
+		return librsync.Delta(signature, bufio.NewReader(file), out)
+	})
+}
+
+func rdiffPatch(c *cli.Context) error {
+	basis, err := getSeekableFile(c, "basisFile")
+	if err != nil {
+		return err
+	}
+	defer basis.Close()
+
+	deltaFile, err := getFile(c, "deltaFile")
+	if err != nil {
+		return err
+	}
+	defer deltaFile.Close()
+
+	return writeFile(c.String("outputFile"), func(out *bufio.Writer) error {
+		return librsync.Patch(basis, deltaFile, out)
+	})
+}
+
+// writeFile creates file and writes it with buffered writer, empty path or stdio means stdout
+func writeFile(path string, write func(out *bufio.Writer) error) error {
+	if path == "" || path == stdio {
+		out := bufio.NewWriter(os.Stdout)
+		if err := write(out); err != nil {
+			return err
+		}
+		return out.Flush()
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("unable to create file '%s'. %w", path, err)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	if err := write(out); err != nil {
+		return err
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("unable to write file '%s'. %w", path, err)
+	}
+
+	return file.Close()
+}
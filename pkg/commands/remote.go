@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/remote"
+	"github.com/urfave/cli"
+)
+
+func remoteFlags() []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:  "address",
+			Usage: "Address of daemon",
+			Value: "localhost:7070",
+		},
+		cli.StringFlag{
+			Name:     "command",
+			Usage:    "Command which runs 'sync serve --server' on other side of pipe (for example 'ssh host sync serve --server --root dir'), used instead of address",
+			Required: false,
+		},
+	}
+}
+
+// connect spawns command when it is provided, otherwise connects to daemon
+func connect(c *cli.Context) (io.ReadWriteCloser, error) {
+	if c.IsSet("command") {
+		return remote.Spawn(c.String("command"))
+	}
+
+	conn, err := net.Dial("tcp", c.String("address"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to daemon. %w", err)
+	}
+
+	return conn, nil
+}
+
+// closeConnection returns err when it is set, otherwise error of closing connection
+func closeConnection(conn io.Closer, err error) error {
+	closeErr := conn.Close()
+	if err != nil {
+		return err
+	}
+
+	return closeErr
+}
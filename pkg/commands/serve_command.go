@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/remote"
+	"github.com/urfave/cli"
+)
+
+func NewServeCommand() cli.Command {
+	return cli.Command{
+		Name:  "serve",
+		Usage: "Runs daemon which keeps basis files and synchronizes them with push and pull commands",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:     "root",
+				Usage:    "Directory with files served by daemon",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address on which daemon listens",
+				Value: ":7070",
+			},
+			cli.BoolFlag{
+				Name:  "server",
+				Usage: "Handle single session on stdin and stdout instead of listening, used by push and pull with command flag",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			server := remote.NewServer(c.String("root"))
+			if c.Bool("server") {
+				return server.Handle(remote.Stdio())
+			}
+
+			listener, err := net.Listen("tcp", c.String("listen"))
+			if err != nil {
+				return fmt.Errorf("unable to listen. %w", err)
+			}
+			defer listener.Close()
+
+			log.Printf("serving '%s' on %s", c.String("root"), listener.Addr())
+			return server.Serve(listener)
+		},
+	}
+}
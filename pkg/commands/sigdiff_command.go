@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/inspect"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/urfave/cli"
+)
+
+func NewSigdiffCommand() cli.Command {
+	return cli.Command{
+		Name:  "sigdiff",
+		Usage: "Compares signatures of two files and estimates how much data differs, without reading files",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:     "firstSignatureFile",
+				Usage:    "Signature of first (old) file, '-' reads stdin",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:     "secondSignatureFile",
+				Usage:    "Signature of second (new) file, '-' reads stdin",
+				Required: true,
+			},
+			cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print output as JSON",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := requireSingleStdin(c, "firstSignatureFile", "secondSignatureFile"); err != nil {
+				return err
+			}
+
+			first, err := readSignatureFile(c, "firstSignatureFile")
+			if err != nil {
+				return err
+			}
+
+			second, err := readSignatureFile(c, "secondSignatureFile")
+			if err != nil {
+				return err
+			}
+
+			diff, err := inspect.DiffSignatures(first, second)
+			if err != nil {
+				return fmt.Errorf("unable to compare signatures. %w", err)
+			}
+
+			if c.Bool("json") {
+				return inspect.WriteJSON(os.Stdout, diff)
+			}
+			return inspect.WriteSignatureDiff(os.Stdout, diff)
+		},
+	}
+}
+
+func readSignatureFile(c *cli.Context, name string) (inspect.Signature, error) {
+	file, err := getFile(c, name)
+	if err != nil {
+		return inspect.Signature{}, err
+	}
+	defer file.Close()
+
+	header, rest, err := sync.ReadSignatureHeader(file)
+	if err != nil {
+		return inspect.Signature{}, fmt.Errorf("unable to read header of signature file '%s'. %w", c.String(name), err)
+	}
+
+	chunks, err := sync.DeserializeChunks(rest)
+	if err != nil {
+		return inspect.Signature{}, fmt.Errorf("unable to deserialize signature file '%s'. %w", c.String(name), err)
+	}
+
+	return inspect.Signature{Header: header, Chunks: chunks}, nil
+}
@@ -1,9 +1,8 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
-	"io/ioutil"
-	"os"
 
 	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
 	"github.com/urfave/cli"
@@ -13,19 +12,44 @@ func NewSignatureCommand() cli.Command {
 	return cli.Command{
 		Name:  "signature",
 		Usage: "Creates signature of a file",
-		Flags: []cli.Flag{
+		Flags: append([]cli.Flag{
 			cli.StringFlag{
 				Name:     "inputFile",
-				Usage:    "File path for which signature should be calculated",
-				Required: true,
+				Usage:    "File path for which signature should be calculated, '-' reads stdin",
+				Required: false,
+			},
+			cli.StringFlag{
+				Name:     "inputDir",
+				Usage:    "Directory for which signature of all files should be calculated, used instead of inputFile",
+				Required: false,
 			},
 			cli.StringFlag{
 				Name:     "signatureFile",
-				Usage:    "File to which signature will be saved, if not provider it will be printed out",
+				Usage:    "File to which signature will be saved, if not provided or '-' it is written to stdout",
 				Required: false,
 			},
-		},
+		}, append(append(filterFlags(), formatFlag()), rdiffSignatureFlags()...)...),
 		Action: func(c *cli.Context) error {
+			if err := requireOneOf(c, "inputFile", "inputDir"); err != nil {
+				return err
+			}
+
+			format, err := fileFormat(c)
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case rdiffFormat:
+				return rdiffSignature(c)
+			case vcdiffFormat:
+				return fmt.Errorf("vcdiff deltas are calculated against native signature")
+			}
+
+			if c.IsSet("inputDir") {
+				return treeSignature(c)
+			}
+
 			file, err := getFile(c, "inputFile")
 			if err != nil {
 				return err
@@ -34,8 +58,10 @@ func NewSignatureCommand() cli.Command {
 
 			s := sync.New()
 
+			// size of file is kept in signature header
+			counter := &countingReader{r: file}
 			chunkList := []sync.Chunk{}
-			err = s.Signature(file, func(c sync.Chunk) {
+			err = s.Signature(counter, func(c sync.Chunk) {
 				chunkList = append(chunkList, c)
 			})
 
@@ -43,23 +69,31 @@ func NewSignatureCommand() cli.Command {
 				return fmt.Errorf("error while calculating signature. %w", err)
 			}
 
-			serializedChunksReader, err := sync.SerializeChunks(chunkList)
+			serializedChunks, err := serializeSignature(chunkList, counter.n)
 			if err != nil {
-				return fmt.Errorf("unable to serialize data chunks. %w", err)
+				return err
 			}
 
-			serializedChunks, err := ioutil.ReadAll(serializedChunksReader)
-			if err != nil {
-				return fmt.Errorf("unable to read serialized data chunks. %w", err)
-			}
+			return writeOutput(c, "signatureFile", serializedChunks)
+		},
+	}
+}
 
-			if c.IsSet("signatureFile") {
-				outputFile := c.String("signatureFile")
-				return os.WriteFile(outputFile, serializedChunks, os.ModePerm)
-			}
+// serializeSignature serializes chunks of file of given size with signature header
+func serializeSignature(chunks []sync.Chunk, size int64) ([]byte, error) {
+	serialized := bytes.Buffer{}
+	if err := sync.WriteSignatureHeader(&serialized, sync.NewSignatureHeader(sync.DefaultChunkSize(), size)); err != nil {
+		return nil, fmt.Errorf("unable to write signature header. %w", err)
+	}
 
-			fmt.Printf("%+v", serializedChunks)
-			return nil
-		},
+	serializedChunksReader, err := sync.SerializeChunks(chunks)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialize data chunks. %w", err)
 	}
+
+	if _, err := serialized.ReadFrom(serializedChunksReader); err != nil {
+		return nil, fmt.Errorf("unable to read serialized data chunks. %w", err)
+	}
+
+	return serialized.Bytes(), nil
 }
@@ -0,0 +1,79 @@
+package commands
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/urfave/cli"
+)
+
+func NewSquashCommand() cli.Command {
+	return cli.Command{
+		Name:  "squash",
+		Usage: "Merges two consecutive deltas into single delta against basis of first one, without reading files",
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:     "firstDeltaFile",
+				Usage:    "Delta which turns basis into middle version, '-' reads stdin",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:     "secondDeltaFile",
+				Usage:    "Delta which turns middle version into new version, '-' reads stdin",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "deltaFile",
+				Usage: "File to which merged delta will be saved, if not provided or '-' it is written to stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := requireSingleStdin(c, "firstDeltaFile", "secondDeltaFile"); err != nil {
+				return err
+			}
+
+			first, err := readDeltaFile(c, "firstDeltaFile")
+			if err != nil {
+				return err
+			}
+
+			second, err := readDeltaFile(c, "secondDeltaFile")
+			if err != nil {
+				return err
+			}
+
+			deltas, err := sync.ComposeDeltas(first, second)
+			if err != nil {
+				return fmt.Errorf("unable to merge deltas. %w", err)
+			}
+
+			serializedDeltaReader, err := sync.SerializeDeltas(deltas)
+			if err != nil {
+				return fmt.Errorf("unable to serialize deltas chunks. %w", err)
+			}
+
+			serializedDeltas, err := ioutil.ReadAll(serializedDeltaReader)
+			if err != nil {
+				return fmt.Errorf("unable to read serialized deltas chunks. %w", err)
+			}
+
+			return writeOutput(c, "deltaFile", serializedDeltas)
+		},
+	}
+}
+
+func readDeltaFile(c *cli.Context, name string) ([]sync.Delta, error) {
+	file, err := getFile(c, name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	deltas, err := sync.DeserializeDelta(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize delta file '%s'. %w", name, err)
+	}
+
+	return deltas, nil
+}
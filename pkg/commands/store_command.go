@@ -0,0 +1,225 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/store"
+	"github.com/urfave/cli"
+)
+
+func storeFlag() cli.Flag {
+	return cli.StringFlag{
+		Name:     "store",
+		Usage:    "Directory of chunk store",
+		Required: true,
+	}
+}
+
+func NewBackupCommand() cli.Command {
+	return cli.Command{
+		Name:  "backup",
+		Usage: "Saves snapshot of inputFile in chunk store, chunks which are already stored are not saved again",
+		Flags: []cli.Flag{
+			storeFlag(),
+			cli.StringFlag{
+				Name:     "inputFile",
+				Usage:    "File which should be backed up, '-' reads stdin",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of snapshot, by default name of inputFile",
+			},
+			cli.IntFlag{
+				Name:  "chunkSize",
+				Usage: "Chunk size used when store is created",
+				Value: store.DefaultChunkSize,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			name := c.String("name")
+			if name == "" {
+				name = filepath.Base(c.String("inputFile"))
+			}
+
+			file, err := getFile(c, "inputFile")
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+
+			s, err := store.OpenOrInit(c.String("store"), c.Int("chunkSize"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			snapshot, stats, err := s.Backup(name, bufio.NewReader(file))
+			if err != nil {
+				return fmt.Errorf("unable to backup file. %w", err)
+			}
+
+			log.Printf("saved snapshot %d of '%s', %d of %d chunks were new (%d bytes)",
+				snapshot.Id, snapshot.Name, stats.NewChunks, stats.Chunks, stats.NewBytes)
+			return s.Close()
+		},
+	}
+}
+
+func NewRestoreCommand() cli.Command {
+	return cli.Command{
+		Name:  "restore",
+		Usage: "Recreates file from snapshot in chunk store",
+		Flags: []cli.Flag{
+			storeFlag(),
+			cli.UintFlag{
+				Name:     "snapshot",
+				Usage:    "Id of snapshot which should be restored",
+				Required: true,
+			},
+			cli.StringFlag{
+				Name:  "outputFile",
+				Usage: "File to which snapshot will be restored, if not provided or '-' it is written to stdout",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			s, err := store.Open(c.String("store"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			return writeFile(c.String("outputFile"), func(out *bufio.Writer) error {
+				return s.Restore(uint32(c.Uint("snapshot")), out)
+			})
+		},
+	}
+}
+
+func NewLsCommand() cli.Command {
+	return cli.Command{
+		Name:  "ls",
+		Usage: "Lists snapshots in chunk store",
+		Flags: []cli.Flag{
+			storeFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			s, err := store.Open(c.String("store"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			snapshots, err := s.Snapshots()
+			if err != nil {
+				return err
+			}
+
+			tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tTIME\tNAME\tSIZE\tCHUNKS")
+			for _, snapshot := range snapshots {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\n", snapshot.Id, snapshot.Time.Format(time.RFC3339),
+					snapshot.Name, snapshot.Size, len(snapshot.Chunks))
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func NewForgetCommand() cli.Command {
+	return cli.Command{
+		Name:  "forget",
+		Usage: "Removes snapshot from chunk store, its data is removed by gc",
+		Flags: []cli.Flag{
+			storeFlag(),
+			cli.UintFlag{
+				Name:     "snapshot",
+				Usage:    "Id of snapshot which should be removed",
+				Required: true,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			s, err := store.Open(c.String("store"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			return s.DeleteSnapshot(uint32(c.Uint("snapshot")))
+		},
+	}
+}
+
+func NewGCCommand() cli.Command {
+	return cli.Command{
+		Name:  "gc",
+		Usage: "Removes chunks which are not used by any snapshot and repacks pack files with little live data",
+		Flags: []cli.Flag{
+			storeFlag(),
+			cli.Float64Flag{
+				Name:  "repackThreshold",
+				Usage: "Pack files where share of live data is lower are rewritten",
+				Value: store.DefaultRepackThreshold,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			s, err := store.Open(c.String("store"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			stats, err := s.GC(c.Float64("repackThreshold"))
+			if err != nil {
+				return fmt.Errorf("unable to collect garbage. %w", err)
+			}
+
+			log.Printf("removed %d chunks and %d packs, repacked %d packs, reclaimed %d bytes",
+				stats.RemovedChunks, stats.RemovedPacks, stats.RepackedPacks, stats.ReclaimedBytes)
+			return s.Close()
+		},
+	}
+}
+
+func NewCheckCommand() cli.Command {
+	return cli.Command{
+		Name:  "check",
+		Usage: "Verifies hashes of all chunks in chunk store and reports chunks missing for snapshots",
+		Flags: []cli.Flag{
+			storeFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			s, err := store.Open(c.String("store"))
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			report, err := s.Check()
+			if err != nil {
+				return fmt.Errorf("unable to check store. %w", err)
+			}
+
+			for _, corrupted := range report.Corrupted {
+				fmt.Printf("corrupted chunk %s: %v\n", corrupted.Key, corrupted.Err)
+			}
+
+			for _, missing := range report.Missing {
+				fmt.Printf("missing chunk %s of snapshot %d\n", missing.Key, missing.Snapshot)
+			}
+
+			fmt.Printf("checked %d chunks of %d snapshots\n", report.Chunks, report.Snapshots)
+			if !report.Ok() {
+				return fmt.Errorf("store has %d corrupted and %d missing chunks", len(report.Corrupted), len(report.Missing))
+			}
+
+			return nil
+		},
+	}
+}
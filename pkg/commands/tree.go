@@ -0,0 +1,204 @@
+package commands
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"io/ioutil"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/filter"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/tree"
+	"github.com/urfave/cli"
+)
+
+// filterRule is include or exclude rule given in command line
+type filterRule struct {
+	include bool
+	pattern string
+}
+
+// filterRuleFlag is value of include or exclude flag, both flags append to the same list,
+// so rules keep order in which they were given in command line
+type filterRuleFlag struct {
+	include bool
+	rules   *[]filterRule
+}
+
+func (f *filterRuleFlag) Set(pattern string) error {
+	*f.rules = append(*f.rules, filterRule{include: f.include, pattern: pattern})
+	return nil
+}
+
+func (f *filterRuleFlag) String() string {
+	return ""
+}
+
+// filterFlag is include or exclude flag, rules list is created when command line is parsed,
+// so every invocation of command has its own rules
+type filterFlag struct {
+	cli.GenericFlag
+	include bool
+}
+
+func (f filterFlag) Apply(set *flag.FlagSet) {
+	f.ApplyWithError(set)
+}
+
+// ApplyWithError registers flag value with new rules list, or with list of the other filter flag when it was registered before
+func (f filterFlag) ApplyWithError(set *flag.FlagSet) error {
+	rules := &[]filterRule{}
+	for _, name := range []string{"include", "exclude"} {
+		if registered := set.Lookup(name); registered != nil {
+			if ruleFlag, ok := registered.Value.(*filterRuleFlag); ok {
+				rules = ruleFlag.rules
+			}
+		}
+	}
+
+	set.Var(&filterRuleFlag{include: f.include, rules: rules}, f.Name, f.Usage)
+	return nil
+}
+
+func filterFlags() []cli.Flag {
+	return []cli.Flag{
+		filterFlag{
+			GenericFlag: cli.GenericFlag{
+				Name:  "include",
+				Usage: "Pattern of paths which should be included even if they are excluded by later rules, can be repeated",
+				Value: &filterRuleFlag{include: true, rules: &[]filterRule{}},
+			},
+			include: true,
+		},
+		filterFlag{
+			GenericFlag: cli.GenericFlag{
+				Name:  "exclude",
+				Usage: "Pattern of paths which should be skipped unless they are included by earlier rules, can be repeated",
+				Value: &filterRuleFlag{include: false, rules: &[]filterRule{}},
+			},
+			include: false,
+		},
+		cli.StringFlag{
+			Name:     "filterFile",
+			Usage:    "File with filter rules, one per line in '+ pattern' or '- pattern' format",
+			Required: false,
+		},
+	}
+}
+
+// newTreeFilter creates filter from flags, include and exclude rules are checked in order
+// in which they were given (first matching rule wins), rules from filterFile are checked after them
+func newTreeFilter(c *cli.Context, fsys fs.FS) (*filter.Filter, error) {
+	f := filter.New(fsys)
+
+	if ruleFlag, ok := c.Generic("include").(*filterRuleFlag); ok {
+		for _, rule := range *ruleFlag.rules {
+			add := f.Exclude
+			if rule.include {
+				add = f.Include
+			}
+
+			if err := add(rule.pattern); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if c.IsSet("filterFile") {
+		filterFile, err := getFile(c, "filterFile")
+		if err != nil {
+			return nil, err
+		}
+		defer filterFile.Close()
+
+		if err := f.AddRules(filterFile); err != nil {
+			return nil, fmt.Errorf("unable to read filter file. %w", err)
+		}
+	}
+
+	return f, nil
+}
+
+func treeSignature(c *cli.Context) error {
+	fsys := tree.DirFS(c.String("inputDir"))
+	treeFilter, err := newTreeFilter(c, fsys)
+	if err != nil {
+		return err
+	}
+
+	manifest, err := tree.Signature(fsys, treeFilter)
+	if err != nil {
+		return fmt.Errorf("error while calculating tree signature. %w", err)
+	}
+
+	serializedManifestReader, err := tree.SerializeManifest(manifest)
+	if err != nil {
+		return fmt.Errorf("unable to serialize tree signature. %w", err)
+	}
+
+	serializedManifest, err := ioutil.ReadAll(serializedManifestReader)
+	if err != nil {
+		return fmt.Errorf("unable to read serialized tree signature. %w", err)
+	}
+
+	return writeOutput(c, "signatureFile", serializedManifest)
+}
+
+func treeDelta(c *cli.Context) error {
+	sigFile, err := getFile(c, "signatureFile")
+	if err != nil {
+		return err
+	}
+	defer sigFile.Close()
+
+	manifest, err := tree.DeserializeManifest(sigFile)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize tree signature file. %w", err)
+	}
+
+	fsys := tree.DirFS(c.String("inputDir"))
+	treeFilter, err := newTreeFilter(c, fsys)
+	if err != nil {
+		return err
+	}
+
+	treeDelta, err := tree.Delta(fsys, manifest, treeFilter)
+	if err != nil {
+		return fmt.Errorf("error while calculating tree delta. %w", err)
+	}
+
+	serializedDeltaReader, err := tree.SerializeTreeDelta(treeDelta)
+	if err != nil {
+		return fmt.Errorf("unable to serialize tree delta. %w", err)
+	}
+
+	serializedDelta, err := ioutil.ReadAll(serializedDeltaReader)
+	if err != nil {
+		return fmt.Errorf("unable to read serialized tree delta. %w", err)
+	}
+
+	return writeOutput(c, "deltaFile", serializedDelta)
+}
+
+func treePatch(c *cli.Context) error {
+	deltaFile, err := getFile(c, "deltaFile")
+	if err != nil {
+		return err
+	}
+	defer deltaFile.Close()
+
+	treeDelta, err := tree.DeserializeTreeDelta(deltaFile)
+	if err != nil {
+		return fmt.Errorf("unable to deserialize tree delta file. %w", err)
+	}
+
+	options := tree.PatchOptions{
+		NoOwner:  c.Bool("noOwner"),
+		NoXattrs: c.Bool("noXattrs"),
+	}
+
+	if err := tree.Patch(c.String("basisDir"), treeDelta, options); err != nil {
+		return fmt.Errorf("error while applying tree delta. %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+)
+
+func Test_FilterRulesKeepCommandLineOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		keepExcluded bool
+	}{
+		{"exclude first", []string{"--exclude", "*.log", "--include", "keep.log"}, true},
+		{"include first", []string{"--include", "keep.log", "--exclude", "*.log"}, false},
+		{"mixed with other rules", []string{"--exclude", "*.tmp", "--include", "keep.log", "--exclude=*.log"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			excluded := map[string]bool{}
+
+			app := cli.NewApp()
+			app.Flags = filterFlags()
+			app.Action = func(c *cli.Context) error {
+				f, err := newTreeFilter(c, fstest.MapFS{})
+				if err != nil {
+					return err
+				}
+
+				for _, path := range []string{"keep.log", "other.log", "main.go"} {
+					if excluded[path], err = f.Excluded(path, false); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			require.Nil(t, app.Run(append([]string{"sync"}, test.args...)))
+			require.Equal(t, map[string]bool{"keep.log": test.keepExcluded, "other.log": true, "main.go": false}, excluded)
+		})
+	}
+}
+
+func Test_FilterRulesAreNotSharedBetweenInvocations(t *testing.T) {
+	excluded := false
+
+	app := cli.NewApp()
+	app.Flags = filterFlags()
+	app.Action = func(c *cli.Context) error {
+		f, err := newTreeFilter(c, fstest.MapFS{})
+		if err != nil {
+			return err
+		}
+
+		excluded, err = f.Excluded("app.log", false)
+		return err
+	}
+
+	require.Nil(t, app.Run([]string{"sync", "--exclude", "*.log"}))
+	require.True(t, excluded)
+
+	require.Nil(t, app.Run([]string{"sync", "--include", "other.log"}))
+	require.False(t, excluded)
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/vcdiff"
+	"github.com/urfave/cli"
+)
+
+// vcdiffDelta calculates delta against native signature and writes it as VCDIFF
+func vcdiffDelta(c *cli.Context) error {
+	// size of input has to be known before encoding
+	file, err := getSeekableFile(c, "inputFile")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	size, err := fileSize(file)
+	if err != nil {
+		return fmt.Errorf("unable to read input file size. %w", err)
+	}
+
+	sigFile, err := getFile(c, "signatureFile")
+	if err != nil {
+		return err
+	}
+	defer sigFile.Close()
+
+	return writeFile(c.String("deltaFile"), func(out *bufio.Writer) error {
+		encoder, err := vcdiff.NewEncoder(out, size)
+		if err != nil {
+			return err
+		}
+
+		s := sync.New()
+		var writeErr error
+		err = s.Delta(bufio.NewReader(file), sigFile, func(d sync.Delta) {
+			if writeErr == nil {
+				writeErr = encoder.Write(d)
+			}
+		})
+
+		if err != nil {
+			return fmt.Errorf("error while calculating delta. %w", err)
+		}
+
+		if writeErr != nil {
+			return fmt.Errorf("unable to encode delta. %w", writeErr)
+		}
+
+		return encoder.Close()
+	})
+}
+
+func vcdiffPatch(c *cli.Context) error {
+	basis, err := getSeekableFile(c, "basisFile")
+	if err != nil {
+		return err
+	}
+	defer basis.Close()
+
+	deltaFile, err := getFile(c, "deltaFile")
+	if err != nil {
+		return err
+	}
+	defer deltaFile.Close()
+
+	return writeFile(c.String("outputFile"), func(out *bufio.Writer) error {
+		return vcdiff.Decode(basis, deltaFile, out)
+	})
+}
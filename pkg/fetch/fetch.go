@@ -0,0 +1,237 @@
+package fetch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// chunkSize has to be the same as chunk size used by sync package to calculate signature
+var chunkSize = int64(sync.DefaultChunkSize())
+
+// Range of remote file which is missing locally, End is exclusive
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Stats describes how new file was assembled
+type Stats struct {
+	LocalBytes   int64
+	FetchedBytes int64
+	Requests     int
+}
+
+// Fetcher downloads file published together with its signature, reusing data of local file.
+// Signature is computed for remote file, so all matching happens on client side and server
+// only has to serve static files and support Range requests.
+type Fetcher struct {
+	HTTP *http.Client
+}
+
+func New() *Fetcher {
+	return &Fetcher{HTTP: http.DefaultClient}
+}
+
+// Fetch writes file from url to out. Parts of it found in local are copied from there,
+// missing ones are downloaded with one Range request per continuous part.
+// local can be nil when there is no previous version of file.
+func (f *Fetcher) Fetch(url string, signatureURL string, local io.ReadSeeker, out io.Writer) (Stats, error) {
+	stats := Stats{}
+
+	chunks, err := f.signature(signatureURL)
+	if err != nil {
+		return stats, err
+	}
+
+	size, err := f.size(url)
+	if err != nil {
+		return stats, err
+	}
+	stats.Requests = 2
+
+	if int64(len(chunks)) != chunksCount(size) {
+		return stats, fmt.Errorf("signature has %d chunks, which does not match remote file of size %d", len(chunks), size)
+	}
+
+	found := map[uint32]int64{}
+	if local != nil {
+		s := sync.New()
+		err := s.Matches(local, chunks, func(chunkId uint32, offset int64) {
+			if _, ok := found[chunkId]; !ok {
+				found[chunkId] = offset
+			}
+		})
+		if err != nil {
+			return stats, fmt.Errorf("unable to match local file. %w", err)
+		}
+	}
+
+	missing := MissingRanges(found, size)
+	buffer := make([]byte, chunkSize)
+
+	for id := uint32(0); int64(id) < int64(len(chunks)); {
+		start := int64(id) * chunkSize
+
+		if len(missing) > 0 && missing[0].Start == start {
+			written, err := f.fetchRange(url, missing[0], chunks[id:], out)
+			if err != nil {
+				return stats, err
+			}
+
+			stats.FetchedBytes += written
+			stats.Requests++
+			id += uint32(chunksCount(written))
+			missing = missing[1:]
+			continue
+		}
+
+		length := chunkLength(start, size)
+		if _, err := local.Seek(found[id], io.SeekStart); err != nil {
+			return stats, fmt.Errorf("unable to read local file. %w", err)
+		}
+
+		if _, err := io.ReadFull(local, buffer[:length]); err != nil {
+			return stats, fmt.Errorf("unable to read local file. %w", err)
+		}
+
+		if _, err := out.Write(buffer[:length]); err != nil {
+			return stats, err
+		}
+
+		stats.LocalBytes += length
+		id++
+	}
+
+	return stats, nil
+}
+
+// MissingRanges coalesces chunks which were not found locally into continuous ranges of remote file
+func MissingRanges(found map[uint32]int64, size int64) []Range {
+	ranges := []Range{}
+
+	for id := int64(0); id < chunksCount(size); id++ {
+		if _, ok := found[uint32(id)]; ok {
+			continue
+		}
+
+		start := id * chunkSize
+		end := start + chunkLength(start, size)
+		if len(ranges) > 0 && ranges[len(ranges)-1].End == start {
+			ranges[len(ranges)-1].End = end
+			continue
+		}
+
+		ranges = append(ranges, Range{Start: start, End: end})
+	}
+
+	return ranges
+}
+
+func (f *Fetcher) signature(signatureURL string) ([]sync.Chunk, error) {
+	response, err := f.HTTP.Get(signatureURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to download signature. %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to download signature, unexpected status %s", response.Status)
+	}
+
+	chunks, err := sync.DeserializeChunks(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to deserialize signature. %w", err)
+	}
+
+	return chunks, nil
+}
+
+func (f *Fetcher) size(url string) (int64, error) {
+	response, err := f.HTTP.Head(url)
+	if err != nil {
+		return 0, fmt.Errorf("unable to get size of remote file. %w", err)
+	}
+	response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unable to get size of remote file, unexpected status %s", response.Status)
+	}
+
+	if response.ContentLength < 0 {
+		return 0, fmt.Errorf("server did not send size of remote file")
+	}
+
+	return response.ContentLength, nil
+}
+
+// fetchRange downloads part of remote file and checks it against chunks, first of which starts at r.Start
+func (f *Fetcher) fetchRange(url string, r Range, chunks []sync.Chunk, out io.Writer) (int64, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", r.Start, r.End-1))
+
+	response, err := f.HTTP.Do(request)
+	if err != nil {
+		return 0, fmt.Errorf("unable to download range %d-%d. %w", r.Start, r.End, err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusOK {
+		return 0, fmt.Errorf("server does not support Range requests")
+	}
+
+	if response.StatusCode != http.StatusPartialContent {
+		return 0, fmt.Errorf("unable to download range %d-%d, unexpected status %s", r.Start, r.End, response.Status)
+	}
+
+	counter := &countingWriter{out: out}
+	data := io.TeeReader(io.LimitReader(response.Body, r.End-r.Start), counter)
+
+	var mismatch error
+	s := sync.New()
+	err = s.Signature(data, func(c sync.Chunk) {
+		// rolling hash of last shorter chunk depends on data before it, so only strong hash is compared
+		if mismatch == nil && !bytes.Equal(c.StrongHash, chunks[c.Id].StrongHash) {
+			mismatch = fmt.Errorf("data at offset %d does not match signature, remote file has changed", r.Start+int64(c.Id)*chunkSize)
+		}
+	})
+
+	if err == nil {
+		err = mismatch
+	}
+
+	if err == nil && counter.written != r.End-r.Start {
+		err = fmt.Errorf("range %d-%d is incomplete, received %d bytes", r.Start, r.End, counter.written)
+	}
+
+	return counter.written, err
+}
+
+type countingWriter struct {
+	out     io.Writer
+	written int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.out.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+func chunksCount(size int64) int64 {
+	return (size + chunkSize - 1) / chunkSize
+}
+
+func chunkLength(start int64, size int64) int64 {
+	if size-start < chunkSize {
+		return size - start
+	}
+
+	return chunkSize
+}
@@ -0,0 +1,134 @@
+package fetch
+
+import (
+	"bytes"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+type requestLog struct {
+	ranges []string
+}
+
+func startServer(t *testing.T, data []byte) (*httptest.Server, *requestLog) {
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "file"), data, 0644))
+
+	s := sync.New()
+	chunks := []sync.Chunk{}
+	s.Signature(bytes.NewReader(data), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	})
+
+	signature, err := sync.SerializeChunks(chunks)
+	require.Nil(t, err)
+	signatureFile, err := os.Create(filepath.Join(dir, "file.sig"))
+	require.Nil(t, err)
+	_, err = signatureFile.ReadFrom(signature)
+	require.Nil(t, err)
+	require.Nil(t, signatureFile.Close())
+
+	log := &requestLog{}
+	files := http.FileServer(http.Dir(dir))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			log.ranges = append(log.ranges, r.Header.Get("Range"))
+		}
+		files.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, log
+}
+
+func Test_FetchDownloadsOnlyMissingRanges(t *testing.T) {
+	old := randomData(10000, 1)
+	// new version has changed bytes in two places and is shorter
+	new := append([]byte{}, old[:9990]...)
+	copy(new[100:], []byte("changed"))
+	copy(new[5000:], bytes.Repeat([]byte{'x'}, 40))
+
+	server, log := startServer(t, new)
+
+	out := &bytes.Buffer{}
+	stats, err := New().Fetch(server.URL+"/file", server.URL+"/file.sig", bytes.NewReader(old), out)
+
+	require.Nil(t, err)
+	require.Equal(t, new, out.Bytes())
+	require.Equal(t, []string{"bytes=96-111", "bytes=4992-5039", "bytes=9984-9989"}, log.ranges)
+	require.Equal(t, int64(16+48+6), stats.FetchedBytes)
+	require.Equal(t, int64(len(new))-stats.FetchedBytes, stats.LocalBytes)
+	require.Equal(t, 5, stats.Requests)
+}
+
+func Test_FetchReusesDataMovedInLocalFile(t *testing.T) {
+	new := randomData(4096, 2)
+	old := append(randomData(7, 3), new...)
+
+	server, log := startServer(t, new)
+
+	out := &bytes.Buffer{}
+	stats, err := New().Fetch(server.URL+"/file", server.URL+"/file.sig", bytes.NewReader(old), out)
+
+	require.Nil(t, err)
+	require.Equal(t, new, out.Bytes())
+	require.Empty(t, log.ranges)
+	require.Equal(t, int64(len(new)), stats.LocalBytes)
+}
+
+func Test_FetchWithoutLocalFileDownloadsWholeFile(t *testing.T) {
+	new := randomData(1000, 4)
+	server, log := startServer(t, new)
+
+	out := &bytes.Buffer{}
+	_, err := New().Fetch(server.URL+"/file", server.URL+"/file.sig", nil, out)
+
+	require.Nil(t, err)
+	require.Equal(t, new, out.Bytes())
+	require.Equal(t, []string{"bytes=0-999"}, log.ranges)
+}
+
+func Test_FetchFailsWhenRemoteFileDoesNotMatchSignature(t *testing.T) {
+	new := randomData(1000, 5)
+	server, _ := startServer(t, new)
+
+	changed := append([]byte{}, new...)
+	changed[500]++
+	dir := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "file"), changed, 0644))
+	sig, err := http.Get(server.URL + "/file.sig")
+	require.Nil(t, err)
+	defer sig.Body.Close()
+	signatureFile, err := os.Create(filepath.Join(dir, "file.sig"))
+	require.Nil(t, err)
+	_, err = signatureFile.ReadFrom(sig.Body)
+	require.Nil(t, err)
+	require.Nil(t, signatureFile.Close())
+
+	changedServer := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer changedServer.Close()
+
+	_, err = New().Fetch(changedServer.URL+"/file", changedServer.URL+"/file.sig", nil, &bytes.Buffer{})
+	require.ErrorContains(t, err, "data at offset 496 does not match signature")
+}
+
+func Test_MissingRangesAreCoalesced(t *testing.T) {
+	found := map[uint32]int64{0: 0, 3: 100, 4: 200}
+
+	require.Equal(t, []Range{{Start: 16, End: 48}, {Start: 80, End: 90}}, MissingRanges(found, 90))
+	require.Equal(t, []Range{}, MissingRanges(map[uint32]int64{}, 0))
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
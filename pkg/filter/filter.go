@@ -0,0 +1,162 @@
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// IgnoreFileName is name of per directory file with gitignore like rules
+const IgnoreFileName = ".syncignore"
+
+// Filter decides which paths of tree should be skipped.
+// Include and exclude rules are checked in order in which they were added and first matching rule wins (like in rsync).
+// When none of them matches, rules from IgnoreFileName files are used,
+// those are scoped to directory where the file is and the last matching rule wins (like in gitignore).
+type Filter struct {
+	fsys    fs.FS
+	rules   []pattern
+	ignores map[string][]pattern
+}
+
+func New(fsys fs.FS) *Filter {
+	return &Filter{
+		fsys:    fsys,
+		rules:   []pattern{},
+		ignores: map[string][]pattern{},
+	}
+}
+
+func (f *Filter) Include(rule string) error {
+	return f.add(rule, true)
+}
+
+func (f *Filter) Exclude(rule string) error {
+	return f.add(rule, false)
+}
+
+// AddRules reads rules in rsync filter file format,
+// one rule per line, "+ pattern" includes and "- pattern" excludes
+func (f *Filter) AddRules(rulesReader io.Reader) error {
+	scanner := bufio.NewScanner(rulesReader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(line, "+ "):
+			err = f.Include(line[2:])
+		case strings.HasPrefix(line, "- "):
+			err = f.Exclude(line[2:])
+		default:
+			err = fmt.Errorf("rule '%s' has to start with '+ ' or '- '", line)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (f *Filter) add(rule string, include bool) error {
+	p, ok := parsePattern(rule)
+	if !ok || p.negate {
+		return fmt.Errorf("invalid filter rule '%s'", rule)
+	}
+
+	// for rsync like rules negate means that matching path is included
+	p.negate = include
+	f.rules = append(f.rules, p)
+	return nil
+}
+
+// Excluded checks if slash separated path (relative to tree root) should be skipped,
+// path is also excluded when any of its parent directories is excluded
+func (f *Filter) Excluded(filePath string, isDir bool) (bool, error) {
+	parts := strings.Split(filePath, "/")
+
+	for i := 1; i <= len(parts); i++ {
+		excluded, err := f.excludedEntry(path.Join(parts[:i]...), i < len(parts) || isDir)
+		if err != nil || excluded {
+			return excluded, err
+		}
+	}
+
+	return false, nil
+}
+
+func (f *Filter) excludedEntry(filePath string, isDir bool) (bool, error) {
+	for _, rule := range f.rules {
+		if rule.matches(filePath, isDir) {
+			return !rule.negate, nil
+		}
+	}
+
+	excluded := false
+	dir := "."
+	relPath := filePath
+	parts := strings.Split(filePath, "/")
+
+	for i := 0; i < len(parts); i++ {
+		if i > 0 {
+			dir = path.Join(parts[:i]...)
+			relPath = path.Join(parts[i:]...)
+		}
+
+		rules, err := f.ignoreRules(dir)
+		if err != nil {
+			return false, err
+		}
+
+		for _, rule := range rules {
+			if rule.matches(relPath, isDir) {
+				excluded = !rule.negate
+			}
+		}
+	}
+
+	return excluded, nil
+}
+
+// ignoreRules returns rules from IgnoreFileName placed in dir
+func (f *Filter) ignoreRules(dir string) ([]pattern, error) {
+	if rules, ok := f.ignores[dir]; ok {
+		return rules, nil
+	}
+
+	rules := []pattern{}
+	file, err := f.fsys.Open(path.Join(dir, IgnoreFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		f.ignores[dir] = rules
+		return rules, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to open '%s'. %w", path.Join(dir, IgnoreFileName), err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if rule, ok := parsePattern(scanner.Text()); ok {
+			rules = append(rules, rule)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read '%s'. %w", path.Join(dir, IgnoreFileName), err)
+	}
+
+	f.ignores[dir] = rules
+	return rules, nil
+}
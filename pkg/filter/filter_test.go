@@ -0,0 +1,126 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PatternMatching(t *testing.T) {
+	tests := []struct {
+		name     string
+		pattern  string
+		path     string
+		isDir    bool
+		expected bool
+	}{
+		{"name matches at root", "*.o", "main.o", false, true},
+		{"name matches at any depth", "*.o", "a/b/main.o", false, true},
+		{"name does not match other extension", "*.o", "a/main.c", false, false},
+		{"leading slash anchors to base", "/build", "build", true, true},
+		{"anchored pattern does not match deeper", "/build", "src/build", true, false},
+		{"pattern with middle slash is anchored", "docs/*.md", "docs/a.md", false, true},
+		{"pattern with middle slash does not match deeper", "docs/*.md", "x/docs/a.md", false, false},
+		{"directory only pattern matches directory", "out/", "a/out", true, true},
+		{"directory only pattern does not match file", "out/", "a/out", false, false},
+		{"double star matches any directories", "a/**/z.txt", "a/b/c/z.txt", false, true},
+		{"double star matches no directories", "a/**/z.txt", "a/z.txt", false, true},
+		{"leading double star", "**/tmp", "x/y/tmp", true, true},
+		{"trailing double star", "logs/**", "logs/a/b.log", false, true},
+		{"trailing double star does not match directory itself", "logs/**", "logs", true, false},
+		{"trailing double star matches direct child", "logs/**", "logs/a.log", false, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p, ok := parsePattern(test.pattern)
+			require.True(t, ok)
+			require.Equal(t, test.expected, p.matches(test.path, test.isDir))
+		})
+	}
+}
+
+func Test_ParsePatternSkipsCommentsAndEmptyLines(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "!", "/"} {
+		_, ok := parsePattern(line)
+		require.False(t, ok, "line '%s' should be skipped", line)
+	}
+
+	p, ok := parsePattern(`\#file`)
+	require.True(t, ok)
+	require.True(t, p.matches("#file", false))
+}
+
+func Test_FirstMatchingIncludeOrExcludeWins(t *testing.T) {
+	f := New(fstest.MapFS{})
+	require.Nil(t, f.Include("*.go"))
+	require.Nil(t, f.Exclude("*"))
+
+	assertExcluded(t, f, "main.go", false, false)
+	assertExcluded(t, f, "README.md", false, true)
+}
+
+func Test_ExcludedDirectoryExcludesItsContent(t *testing.T) {
+	f := New(fstest.MapFS{})
+	require.Nil(t, f.Exclude(".git/"))
+
+	assertExcluded(t, f, ".git", true, true)
+	assertExcluded(t, f, ".git/objects/ab", false, true)
+	assertExcluded(t, f, "src/.git/config", false, true)
+	assertExcluded(t, f, "src/.gitignore", false, false)
+}
+
+func Test_AddRulesReadsFilterFile(t *testing.T) {
+	f := New(fstest.MapFS{})
+	err := f.AddRules(strings.NewReader("# keep sources\n+ *.go\n\n- /vendor/\n- *.tmp\n"))
+	require.Nil(t, err)
+
+	assertExcluded(t, f, "vendor/lib/a.tmp", false, true)
+	// directory is excluded before its content is checked, like in rsync
+	assertExcluded(t, f, "vendor/lib/a.go", false, true)
+	assertExcluded(t, f, "lib/a.go", false, false)
+	assertExcluded(t, f, "src/vendor/a.txt", false, false)
+	assertExcluded(t, f, "a.tmp", false, true)
+
+	require.NotNil(t, f.AddRules(strings.NewReader("*.tmp\n")))
+}
+
+func Test_IgnoreFilesUseGitignoreSemantics(t *testing.T) {
+	fsys := fstest.MapFS{
+		".syncignore":          {Data: []byte("*.log\n!important.log\n/build/\n")},
+		"sub/.syncignore":      {Data: []byte("!debug.log\ncache/\n")},
+		"sub/deep/.syncignore": {Data: []byte("/local.txt\n")},
+	}
+	f := New(fsys)
+
+	assertExcluded(t, f, "app.log", false, true)
+	assertExcluded(t, f, "important.log", false, false)
+	assertExcluded(t, f, "x/important.log", false, false)
+	assertExcluded(t, f, "build/out.bin", false, true)
+	assertExcluded(t, f, "sub/build/out.bin", false, false)
+	assertExcluded(t, f, "sub/debug.log", false, false)
+	assertExcluded(t, f, "sub/other.log", false, true)
+	assertExcluded(t, f, "debug.log", false, true)
+	assertExcluded(t, f, "sub/x/cache/a", false, true)
+	assertExcluded(t, f, "cache/a", false, false)
+	assertExcluded(t, f, "sub/deep/local.txt", false, true)
+	assertExcluded(t, f, "sub/deep/more/local.txt", false, false)
+}
+
+func Test_IncludeRuleOverridesIgnoreFile(t *testing.T) {
+	f := New(fstest.MapFS{
+		".syncignore": {Data: []byte("*.log\n")},
+	})
+	require.Nil(t, f.Include("keep.log"))
+
+	assertExcluded(t, f, "keep.log", false, false)
+	assertExcluded(t, f, "other.log", false, true)
+}
+
+func assertExcluded(t *testing.T, f *Filter, path string, isDir bool, expected bool) {
+	excluded, err := f.Excluded(path, isDir)
+	require.Nil(t, err)
+	require.Equal(t, expected, excluded, "unexpected result for '%s'", path)
+}
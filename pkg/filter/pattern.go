@@ -0,0 +1,92 @@
+package filter
+
+import (
+	"path"
+	"strings"
+)
+
+// pattern is single gitignore like rule
+type pattern struct {
+	segments []string
+	negate   bool
+	dirOnly  bool
+}
+
+// parsePattern parses pattern using gitignore syntax:
+// "!" negates pattern, trailing "/" matches only directories,
+// pattern containing "/" is anchored to base directory, otherwise it matches name at any depth,
+// "**" matches any number of directories, trailing "**" matches at least one path component
+func parsePattern(line string) (pattern, bool) {
+	p := pattern{}
+
+	line = strings.TrimRight(line, " \t\r")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\`) {
+		line = line[1:]
+	}
+
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+
+	if line == "" {
+		return p, false
+	}
+
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	p.segments = strings.Split(line, "/")
+	if !anchored {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+
+	return p, true
+}
+
+// matches checks slash separated path relative to pattern base directory
+func (p pattern) matches(relPath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	return matchSegments(p.segments, strings.Split(relPath, "/"))
+}
+
+func matchSegments(patternSegments []string, pathSegments []string) bool {
+	if len(patternSegments) == 0 {
+		return len(pathSegments) == 0
+	}
+
+	if patternSegments[0] == "**" {
+		// trailing "**" matches everything inside directory, but not directory itself
+		if len(patternSegments) == 1 {
+			return len(pathSegments) > 0
+		}
+
+		for i := 0; i <= len(pathSegments); i++ {
+			if matchSegments(patternSegments[1:], pathSegments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(pathSegments) == 0 {
+		return false
+	}
+
+	matched, err := path.Match(patternSegments[0], pathSegments[0])
+	if err != nil || !matched {
+		return false
+	}
+
+	return matchSegments(patternSegments[1:], pathSegments[1:])
+}
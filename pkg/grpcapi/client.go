@@ -0,0 +1,173 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi/syncpb"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"google.golang.org/grpc"
+)
+
+// Client calls Sync gRPC service, requests are sent while responses are received
+type Client struct {
+	sync syncpb.SyncClient
+}
+
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{sync: syncpb.NewSyncClient(conn)}
+}
+
+// Signature calculates signature of file on server
+func (c *Client) Signature(ctx context.Context, file io.Reader, handleChunks sync.ChunkHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.sync.ComputeSignature(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendErr := sendAsync(stream, func() error {
+		return sendFile(file, stream.Send)
+	})
+
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return <-sendErr
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, chunk := range message.GetChunks() {
+			handleChunks(chunkFromProto(chunk))
+		}
+	}
+}
+
+// Delta calculates on server delta of file against signature of its previous version
+func (c *Client) Delta(ctx context.Context, chunks []sync.Chunk, file io.Reader, handleDeltas sync.DeltaHandler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.sync.ComputeDelta(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendErr := sendAsync(stream, func() error {
+		for start := 0; start < len(chunks); start += batchSize {
+			end := start + batchSize
+			if end > len(chunks) {
+				end = len(chunks)
+			}
+
+			batch := &syncpb.Chunks{}
+			for _, chunk := range chunks[start:end] {
+				batch.Chunks = append(batch.Chunks, chunkToProto(chunk))
+			}
+
+			message := &syncpb.ComputeDeltaRequest{Message: &syncpb.ComputeDeltaRequest_Signature{Signature: batch}}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+
+		return sendFile(file, func(data *syncpb.FileData) error {
+			return stream.Send(&syncpb.ComputeDeltaRequest{Message: &syncpb.ComputeDeltaRequest_File{File: data}})
+		})
+	})
+
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return <-sendErr
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, delta := range message.GetDeltas() {
+			handleDeltas(deltaFromProto(delta))
+		}
+	}
+}
+
+// Patch applies deltas to basis on server and writes result to out
+func (c *Client) Patch(ctx context.Context, basis io.Reader, deltas []sync.Delta, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.sync.ApplyPatch(ctx)
+	if err != nil {
+		return err
+	}
+
+	sendErr := sendAsync(stream, func() error {
+		err := sendFile(basis, func(data *syncpb.FileData) error {
+			return stream.Send(&syncpb.ApplyPatchRequest{Message: &syncpb.ApplyPatchRequest_Basis{Basis: data}})
+		})
+		if err != nil {
+			return err
+		}
+
+		for start := 0; start < len(deltas); start += batchSize {
+			end := start + batchSize
+			if end > len(deltas) {
+				end = len(deltas)
+			}
+
+			batch := &syncpb.Deltas{}
+			for _, delta := range deltas[start:end] {
+				batch.Deltas = append(batch.Deltas, deltaToProto(delta))
+			}
+
+			message := &syncpb.ApplyPatchRequest{Message: &syncpb.ApplyPatchRequest_Delta{Delta: batch}}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			return <-sendErr
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := out.Write(message.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+// sendAsync sends requests in background, so responses can be received at the same time.
+// Error of sending requests is available in returned channel once send finished and stream was closed.
+func sendAsync(stream grpc.ClientStream, send func() error) <-chan error {
+	result := make(chan error, 1)
+
+	go func() {
+		err := send()
+		if err == io.EOF {
+			// server closed stream, real error will be returned by Recv
+			err = nil
+		}
+
+		if closeErr := stream.CloseSend(); err == nil {
+			err = closeErr
+		}
+		result <- err
+	}()
+
+	return result
+}
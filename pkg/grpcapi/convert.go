@@ -0,0 +1,22 @@
+package grpcapi
+
+import (
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi/syncpb"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+func chunkToProto(c sync.Chunk) *syncpb.Chunk {
+	return &syncpb.Chunk{Id: c.Id, RollingHash: c.RollingHash, StrongHash: c.StrongHash}
+}
+
+func chunkFromProto(c *syncpb.Chunk) sync.Chunk {
+	return sync.Chunk{Id: c.GetId(), RollingHash: c.GetRollingHash(), StrongHash: c.GetStrongHash()}
+}
+
+func deltaToProto(d sync.Delta) *syncpb.Delta {
+	return &syncpb.Delta{Id: d.Id, Operation: syncpb.Operation(d.Operation), Data: d.Data}
+}
+
+func deltaFromProto(d *syncpb.Delta) sync.Delta {
+	return sync.Delta{Id: d.GetId(), Operation: sync.Operation(d.GetOperation()), Data: d.GetData()}
+}
@@ -0,0 +1,180 @@
+package grpcapi
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi/syncpb"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements Sync gRPC service with sync package
+type Server struct {
+	syncpb.UnimplementedSyncServer
+}
+
+func NewServer() *Server {
+	return &Server{}
+}
+
+// Register adds service to grpc server
+func (s *Server) Register(server *grpc.Server) {
+	syncpb.RegisterSyncServer(server, s)
+}
+
+func (s *Server) ComputeSignature(stream syncpb.Sync_ComputeSignatureServer) error {
+	file := &messageReader{next: func() ([]byte, error) {
+		message, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		return message.GetData(), nil
+	}}
+
+	batch := &syncpb.Chunks{}
+	var sendErr error
+	sy := sync.New()
+	err := sy.Signature(file, func(c sync.Chunk) {
+		batch.Chunks = append(batch.Chunks, chunkToProto(c))
+		if len(batch.Chunks) == batchSize && sendErr == nil {
+			sendErr = stream.Send(batch)
+			batch = &syncpb.Chunks{}
+		}
+	})
+
+	if err == nil {
+		err = sendErr
+	}
+
+	if err == nil && len(batch.Chunks) > 0 {
+		err = stream.Send(batch)
+	}
+
+	return err
+}
+
+func (s *Server) ComputeDelta(stream syncpb.Sync_ComputeDeltaServer) error {
+	chunks := []sync.Chunk{}
+	var first []byte
+
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if message.GetFile() != nil {
+			first = message.GetFile().GetData()
+			break
+		}
+
+		for _, c := range message.GetSignature().GetChunks() {
+			chunks = append(chunks, chunkFromProto(c))
+		}
+	}
+
+	file := &messageReader{pending: first, next: func() ([]byte, error) {
+		message, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+
+		if message.GetFile() == nil {
+			return nil, status.Error(codes.InvalidArgument, "signature has to be sent before file")
+		}
+		return message.GetFile().GetData(), nil
+	}}
+
+	batch := &syncpb.Deltas{}
+	var sendErr error
+	sy := sync.New()
+	err := sy.DeltaFromChunks(file, chunks, sync.Checkpoint{}, func(d sync.Delta) {
+		batch.Deltas = append(batch.Deltas, deltaToProto(d))
+		if len(batch.Deltas) == batchSize && sendErr == nil {
+			sendErr = stream.Send(batch)
+			batch = &syncpb.Deltas{}
+		}
+	}, nil)
+
+	if err == nil {
+		err = sendErr
+	}
+
+	if err == nil && len(batch.Deltas) > 0 {
+		err = stream.Send(batch)
+	}
+
+	return err
+}
+
+func (s *Server) ApplyPatch(stream syncpb.Sync_ApplyPatchServer) error {
+	// patch needs random access to basis, so it is kept in temporary file
+	basis, err := os.CreateTemp("", "sync-grpc-basis-*")
+	if err != nil {
+		return status.Errorf(codes.Internal, "unable to create basis file. %v", err)
+	}
+	defer os.Remove(basis.Name())
+	defer basis.Close()
+
+	var first *syncpb.Deltas
+	for {
+		message, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if message.GetDelta() != nil {
+			first = message.GetDelta()
+			break
+		}
+
+		if _, err := basis.Write(message.GetBasis().GetData()); err != nil {
+			return status.Errorf(codes.Internal, "unable to write basis file. %v", err)
+		}
+	}
+
+	out := bufio.NewWriterSize(&messageWriter{send: stream.Send}, fileDataSize)
+	sy := sync.New()
+	patcher := sy.NewPatcher(basis, out)
+
+	for message := first; message != nil; {
+		for _, d := range message.GetDeltas() {
+			if err := patcher.Apply(deltaFromProto(d)); err != nil {
+				return status.Errorf(codes.InvalidArgument, "unable to apply delta. %v", err)
+			}
+		}
+
+		next, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if next.GetDelta() == nil {
+			return status.Error(codes.InvalidArgument, "basis has to be sent before delta")
+		}
+		message = next.GetDelta()
+	}
+
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("unable to send patched file. %w", err)
+	}
+
+	return nil
+}
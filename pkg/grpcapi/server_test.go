@@ -0,0 +1,122 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi/syncpb"
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startServer(t *testing.T) *Client {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	NewServer().Register(server)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return NewClient(conn)
+}
+
+func Test_SignatureDeltaAndPatchRecreateNewFile(t *testing.T) {
+	client := startServer(t)
+	ctx := context.Background()
+
+	oldData := randomData(100000, 1)
+	newData := append(append(append([]byte{}, oldData[:40000]...), []byte("inserted data")...), oldData[40000:]...)
+
+	chunks := []sync.Chunk{}
+	err := client.Signature(ctx, bytes.NewReader(oldData), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	})
+	require.Nil(t, err)
+
+	s := sync.New()
+	expectedChunks := []sync.Chunk{}
+	s.Signature(bytes.NewReader(oldData), func(c sync.Chunk) {
+		expectedChunks = append(expectedChunks, c)
+	})
+	require.Equal(t, expectedChunks, chunks)
+
+	deltas := []sync.Delta{}
+	err = client.Delta(ctx, chunks, bytes.NewReader(newData), func(d sync.Delta) {
+		deltas = append(deltas, d)
+	})
+	require.Nil(t, err)
+	require.Less(t, len(deltas), len(newData)/4)
+
+	out := &bytes.Buffer{}
+	err = client.Patch(ctx, bytes.NewReader(oldData), deltas, out)
+	require.Nil(t, err)
+	require.Equal(t, newData, out.Bytes())
+}
+
+func Test_EmptyFile(t *testing.T) {
+	client := startServer(t)
+	ctx := context.Background()
+
+	chunks := []sync.Chunk{}
+	err := client.Signature(ctx, bytes.NewReader(nil), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	})
+	require.Nil(t, err)
+	require.Empty(t, chunks)
+
+	deltas := []sync.Delta{}
+	err = client.Delta(ctx, chunks, bytes.NewReader(nil), func(d sync.Delta) {
+		deltas = append(deltas, d)
+	})
+	require.Nil(t, err)
+	require.Empty(t, deltas)
+
+	out := &bytes.Buffer{}
+	err = client.Patch(ctx, bytes.NewReader(nil), deltas, out)
+	require.Nil(t, err)
+	require.Equal(t, 0, out.Len())
+}
+
+func Test_PatchFailsForInvalidDelta(t *testing.T) {
+	client := startServer(t)
+
+	deltas := []sync.Delta{{Id: 0, Operation: 10, Data: []byte{0}}}
+	err := client.Patch(context.Background(), bytes.NewReader([]byte("short")), deltas, &bytes.Buffer{})
+
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_ProtoOperationsCoverAllDeltaOperations(t *testing.T) {
+	// every operation known to sync package has its name, so none of them is sent as unknown enum value
+	for op := sync.Operation(0); !strings.HasPrefix(op.String(), "unknown"); op++ {
+		_, ok := syncpb.Operation_name[int32(op)]
+		require.True(t, ok, "operation %s is missing in proto", op)
+
+		d := sync.Delta{Id: 1, Operation: op, Data: []byte{1, 2}}
+		require.Equal(t, d, deltaFromProto(deltaToProto(d)))
+	}
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
@@ -0,0 +1,63 @@
+package grpcapi
+
+import (
+	"io"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/grpcapi/syncpb"
+)
+
+// batchSize is number of chunks or deltas sent in single message
+const batchSize = 1024
+
+// fileDataSize is maximum size of file data sent in single message
+const fileDataSize = 32 * 1024
+
+// messageReader turns stream of messages with file data into io.Reader,
+// next returns data of next message and io.EOF when there are no more of them
+type messageReader struct {
+	next    func() ([]byte, error)
+	pending []byte
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		data, err := r.next()
+		if err != nil {
+			return 0, err
+		}
+		r.pending = data
+	}
+
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// messageWriter sends every write as FileData messages
+type messageWriter struct {
+	send func(*syncpb.FileData) error
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		end := written + fileDataSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		// message is serialized before Send returns, so buffer can be reused
+		if err := w.send(&syncpb.FileData{Data: p[written:end]}); err != nil {
+			return written, err
+		}
+		written = end
+	}
+
+	return written, nil
+}
+
+// sendFile streams file in FileData messages
+func sendFile(file io.Reader, send func(*syncpb.FileData) error) error {
+	_, err := io.CopyBuffer(&messageWriter{send: send}, file, make([]byte, fileDataSize))
+	return err
+}
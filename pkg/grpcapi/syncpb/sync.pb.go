@@ -0,0 +1,739 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        (unknown)
+// source: syncpb/sync.proto
+
+package syncpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Operation int32
+
+const (
+	Operation_NEW_DATA        Operation = 0
+	Operation_EXISTING_DATA   Operation = 1
+	Operation_HOLE            Operation = 2
+	Operation_COMPRESSED_DATA Operation = 3
+	Operation_COPY_RANGE      Operation = 4
+	Operation_TARGET_COPY     Operation = 5
+)
+
+// Enum value maps for Operation.
+var (
+	Operation_name = map[int32]string{
+		0: "NEW_DATA",
+		1: "EXISTING_DATA",
+		2: "HOLE",
+		3: "COMPRESSED_DATA",
+		4: "COPY_RANGE",
+		5: "TARGET_COPY",
+	}
+	Operation_value = map[string]int32{
+		"NEW_DATA":        0,
+		"EXISTING_DATA":   1,
+		"HOLE":            2,
+		"COMPRESSED_DATA": 3,
+		"COPY_RANGE":      4,
+		"TARGET_COPY":     5,
+	}
+)
+
+func (x Operation) Enum() *Operation {
+	p := new(Operation)
+	*p = x
+	return p
+}
+
+func (x Operation) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Operation) Descriptor() protoreflect.EnumDescriptor {
+	return file_syncpb_sync_proto_enumTypes[0].Descriptor()
+}
+
+func (Operation) Type() protoreflect.EnumType {
+	return &file_syncpb_sync_proto_enumTypes[0]
+}
+
+func (x Operation) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Operation.Descriptor instead.
+func (Operation) EnumDescriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{0}
+}
+
+type FileData struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Data []byte `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *FileData) Reset() {
+	*x = FileData{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FileData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FileData) ProtoMessage() {}
+
+func (x *FileData) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FileData.ProtoReflect.Descriptor instead.
+func (*FileData) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FileData) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Chunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id          uint32 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	RollingHash uint32 `protobuf:"varint,2,opt,name=rolling_hash,json=rollingHash,proto3" json:"rolling_hash,omitempty"`
+	StrongHash  []byte `protobuf:"bytes,3,opt,name=strong_hash,json=strongHash,proto3" json:"strong_hash,omitempty"`
+}
+
+func (x *Chunk) Reset() {
+	*x = Chunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Chunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunk) ProtoMessage() {}
+
+func (x *Chunk) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunk.ProtoReflect.Descriptor instead.
+func (*Chunk) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Chunk) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Chunk) GetRollingHash() uint32 {
+	if x != nil {
+		return x.RollingHash
+	}
+	return 0
+}
+
+func (x *Chunk) GetStrongHash() []byte {
+	if x != nil {
+		return x.StrongHash
+	}
+	return nil
+}
+
+type Chunks struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Chunks []*Chunk `protobuf:"bytes,1,rep,name=chunks,proto3" json:"chunks,omitempty"`
+}
+
+func (x *Chunks) Reset() {
+	*x = Chunks{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Chunks) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chunks) ProtoMessage() {}
+
+func (x *Chunks) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chunks.ProtoReflect.Descriptor instead.
+func (*Chunks) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Chunks) GetChunks() []*Chunk {
+	if x != nil {
+		return x.Chunks
+	}
+	return nil
+}
+
+type Delta struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id        uint32    `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Operation Operation `protobuf:"varint,2,opt,name=operation,proto3,enum=sync.v1.Operation" json:"operation,omitempty"`
+	Data      []byte    `protobuf:"bytes,3,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (x *Delta) Reset() {
+	*x = Delta{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Delta) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Delta) ProtoMessage() {}
+
+func (x *Delta) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Delta.ProtoReflect.Descriptor instead.
+func (*Delta) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Delta) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Delta) GetOperation() Operation {
+	if x != nil {
+		return x.Operation
+	}
+	return Operation_NEW_DATA
+}
+
+func (x *Delta) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type Deltas struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Deltas []*Delta `protobuf:"bytes,1,rep,name=deltas,proto3" json:"deltas,omitempty"`
+}
+
+func (x *Deltas) Reset() {
+	*x = Deltas{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Deltas) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Deltas) ProtoMessage() {}
+
+func (x *Deltas) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Deltas.ProtoReflect.Descriptor instead.
+func (*Deltas) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Deltas) GetDeltas() []*Delta {
+	if x != nil {
+		return x.Deltas
+	}
+	return nil
+}
+
+// ComputeDeltaRequest stream has to contain all signature messages before file messages
+type ComputeDeltaRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Message:
+	//	*ComputeDeltaRequest_Signature
+	//	*ComputeDeltaRequest_File
+	Message isComputeDeltaRequest_Message `protobuf_oneof:"message"`
+}
+
+func (x *ComputeDeltaRequest) Reset() {
+	*x = ComputeDeltaRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ComputeDeltaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComputeDeltaRequest) ProtoMessage() {}
+
+func (x *ComputeDeltaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComputeDeltaRequest.ProtoReflect.Descriptor instead.
+func (*ComputeDeltaRequest) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{5}
+}
+
+func (m *ComputeDeltaRequest) GetMessage() isComputeDeltaRequest_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (x *ComputeDeltaRequest) GetSignature() *Chunks {
+	if x, ok := x.GetMessage().(*ComputeDeltaRequest_Signature); ok {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *ComputeDeltaRequest) GetFile() *FileData {
+	if x, ok := x.GetMessage().(*ComputeDeltaRequest_File); ok {
+		return x.File
+	}
+	return nil
+}
+
+type isComputeDeltaRequest_Message interface {
+	isComputeDeltaRequest_Message()
+}
+
+type ComputeDeltaRequest_Signature struct {
+	Signature *Chunks `protobuf:"bytes,1,opt,name=signature,proto3,oneof"`
+}
+
+type ComputeDeltaRequest_File struct {
+	File *FileData `protobuf:"bytes,2,opt,name=file,proto3,oneof"`
+}
+
+func (*ComputeDeltaRequest_Signature) isComputeDeltaRequest_Message() {}
+
+func (*ComputeDeltaRequest_File) isComputeDeltaRequest_Message() {}
+
+// ApplyPatchRequest stream has to contain all basis messages before delta messages
+type ApplyPatchRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Message:
+	//	*ApplyPatchRequest_Basis
+	//	*ApplyPatchRequest_Delta
+	Message isApplyPatchRequest_Message `protobuf_oneof:"message"`
+}
+
+func (x *ApplyPatchRequest) Reset() {
+	*x = ApplyPatchRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_syncpb_sync_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ApplyPatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApplyPatchRequest) ProtoMessage() {}
+
+func (x *ApplyPatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_syncpb_sync_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApplyPatchRequest.ProtoReflect.Descriptor instead.
+func (*ApplyPatchRequest) Descriptor() ([]byte, []int) {
+	return file_syncpb_sync_proto_rawDescGZIP(), []int{6}
+}
+
+func (m *ApplyPatchRequest) GetMessage() isApplyPatchRequest_Message {
+	if m != nil {
+		return m.Message
+	}
+	return nil
+}
+
+func (x *ApplyPatchRequest) GetBasis() *FileData {
+	if x, ok := x.GetMessage().(*ApplyPatchRequest_Basis); ok {
+		return x.Basis
+	}
+	return nil
+}
+
+func (x *ApplyPatchRequest) GetDelta() *Deltas {
+	if x, ok := x.GetMessage().(*ApplyPatchRequest_Delta); ok {
+		return x.Delta
+	}
+	return nil
+}
+
+type isApplyPatchRequest_Message interface {
+	isApplyPatchRequest_Message()
+}
+
+type ApplyPatchRequest_Basis struct {
+	Basis *FileData `protobuf:"bytes,1,opt,name=basis,proto3,oneof"`
+}
+
+type ApplyPatchRequest_Delta struct {
+	Delta *Deltas `protobuf:"bytes,2,opt,name=delta,proto3,oneof"`
+}
+
+func (*ApplyPatchRequest_Basis) isApplyPatchRequest_Message() {}
+
+func (*ApplyPatchRequest_Delta) isApplyPatchRequest_Message() {}
+
+var File_syncpb_sync_proto protoreflect.FileDescriptor
+
+var file_syncpb_sync_proto_rawDesc = []byte{
+	0x0a, 0x11, 0x73, 0x79, 0x6e, 0x63, 0x70, 0x62, 0x2f, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x12, 0x07, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x22, 0x1e, 0x0a, 0x08,
+	0x46, 0x69, 0x6c, 0x65, 0x44, 0x61, 0x74, 0x61, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x5b, 0x0a, 0x05,
+	0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0d, 0x52, 0x02, 0x69, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x72, 0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67,
+	0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x0b, 0x72, 0x6f, 0x6c,
+	0x6c, 0x69, 0x6e, 0x67, 0x48, 0x61, 0x73, 0x68, 0x12, 0x1f, 0x0a, 0x0b, 0x73, 0x74, 0x72, 0x6f,
+	0x6e, 0x67, 0x5f, 0x68, 0x61, 0x73, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0a, 0x73,
+	0x74, 0x72, 0x6f, 0x6e, 0x67, 0x48, 0x61, 0x73, 0x68, 0x22, 0x30, 0x0a, 0x06, 0x43, 0x68, 0x75,
+	0x6e, 0x6b, 0x73, 0x12, 0x26, 0x0a, 0x06, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68,
+	0x75, 0x6e, 0x6b, 0x52, 0x06, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x22, 0x5d, 0x0a, 0x05, 0x44,
+	0x65, 0x6c, 0x74, 0x61, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x30, 0x0a, 0x09, 0x6f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76,
+	0x31, 0x2e, 0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x6f, 0x70, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x30, 0x0a, 0x06, 0x44, 0x65,
+	0x6c, 0x74, 0x61, 0x73, 0x12, 0x26, 0x0a, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x0e, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x44,
+	0x65, 0x6c, 0x74, 0x61, 0x52, 0x06, 0x64, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x22, 0x7a, 0x0a, 0x13,
+	0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x2f, 0x0a, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31,
+	0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x48, 0x00, 0x52, 0x09, 0x73, 0x69, 0x67, 0x6e, 0x61,
+	0x74, 0x75, 0x72, 0x65, 0x12, 0x27, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c,
+	0x65, 0x44, 0x61, 0x74, 0x61, 0x48, 0x00, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x42, 0x09, 0x0a,
+	0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x22, 0x72, 0x0a, 0x11, 0x41, 0x70, 0x70, 0x6c,
+	0x79, 0x50, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x29, 0x0a,
+	0x05, 0x62, 0x61, 0x73, 0x69, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x73,
+	0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x61, 0x74, 0x61, 0x48,
+	0x00, 0x52, 0x05, 0x62, 0x61, 0x73, 0x69, 0x73, 0x12, 0x27, 0x0a, 0x05, 0x64, 0x65, 0x6c, 0x74,
+	0x61, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x48, 0x00, 0x52, 0x05, 0x64, 0x65, 0x6c, 0x74,
+	0x61, 0x42, 0x09, 0x0a, 0x07, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x2a, 0x6c, 0x0a, 0x09,
+	0x4f, 0x70, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x0c, 0x0a, 0x08, 0x4e, 0x45, 0x57,
+	0x5f, 0x44, 0x41, 0x54, 0x41, 0x10, 0x00, 0x12, 0x11, 0x0a, 0x0d, 0x45, 0x58, 0x49, 0x53, 0x54,
+	0x49, 0x4e, 0x47, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x10, 0x01, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x4f,
+	0x4c, 0x45, 0x10, 0x02, 0x12, 0x13, 0x0a, 0x0f, 0x43, 0x4f, 0x4d, 0x50, 0x52, 0x45, 0x53, 0x53,
+	0x45, 0x44, 0x5f, 0x44, 0x41, 0x54, 0x41, 0x10, 0x03, 0x12, 0x0e, 0x0a, 0x0a, 0x43, 0x4f, 0x50,
+	0x59, 0x5f, 0x52, 0x41, 0x4e, 0x47, 0x45, 0x10, 0x04, 0x12, 0x0f, 0x0a, 0x0b, 0x54, 0x41, 0x52,
+	0x47, 0x45, 0x54, 0x5f, 0x43, 0x4f, 0x50, 0x59, 0x10, 0x05, 0x32, 0xc6, 0x01, 0x0a, 0x04, 0x53,
+	0x79, 0x6e, 0x63, 0x12, 0x3a, 0x0a, 0x10, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x53, 0x69,
+	0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65, 0x12, 0x11, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76,
+	0x31, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x61, 0x74, 0x61, 0x1a, 0x0f, 0x2e, 0x73, 0x79, 0x6e,
+	0x63, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x73, 0x28, 0x01, 0x30, 0x01, 0x12,
+	0x41, 0x0a, 0x0c, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74, 0x65, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x12,
+	0x1c, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x75, 0x74,
+	0x65, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e,
+	0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x6c, 0x74, 0x61, 0x73, 0x28, 0x01,
+	0x30, 0x01, 0x12, 0x3f, 0x0a, 0x0a, 0x41, 0x70, 0x70, 0x6c, 0x79, 0x50, 0x61, 0x74, 0x63, 0x68,
+	0x12, 0x1a, 0x2e, 0x73, 0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x70, 0x70, 0x6c, 0x79,
+	0x50, 0x61, 0x74, 0x63, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x11, 0x2e, 0x73,
+	0x79, 0x6e, 0x63, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x69, 0x6c, 0x65, 0x44, 0x61, 0x74, 0x61, 0x28,
+	0x01, 0x30, 0x01, 0x42, 0x43, 0x5a, 0x41, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f,
+	0x6d, 0x2f, 0x70, 0x69, 0x6f, 0x74, 0x72, 0x6a, 0x61, 0x72, 0x6f, 0x6d, 0x69, 0x6e, 0x2f, 0x72,
+	0x6f, 0x6c, 0x6c, 0x69, 0x6e, 0x67, 0x2d, 0x68, 0x61, 0x73, 0x68, 0x2d, 0x61, 0x6c, 0x67, 0x6f,
+	0x72, 0x69, 0x74, 0x68, 0x6d, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x61, 0x70,
+	0x69, 0x2f, 0x73, 0x79, 0x6e, 0x63, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_syncpb_sync_proto_rawDescOnce sync.Once
+	file_syncpb_sync_proto_rawDescData = file_syncpb_sync_proto_rawDesc
+)
+
+func file_syncpb_sync_proto_rawDescGZIP() []byte {
+	file_syncpb_sync_proto_rawDescOnce.Do(func() {
+		file_syncpb_sync_proto_rawDescData = protoimpl.X.CompressGZIP(file_syncpb_sync_proto_rawDescData)
+	})
+	return file_syncpb_sync_proto_rawDescData
+}
+
+var file_syncpb_sync_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_syncpb_sync_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_syncpb_sync_proto_goTypes = []interface{}{
+	(Operation)(0),              // 0: sync.v1.Operation
+	(*FileData)(nil),            // 1: sync.v1.FileData
+	(*Chunk)(nil),               // 2: sync.v1.Chunk
+	(*Chunks)(nil),              // 3: sync.v1.Chunks
+	(*Delta)(nil),               // 4: sync.v1.Delta
+	(*Deltas)(nil),              // 5: sync.v1.Deltas
+	(*ComputeDeltaRequest)(nil), // 6: sync.v1.ComputeDeltaRequest
+	(*ApplyPatchRequest)(nil),   // 7: sync.v1.ApplyPatchRequest
+}
+var file_syncpb_sync_proto_depIdxs = []int32{
+	2,  // 0: sync.v1.Chunks.chunks:type_name -> sync.v1.Chunk
+	0,  // 1: sync.v1.Delta.operation:type_name -> sync.v1.Operation
+	4,  // 2: sync.v1.Deltas.deltas:type_name -> sync.v1.Delta
+	3,  // 3: sync.v1.ComputeDeltaRequest.signature:type_name -> sync.v1.Chunks
+	1,  // 4: sync.v1.ComputeDeltaRequest.file:type_name -> sync.v1.FileData
+	1,  // 5: sync.v1.ApplyPatchRequest.basis:type_name -> sync.v1.FileData
+	5,  // 6: sync.v1.ApplyPatchRequest.delta:type_name -> sync.v1.Deltas
+	1,  // 7: sync.v1.Sync.ComputeSignature:input_type -> sync.v1.FileData
+	6,  // 8: sync.v1.Sync.ComputeDelta:input_type -> sync.v1.ComputeDeltaRequest
+	7,  // 9: sync.v1.Sync.ApplyPatch:input_type -> sync.v1.ApplyPatchRequest
+	3,  // 10: sync.v1.Sync.ComputeSignature:output_type -> sync.v1.Chunks
+	5,  // 11: sync.v1.Sync.ComputeDelta:output_type -> sync.v1.Deltas
+	1,  // 12: sync.v1.Sync.ApplyPatch:output_type -> sync.v1.FileData
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_syncpb_sync_proto_init() }
+func file_syncpb_sync_proto_init() {
+	if File_syncpb_sync_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_syncpb_sync_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*FileData); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Chunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Chunks); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Delta); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Deltas); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ComputeDeltaRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_syncpb_sync_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*ApplyPatchRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_syncpb_sync_proto_msgTypes[5].OneofWrappers = []interface{}{
+		(*ComputeDeltaRequest_Signature)(nil),
+		(*ComputeDeltaRequest_File)(nil),
+	}
+	file_syncpb_sync_proto_msgTypes[6].OneofWrappers = []interface{}{
+		(*ApplyPatchRequest_Basis)(nil),
+		(*ApplyPatchRequest_Delta)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_syncpb_sync_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_syncpb_sync_proto_goTypes,
+		DependencyIndexes: file_syncpb_sync_proto_depIdxs,
+		EnumInfos:         file_syncpb_sync_proto_enumTypes,
+		MessageInfos:      file_syncpb_sync_proto_msgTypes,
+	}.Build()
+	File_syncpb_sync_proto = out.File
+	file_syncpb_sync_proto_rawDesc = nil
+	file_syncpb_sync_proto_goTypes = nil
+	file_syncpb_sync_proto_depIdxs = nil
+}
@@ -0,0 +1,279 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             (unknown)
+// source: syncpb/sync.proto
+
+package syncpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// SyncClient is the client API for Sync service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SyncClient interface {
+	// ComputeSignature receives file and responds with its signature
+	ComputeSignature(ctx context.Context, opts ...grpc.CallOption) (Sync_ComputeSignatureClient, error)
+	// ComputeDelta receives signature of old file followed by new file and responds with delta
+	ComputeDelta(ctx context.Context, opts ...grpc.CallOption) (Sync_ComputeDeltaClient, error)
+	// ApplyPatch receives basis file followed by delta and responds with patched file
+	ApplyPatch(ctx context.Context, opts ...grpc.CallOption) (Sync_ApplyPatchClient, error)
+}
+
+type syncClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSyncClient(cc grpc.ClientConnInterface) SyncClient {
+	return &syncClient{cc}
+}
+
+func (c *syncClient) ComputeSignature(ctx context.Context, opts ...grpc.CallOption) (Sync_ComputeSignatureClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Sync_ServiceDesc.Streams[0], "/sync.v1.Sync/ComputeSignature", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &syncComputeSignatureClient{stream}
+	return x, nil
+}
+
+type Sync_ComputeSignatureClient interface {
+	Send(*FileData) error
+	Recv() (*Chunks, error)
+	grpc.ClientStream
+}
+
+type syncComputeSignatureClient struct {
+	grpc.ClientStream
+}
+
+func (x *syncComputeSignatureClient) Send(m *FileData) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *syncComputeSignatureClient) Recv() (*Chunks, error) {
+	m := new(Chunks)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *syncClient) ComputeDelta(ctx context.Context, opts ...grpc.CallOption) (Sync_ComputeDeltaClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Sync_ServiceDesc.Streams[1], "/sync.v1.Sync/ComputeDelta", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &syncComputeDeltaClient{stream}
+	return x, nil
+}
+
+type Sync_ComputeDeltaClient interface {
+	Send(*ComputeDeltaRequest) error
+	Recv() (*Deltas, error)
+	grpc.ClientStream
+}
+
+type syncComputeDeltaClient struct {
+	grpc.ClientStream
+}
+
+func (x *syncComputeDeltaClient) Send(m *ComputeDeltaRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *syncComputeDeltaClient) Recv() (*Deltas, error) {
+	m := new(Deltas)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *syncClient) ApplyPatch(ctx context.Context, opts ...grpc.CallOption) (Sync_ApplyPatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Sync_ServiceDesc.Streams[2], "/sync.v1.Sync/ApplyPatch", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &syncApplyPatchClient{stream}
+	return x, nil
+}
+
+type Sync_ApplyPatchClient interface {
+	Send(*ApplyPatchRequest) error
+	Recv() (*FileData, error)
+	grpc.ClientStream
+}
+
+type syncApplyPatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *syncApplyPatchClient) Send(m *ApplyPatchRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *syncApplyPatchClient) Recv() (*FileData, error) {
+	m := new(FileData)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// SyncServer is the server API for Sync service.
+// All implementations must embed UnimplementedSyncServer
+// for forward compatibility
+type SyncServer interface {
+	// ComputeSignature receives file and responds with its signature
+	ComputeSignature(Sync_ComputeSignatureServer) error
+	// ComputeDelta receives signature of old file followed by new file and responds with delta
+	ComputeDelta(Sync_ComputeDeltaServer) error
+	// ApplyPatch receives basis file followed by delta and responds with patched file
+	ApplyPatch(Sync_ApplyPatchServer) error
+	mustEmbedUnimplementedSyncServer()
+}
+
+// UnimplementedSyncServer must be embedded to have forward compatible implementations.
+type UnimplementedSyncServer struct {
+}
+
+func (UnimplementedSyncServer) ComputeSignature(Sync_ComputeSignatureServer) error {
+	return status.Errorf(codes.Unimplemented, "method ComputeSignature not implemented")
+}
+func (UnimplementedSyncServer) ComputeDelta(Sync_ComputeDeltaServer) error {
+	return status.Errorf(codes.Unimplemented, "method ComputeDelta not implemented")
+}
+func (UnimplementedSyncServer) ApplyPatch(Sync_ApplyPatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method ApplyPatch not implemented")
+}
+func (UnimplementedSyncServer) mustEmbedUnimplementedSyncServer() {}
+
+// UnsafeSyncServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SyncServer will
+// result in compilation errors.
+type UnsafeSyncServer interface {
+	mustEmbedUnimplementedSyncServer()
+}
+
+func RegisterSyncServer(s grpc.ServiceRegistrar, srv SyncServer) {
+	s.RegisterService(&Sync_ServiceDesc, srv)
+}
+
+func _Sync_ComputeSignature_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SyncServer).ComputeSignature(&syncComputeSignatureServer{stream})
+}
+
+type Sync_ComputeSignatureServer interface {
+	Send(*Chunks) error
+	Recv() (*FileData, error)
+	grpc.ServerStream
+}
+
+type syncComputeSignatureServer struct {
+	grpc.ServerStream
+}
+
+func (x *syncComputeSignatureServer) Send(m *Chunks) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *syncComputeSignatureServer) Recv() (*FileData, error) {
+	m := new(FileData)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Sync_ComputeDelta_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SyncServer).ComputeDelta(&syncComputeDeltaServer{stream})
+}
+
+type Sync_ComputeDeltaServer interface {
+	Send(*Deltas) error
+	Recv() (*ComputeDeltaRequest, error)
+	grpc.ServerStream
+}
+
+type syncComputeDeltaServer struct {
+	grpc.ServerStream
+}
+
+func (x *syncComputeDeltaServer) Send(m *Deltas) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *syncComputeDeltaServer) Recv() (*ComputeDeltaRequest, error) {
+	m := new(ComputeDeltaRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Sync_ApplyPatch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(SyncServer).ApplyPatch(&syncApplyPatchServer{stream})
+}
+
+type Sync_ApplyPatchServer interface {
+	Send(*FileData) error
+	Recv() (*ApplyPatchRequest, error)
+	grpc.ServerStream
+}
+
+type syncApplyPatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *syncApplyPatchServer) Send(m *FileData) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *syncApplyPatchServer) Recv() (*ApplyPatchRequest, error) {
+	m := new(ApplyPatchRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Sync_ServiceDesc is the grpc.ServiceDesc for Sync service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Sync_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "sync.v1.Sync",
+	HandlerType: (*SyncServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ComputeSignature",
+			Handler:       _Sync_ComputeSignature_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ComputeDelta",
+			Handler:       _Sync_ComputeDelta_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ApplyPatch",
+			Handler:       _Sync_ApplyPatch_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "syncpb/sync.proto",
+}
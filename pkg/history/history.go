@@ -0,0 +1,312 @@
+// Package history keeps versions of single file as full base followed by chain of deltas.
+// Every version is delta against previous one, so any version is recreated by patching its base
+// with deltas of following versions. When chain gets too long next version is stored as new base.
+//
+//	log                 list of versions
+//	head.<id>           full copy of latest version, deltas of new versions are calculated against it
+//	objects/<id>.base   full copy of version
+//	objects/<id>.delta  delta of version against previous version
+package history
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// DefaultMaxChain is number of deltas after which new base is stored
+const DefaultMaxChain = 10
+
+const (
+	logFile    = "log"
+	objectsDir = "objects"
+)
+
+type Version struct {
+	Id   uint32
+	Time time.Time
+	// Size is size of file in this version
+	Size int64
+	// StoredSize is size of base or delta of version
+	StoredSize int64
+	Base       bool
+}
+
+type History struct {
+	root     string
+	versions []Version
+}
+
+// Open opens history in root directory, it is created when it does not exist
+func Open(root string) (*History, error) {
+	if err := os.MkdirAll(filepath.Join(root, objectsDir), 0755); err != nil {
+		return nil, fmt.Errorf("unable to create history directory. %w", err)
+	}
+
+	versions := []Version{}
+	file, err := os.Open(filepath.Join(root, logFile))
+	if err == nil {
+		defer file.Close()
+		err = gob.NewDecoder(file).Decode(&versions)
+	}
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unable to read history log. %w", err)
+	}
+
+	return &History{
+		root:     root,
+		versions: versions,
+	}, nil
+}
+
+// Versions returns all versions ordered from oldest
+func (h *History) Versions() []Version {
+	return append([]Version{}, h.versions...)
+}
+
+// Commit stores data as new version, data is read twice.
+// Version is stored as base when there are maxChain deltas after last base
+func (h *History) Commit(data io.ReadSeeker, maxChain int) (Version, error) {
+	version := Version{
+		Id:   uint32(len(h.versions) + 1),
+		Time: time.Now(),
+		Base: len(h.versions) == 0 || h.chainLength() >= maxChain,
+	}
+
+	var err error
+	if version.Base {
+		version.StoredSize, err = h.writeObject(h.basePath(version.Id), func(out io.Writer) error {
+			_, err := io.Copy(out, data)
+			return err
+		})
+	} else {
+		version.StoredSize, err = h.writeObject(h.deltaPath(version.Id), func(out io.Writer) error {
+			return h.delta(data, out)
+		})
+	}
+
+	if err != nil {
+		return version, fmt.Errorf("unable to store version %d. %w", version.Id, err)
+	}
+
+	if _, err := data.Seek(0, io.SeekStart); err != nil {
+		return version, err
+	}
+
+	// new head is written before log, so log never points to missing head
+	version.Size, err = h.writeObject(h.headPath(version.Id), func(out io.Writer) error {
+		_, err := io.Copy(out, data)
+		return err
+	})
+	if err != nil {
+		return version, fmt.Errorf("unable to store head. %w", err)
+	}
+
+	versions := append(h.Versions(), version)
+	if _, err := h.writeObject(filepath.Join(h.root, logFile), func(out io.Writer) error {
+		return gob.NewEncoder(out).Encode(versions)
+	}); err != nil {
+		return version, fmt.Errorf("unable to write history log. %w", err)
+	}
+
+	if len(h.versions) > 0 {
+		os.Remove(h.headPath(h.versions[len(h.versions)-1].Id))
+	}
+
+	h.versions = versions
+	return version, nil
+}
+
+// Checkout writes content of version to out
+func (h *History) Checkout(id uint32, out io.Writer) error {
+	if id == 0 || int(id) > len(h.versions) {
+		return fmt.Errorf("version %d does not exist", id)
+	}
+
+	if int(id) == len(h.versions) {
+		return copyFile(h.headPath(id), out)
+	}
+
+	base := id
+	for !h.versions[base-1].Base {
+		base--
+	}
+
+	if base == id {
+		return copyFile(h.basePath(id), out)
+	}
+
+	basis, err := os.Open(h.basePath(base))
+	if err != nil {
+		return fmt.Errorf("unable to open base of version %d. %w", id, err)
+	}
+	defer basis.Close()
+
+	var current io.ReadSeeker = basis
+	for next := base + 1; next <= id; next++ {
+		deltas, err := h.readDeltas(next)
+		if err != nil {
+			return err
+		}
+
+		s := sync.New()
+		if next == id {
+			return s.Patch(current, deltas, out)
+		}
+
+		patched, err := os.CreateTemp(h.root, "checkout-*")
+		if err != nil {
+			return fmt.Errorf("unable to create temporary file. %w", err)
+		}
+		defer os.Remove(patched.Name())
+		defer patched.Close()
+
+		if err := s.Patch(current, deltas, patched); err != nil {
+			return fmt.Errorf("unable to apply delta of version %d. %w", next, err)
+		}
+
+		if _, err := patched.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		current = patched
+	}
+
+	return nil
+}
+
+// chainLength returns number of deltas stored after last base
+func (h *History) chainLength() int {
+	length := 0
+	for i := len(h.versions) - 1; i >= 0 && !h.versions[i].Base; i-- {
+		length++
+	}
+
+	return length
+}
+
+// delta calculates delta of data against head
+func (h *History) delta(data io.Reader, out io.Writer) error {
+	head, err := os.Open(h.headPath(h.versions[len(h.versions)-1].Id))
+	if err != nil {
+		return fmt.Errorf("unable to open head. %w", err)
+	}
+	defer head.Close()
+
+	s := sync.New()
+	chunks := []sync.Chunk{}
+	if err := s.Signature(head, func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	}); err != nil {
+		return fmt.Errorf("unable to calculate signature of head. %w", err)
+	}
+
+	headSize, err := head.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("unable to read size of head. %w", err)
+	}
+
+	enc := sync.NewStreamEncoder[sync.Delta](out)
+	var writeErr error
+	// head is local, so matches can be extended beyond chunks
+	extender := sync.NewMatchExtender(head, headSize, func(d sync.Delta) {
+		if writeErr == nil {
+			writeErr = enc.Encode(d)
+		}
+	})
+	err = s.DeltaFromChunks(data, chunks, sync.Checkpoint{}, extender.Handle, nil)
+
+	if err == nil {
+		err = extender.Close()
+	}
+
+	if err == nil {
+		err = writeErr
+	}
+
+	if err == nil {
+		err = enc.Flush()
+	}
+
+	return err
+}
+
+func (h *History) readDeltas(id uint32) ([]sync.Delta, error) {
+	file, err := os.Open(h.deltaPath(id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to open delta of version %d. %w", id, err)
+	}
+	defer file.Close()
+
+	deltas, err := sync.DeserializeDelta(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read delta of version %d. %w", id, err)
+	}
+
+	return deltas, nil
+}
+
+// writeObject atomically replaces file at path with data written by write, it returns size of file
+func (h *History) writeObject(path string, write func(out io.Writer) error) (int64, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	counter := &countingWriter{w: file}
+	if err := write(counter); err != nil {
+		return 0, err
+	}
+
+	if err := file.Sync(); err != nil {
+		return 0, err
+	}
+
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+
+	return counter.n, os.Rename(file.Name(), path)
+}
+
+func (h *History) headPath(id uint32) string {
+	return filepath.Join(h.root, fmt.Sprintf("head.%d", id))
+}
+
+func (h *History) basePath(id uint32) string {
+	return filepath.Join(h.root, objectsDir, fmt.Sprintf("%08d.base", id))
+}
+
+func (h *History) deltaPath(id uint32) string {
+	return filepath.Join(h.root, objectsDir, fmt.Sprintf("%08d.delta", id))
+}
+
+func copyFile(path string, out io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(out, file)
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
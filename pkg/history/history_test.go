@@ -0,0 +1,74 @@
+package history
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_AnyVersionCanBeCheckedOut(t *testing.T) {
+	root := t.TempDir()
+	h, err := Open(root)
+	require.Nil(t, err)
+
+	versions := [][]byte{randomData(5000, 1)}
+	for i := 1; i < 6; i++ {
+		previous := versions[i-1]
+		next := append(append(append([]byte{}, previous[:1000*i]...), randomData(50, int64(i))...), previous[1000*i:]...)
+		versions = append(versions, next)
+	}
+
+	for i, data := range versions {
+		version, err := h.Commit(bytes.NewReader(data), 2)
+		require.Nil(t, err)
+		require.Equal(t, uint32(i+1), version.Id)
+		require.Equal(t, int64(len(data)), version.Size)
+	}
+
+	bases := []bool{}
+	for _, version := range h.Versions() {
+		bases = append(bases, version.Base)
+		if !version.Base {
+			require.Less(t, version.StoredSize, version.Size)
+		}
+	}
+	require.Equal(t, []bool{true, false, false, true, false, false}, bases)
+
+	h, err = Open(root)
+	require.Nil(t, err)
+	require.Len(t, h.Versions(), len(versions))
+
+	for i, data := range versions {
+		out := bytes.Buffer{}
+		require.Nil(t, h.Checkout(uint32(i+1), &out))
+		require.Equal(t, data, out.Bytes(), "version %d", i+1)
+	}
+
+	require.ErrorContains(t, h.Checkout(0, &bytes.Buffer{}), "version 0 does not exist")
+	require.ErrorContains(t, h.Checkout(7, &bytes.Buffer{}), "version 7 does not exist")
+}
+
+func Test_EmptyVersionsCanBeCommitted(t *testing.T) {
+	h, err := Open(t.TempDir())
+	require.Nil(t, err)
+
+	for _, data := range [][]byte{{}, []byte("data"), {}} {
+		_, err := h.Commit(bytes.NewReader(data), DefaultMaxChain)
+		require.Nil(t, err)
+	}
+
+	for i, data := range [][]byte{{}, []byte("data"), {}} {
+		out := bytes.Buffer{}
+		require.Nil(t, h.Checkout(uint32(i+1), &out))
+		require.Equal(t, len(data), out.Len())
+	}
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
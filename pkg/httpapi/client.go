@@ -0,0 +1,93 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Client calls Handler endpoints, request bodies are streamed and response bodies have to be closed by caller
+type Client struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP:    http.DefaultClient,
+	}
+}
+
+// Signature returns signature of file
+func (c *Client) Signature(file io.Reader) (io.ReadCloser, error) {
+	return c.do(http.MethodPost, "/signature", "application/octet-stream", file, http.StatusOK)
+}
+
+// Delta returns delta of file against signature
+func (c *Client) Delta(signature io.Reader, file io.Reader) (io.ReadCloser, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	writer := multipart.NewWriter(pipeWriter)
+
+	go func() {
+		pipeWriter.CloseWithError(writeDeltaParts(writer, signature, file))
+	}()
+
+	return c.do(http.MethodPost, "/delta", writer.FormDataContentType(), pipeReader, http.StatusOK)
+}
+
+// PutBasis stores file on server under path
+func (c *Client) PutBasis(path string, file io.Reader) error {
+	body, err := c.do(http.MethodPut, "/basis/"+path, "application/octet-stream", file, http.StatusCreated)
+	if err != nil {
+		return err
+	}
+
+	return body.Close()
+}
+
+// Patch returns basis stored under path patched with delta
+func (c *Client) Patch(path string, delta io.Reader) (io.ReadCloser, error) {
+	return c.do(http.MethodPost, "/patch/"+path, "application/octet-stream", delta, http.StatusOK)
+}
+
+func (c *Client) do(method string, path string, contentType string, body io.Reader, expectedStatus int) (io.ReadCloser, error) {
+	request, err := http.NewRequest(method, c.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", contentType)
+
+	response, err := c.HTTP.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode != expectedStatus {
+		defer response.Body.Close()
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 1024))
+		return nil, fmt.Errorf("unexpected status %d: %s", response.StatusCode, strings.TrimSpace(string(message)))
+	}
+
+	return response.Body, nil
+}
+
+func writeDeltaParts(writer *multipart.Writer, signature io.Reader, file io.Reader) error {
+	for _, part := range []struct {
+		name string
+		data io.Reader
+	}{{"signature", signature}, {"file", file}} {
+		partWriter, err := writer.CreateFormFile(part.name, part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(partWriter, part.data); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
@@ -0,0 +1,286 @@
+package httpapi
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// Handler exposes sync operations over HTTP, responses are streamed.
+// HTTP/2 requests are streamed too, response is written while input is still being received.
+// HTTP/1.x server may close request body once response is being written,
+// so for HTTP/1.x input which is processed together with writing response is first spooled to temporary file.
+//
+//	POST /signature       body is file, responds with its signature
+//	POST /delta           multipart body with "signature" and "file" parts (in that order), responds with delta
+//	PUT  /basis/{path}    body is file stored as basis under path
+//	POST /patch/{path}    body is delta, responds with basis from path patched with it
+type Handler struct {
+	root string
+	mux  *http.ServeMux
+}
+
+// NewHandler creates handler which keeps basis files in root directory
+func NewHandler(root string) *Handler {
+	h := &Handler{
+		root: root,
+		mux:  http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("/signature", h.signature)
+	h.mux.HandleFunc("/delta", h.delta)
+	h.mux.HandleFunc("/basis/", h.basis)
+	h.mux.HandleFunc("/patch/", h.patch)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) signature(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	file, release, err := input(r, r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read file. %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	enc := sync.NewStreamEncoder[sync.Chunk](w)
+
+	s := sync.New()
+	var writeErr error
+	err = s.Signature(file, func(c sync.Chunk) {
+		if writeErr == nil {
+			writeErr = enc.Encode(c)
+		}
+	})
+
+	if err == nil {
+		err = writeErr
+	}
+
+	if err == nil {
+		err = enc.Flush()
+	}
+
+	abortOnError(err)
+}
+
+func (h *Handler) delta(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, fmt.Sprintf("expected multipart body. %v", err), http.StatusBadRequest)
+		return
+	}
+
+	part, err := reader.NextPart()
+	if err != nil || part.FormName() != "signature" {
+		http.Error(w, "first part should be signature", http.StatusBadRequest)
+		return
+	}
+
+	chunks, err := sync.DeserializeChunks(part)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to deserialize signature. %v", err), http.StatusBadRequest)
+		return
+	}
+
+	part, err = reader.NextPart()
+	if err != nil || part.FormName() != "file" {
+		http.Error(w, "second part should be file", http.StatusBadRequest)
+		return
+	}
+
+	file, release, err := input(r, part)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read file. %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	enc := sync.NewStreamEncoder[sync.Delta](w)
+
+	s := sync.New()
+	var writeErr error
+	err = s.DeltaFromChunks(file, chunks, sync.Checkpoint{}, func(d sync.Delta) {
+		if writeErr == nil {
+			writeErr = enc.Encode(d)
+		}
+	}, nil)
+
+	if err == nil {
+		err = writeErr
+	}
+
+	if err == nil {
+		err = enc.Flush()
+	}
+
+	abortOnError(err)
+}
+
+func (h *Handler) basis(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, ok := h.basisPath(w, r, "/basis/")
+	if !ok {
+		return
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".upload-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if _, err := io.Copy(file, r.Body); err != nil {
+		http.Error(w, fmt.Sprintf("unable to store basis. %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if err := file.Close(); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *Handler) patch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	path, ok := h.basisPath(w, r, "/patch/")
+	if !ok {
+		return
+	}
+
+	basis, err := os.Open(path)
+	if os.IsNotExist(err) {
+		http.Error(w, "basis not found", http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer basis.Close()
+
+	delta, release, err := input(r, r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("unable to read delta. %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	s := sync.New()
+	patcher := s.NewPatcher(basis, w)
+
+	err = sync.NewStreamDecoder[sync.Delta](delta).Decode(patcher.Apply)
+	if err != nil && patcher.Written == 0 {
+		http.Error(w, fmt.Sprintf("unable to apply delta. %v", err), http.StatusBadRequest)
+		return
+	}
+
+	abortOnError(err)
+}
+
+// basisPath returns path in root from request url, or responds with error when path is not valid
+func (h *Handler) basisPath(w http.ResponseWriter, r *http.Request, prefix string) (string, bool) {
+	name := strings.TrimPrefix(r.URL.Path, prefix)
+	if !fs.ValidPath(name) || name == "." {
+		http.Error(w, fmt.Sprintf("invalid path '%s'", name), http.StatusBadRequest)
+		return "", false
+	}
+
+	return filepath.Join(h.root, filepath.FromSlash(name)), true
+}
+
+// input returns data of request which is read while response is written, release has to be called once it is processed.
+// HTTP/2 request body can be read together with writing response, for HTTP/1.x data is spooled
+func input(r *http.Request, data io.Reader) (io.Reader, func(), error) {
+	if r.ProtoMajor >= 2 {
+		return data, func() {}, nil
+	}
+
+	file, err := spool(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return file, func() { removeSpooled(file) }, nil
+}
+
+// spool copies data to temporary file and rewinds it, file should be released with removeSpooled
+func spool(data io.Reader) (*os.File, error) {
+	file, err := os.CreateTemp("", "sync-http-*")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = io.Copy(file, data)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+
+	if err != nil {
+		removeSpooled(file)
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func removeSpooled(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
+
+// abortOnError breaks connection when error happens after response was partially sent,
+// so client does not treat response as complete
+func abortOnError(err error) {
+	if err != nil {
+		log.Printf("unable to finish response. %v", err)
+		panic(http.ErrAbortHandler)
+	}
+}
@@ -0,0 +1,158 @@
+package httpapi
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignatureDeltaAndPatchRecreateNewFile(t *testing.T) {
+	server := httptest.NewServer(NewHandler(t.TempDir()))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	oldData := randomData(20000, 1)
+	newData := append(append(append([]byte{}, oldData[:5000]...), []byte("inserted data")...), oldData[5000:]...)
+
+	require.Nil(t, client.PutBasis("dir/file", bytes.NewReader(oldData)))
+
+	signature := readAll(t, func() (io.ReadCloser, error) {
+		return client.Signature(bytes.NewReader(oldData))
+	})
+
+	s := sync.New()
+	expectedChunks := []sync.Chunk{}
+	s.Signature(bytes.NewReader(oldData), func(c sync.Chunk) {
+		expectedChunks = append(expectedChunks, c)
+	})
+	chunks, err := sync.DeserializeChunks(bytes.NewReader(signature))
+	require.Nil(t, err)
+	require.Equal(t, expectedChunks, chunks)
+
+	delta := readAll(t, func() (io.ReadCloser, error) {
+		return client.Delta(bytes.NewReader(signature), bytes.NewReader(newData))
+	})
+
+	deltas, err := sync.DeserializeDelta(bytes.NewReader(delta))
+	require.Nil(t, err)
+	require.Less(t, len(deltas), len(newData)/4)
+
+	patched := readAll(t, func() (io.ReadCloser, error) {
+		return client.Patch("dir/file", bytes.NewReader(delta))
+	})
+	require.Equal(t, newData, patched)
+}
+
+func Test_ResponsesAreStreamedWithChunkedEncoding(t *testing.T) {
+	server := httptest.NewServer(NewHandler(t.TempDir()))
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/signature", "application/octet-stream", bytes.NewReader(randomData(100000, 2)))
+	require.Nil(t, err)
+	defer response.Body.Close()
+
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.Equal(t, []string{"chunked"}, response.TransferEncoding)
+}
+
+func Test_HTTP2RequestsAreStreamedTogetherWithResponses(t *testing.T) {
+	server := httptest.NewUnstartedServer(NewHandler(t.TempDir()))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+	client := NewClient(server.URL)
+	client.HTTP = server.Client()
+
+	data := randomData(1<<20, 3)
+	s := sync.New()
+	expectedChunks := []sync.Chunk{}
+	s.Signature(bytes.NewReader(data), func(c sync.Chunk) {
+		expectedChunks = append(expectedChunks, c)
+	})
+
+	// request body ends only after part of response is received
+	body, bodyWriter := io.Pipe()
+	responded := make(chan struct{})
+	timedOut := make(chan bool, 1)
+	go func() {
+		bodyWriter.Write(data)
+		select {
+		case <-responded:
+			timedOut <- false
+		case <-time.After(10 * time.Second):
+			timedOut <- true
+		}
+		bodyWriter.Close()
+	}()
+
+	signature, err := client.Signature(body)
+	require.Nil(t, err)
+	defer signature.Close()
+
+	first := make([]byte, 1)
+	_, err = io.ReadFull(signature, first)
+	require.Nil(t, err)
+	close(responded)
+	require.False(t, <-timedOut)
+
+	rest, err := io.ReadAll(signature)
+	require.Nil(t, err)
+	chunks, err := sync.DeserializeChunks(bytes.NewReader(append(first, rest...)))
+	require.Nil(t, err)
+	require.Equal(t, expectedChunks, chunks)
+}
+
+func Test_InvalidRequestsAreRejected(t *testing.T) {
+	root := t.TempDir()
+	server := httptest.NewServer(NewHandler(root))
+	defer server.Close()
+	client := NewClient(server.URL)
+
+	_, err := client.Patch("missing", strings.NewReader(""))
+	require.ErrorContains(t, err, "unexpected status 404")
+
+	require.Nil(t, client.PutBasis("file", strings.NewReader("data")))
+	_, err = client.Patch("file", strings.NewReader("not a delta"))
+	require.ErrorContains(t, err, "unexpected status 400")
+
+	// path is cleaned before routing, so it never reaches outside of root
+	err = client.PutBasis("../outside", strings.NewReader("data"))
+	require.Error(t, err)
+	require.NoFileExists(t, filepath.Join(filepath.Dir(root), "outside"))
+
+	response, err := http.Post(server.URL+"/delta", "text/plain", strings.NewReader("data"))
+	require.Nil(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	response, err = http.Get(server.URL + "/signature")
+	require.Nil(t, err)
+	response.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+}
+
+func readAll(t *testing.T, call func() (io.ReadCloser, error)) []byte {
+	body, err := call()
+	require.Nil(t, err)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	require.Nil(t, err)
+	return data
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
@@ -0,0 +1,258 @@
+// Package inspect decodes signature and delta files into human readable summaries and listings
+package inspect
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// SignatureSummary describes signature file
+type SignatureSummary struct {
+	ChunkSize int `json:"chunkSize"`
+	Chunks    int `json:"chunks"`
+	// MaxBasisSize is upper bound of basis size, last chunk can be shorter
+	MaxBasisSize     int64 `json:"maxBasisSize"`
+	StrongHashLength int   `json:"strongHashLength"`
+	// DuplicateChunks is number of chunks with the same strong hash as some earlier chunk
+	DuplicateChunks int `json:"duplicateChunks"`
+	// RollingHashCollisions is number of chunks with the same rolling hash but different strong hash than earlier chunk
+	RollingHashCollisions int `json:"rollingHashCollisions"`
+}
+
+// DeltaSummary describes delta file
+type DeltaSummary struct {
+	ChunkSize   int            `json:"chunkSize"`
+	Version     byte           `json:"version"`
+	Compression string         `json:"compression"`
+	Operations  map[string]int `json:"operations"`
+	// LiteralBytes is number of new bytes stored in delta, for compressed data it is size after compression
+	LiteralBytes int64 `json:"literalBytes"`
+	// CopiedBytes is upper bound of bytes copied from basis, last chunk can be shorter
+	CopiedBytes int64 `json:"copiedBytes"`
+	HoleBytes   int64 `json:"holeBytes"`
+	// TargetCopiedBytes is number of bytes copied from earlier part of new file
+	TargetCopiedBytes int64 `json:"targetCopiedBytes"`
+	DeltaSize         int64 `json:"deltaSize"`
+	// CompressionRatio is size of delta file divided by size of new file, when it is known
+	CompressionRatio float64 `json:"compressionRatio,omitempty"`
+}
+
+// ChunkEntry is single chunk of signature listing
+type ChunkEntry struct {
+	Id          uint32 `json:"id"`
+	Offset      int64  `json:"offset"`
+	RollingHash uint32 `json:"rollingHash"`
+	StrongHash  string `json:"strongHash"`
+}
+
+// DeltaEntry is single delta of listing, Chunk is set for existing data and Offset in basis for copied range.
+// Basis is set for chunks copied from other than first basis of multi-basis delta
+type DeltaEntry struct {
+	Id        uint32  `json:"id"`
+	Operation string  `json:"operation"`
+	Basis     uint32  `json:"basis,omitempty"`
+	Chunk     *uint32 `json:"chunk,omitempty"`
+	Offset    *int64  `json:"offset,omitempty"`
+	Size      int64   `json:"size"`
+}
+
+// chunkSize is size of data described by chunk of signature and ExistingData delta
+var chunkSize = int64(sync.DefaultChunkSize())
+
+func SummarizeSignature(chunks []sync.Chunk) SignatureSummary {
+	summary := SignatureSummary{
+		ChunkSize:    int(chunkSize),
+		Chunks:       len(chunks),
+		MaxBasisSize: int64(len(chunks)) * chunkSize,
+	}
+
+	strongHashes := map[string]bool{}
+	rollingHashes := map[uint32]bool{}
+	for _, c := range chunks {
+		if len(c.StrongHash) > summary.StrongHashLength {
+			summary.StrongHashLength = len(c.StrongHash)
+		}
+
+		if strongHashes[string(c.StrongHash)] {
+			summary.DuplicateChunks++
+			continue
+		}
+
+		if rollingHashes[c.RollingHash] {
+			summary.RollingHashCollisions++
+		}
+
+		strongHashes[string(c.StrongHash)] = true
+		rollingHashes[c.RollingHash] = true
+	}
+
+	return summary
+}
+
+// SummarizeDelta describes deltas read from file of deltaSize bytes.
+// newSize is size of new file used for compression ratio, when it is not positive
+// it is estimated from deltas, which is possible only when they have no compressed data
+func SummarizeDelta(header sync.DeltaHeader, deltas []sync.Delta, deltaSize int64, newSize int64) DeltaSummary {
+	summary := DeltaSummary{
+		ChunkSize:   int(chunkSize),
+		Version:     header.Version,
+		Compression: header.Codec.String(),
+		Operations:  map[string]int{},
+		DeltaSize:   deltaSize,
+	}
+
+	for _, d := range deltas {
+		summary.Operations[d.Operation.String()]++
+
+		switch d.Operation {
+		case sync.NewData:
+			summary.LiteralBytes += int64(len(d.Data))
+		case sync.CompressedData:
+			// first byte is codec
+			summary.LiteralBytes += int64(len(d.Data)) - 1
+		case sync.ExistingData:
+			summary.CopiedBytes += chunkSize
+		case sync.CopyRange:
+			_, length := sync.CopyRangeOf(d)
+			summary.CopiedBytes += length
+		case sync.Hole:
+			summary.HoleBytes += sync.HoleSize(d)
+		case sync.TargetCopy:
+			_, length := sync.CopyRangeOf(d)
+			summary.TargetCopiedBytes += length
+		}
+	}
+
+	if newSize <= 0 && summary.Operations[sync.CompressedData.String()] == 0 {
+		newSize = summary.LiteralBytes + summary.CopiedBytes + summary.HoleBytes + summary.TargetCopiedBytes
+	}
+
+	if newSize > 0 {
+		summary.CompressionRatio = float64(deltaSize) / float64(newSize)
+	}
+
+	return summary
+}
+
+func ListChunks(chunks []sync.Chunk) []ChunkEntry {
+	entries := make([]ChunkEntry, 0, len(chunks))
+	for _, c := range chunks {
+		entries = append(entries, ChunkEntry{
+			Id:          c.Id,
+			Offset:      int64(c.Id) * chunkSize,
+			RollingHash: c.RollingHash,
+			StrongHash:  hex.EncodeToString(c.StrongHash),
+		})
+	}
+
+	return entries
+}
+
+func ListDeltas(deltas []sync.Delta) []DeltaEntry {
+	entries := make([]DeltaEntry, 0, len(deltas))
+	for _, d := range deltas {
+		entry := DeltaEntry{
+			Id:        d.Id,
+			Operation: d.Operation.String(),
+			Size:      int64(len(d.Data)),
+		}
+
+		switch d.Operation {
+		case sync.ExistingData:
+			if len(d.Data) == 4 || len(d.Data) == 8 {
+				basis, chunk := sync.ExistingDataOf(d)
+				entry.Basis = basis
+				entry.Chunk = &chunk
+			}
+			entry.Size = chunkSize
+		case sync.CopyRange, sync.TargetCopy:
+			offset, length := sync.CopyRangeOf(d)
+			entry.Offset = &offset
+			entry.Size = length
+		case sync.CompressedData:
+			if entry.Size > 0 {
+				entry.Size--
+			}
+		case sync.Hole:
+			entry.Size = sync.HoleSize(d)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// WriteJSON writes summary or listing as indented JSON
+func WriteJSON(w io.Writer, value interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+func WriteSignatureSummary(w io.Writer, s SignatureSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "type:\tsignature\n")
+	fmt.Fprintf(tw, "chunk size:\t%d\n", s.ChunkSize)
+	fmt.Fprintf(tw, "chunks:\t%d\n", s.Chunks)
+	fmt.Fprintf(tw, "basis size:\tup to %d bytes\n", s.MaxBasisSize)
+	fmt.Fprintf(tw, "strong hash length:\t%d\n", s.StrongHashLength)
+	fmt.Fprintf(tw, "duplicate chunks:\t%d\n", s.DuplicateChunks)
+	fmt.Fprintf(tw, "rolling hash collisions:\t%d\n", s.RollingHashCollisions)
+	return tw.Flush()
+}
+
+func WriteDeltaSummary(w io.Writer, s DeltaSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "type:\tdelta\n")
+	fmt.Fprintf(tw, "version:\t%d\n", s.Version)
+	fmt.Fprintf(tw, "compression:\t%s\n", s.Compression)
+	fmt.Fprintf(tw, "chunk size:\t%d\n", s.ChunkSize)
+	for _, o := range []sync.Operation{sync.NewData, sync.ExistingData, sync.CopyRange, sync.TargetCopy, sync.Hole, sync.CompressedData} {
+		fmt.Fprintf(tw, "%s operations:\t%d\n", o, s.Operations[o.String()])
+	}
+	fmt.Fprintf(tw, "literal bytes:\t%d\n", s.LiteralBytes)
+	fmt.Fprintf(tw, "copied bytes:\tup to %d\n", s.CopiedBytes)
+	fmt.Fprintf(tw, "hole bytes:\t%d\n", s.HoleBytes)
+	fmt.Fprintf(tw, "target copied bytes:\t%d\n", s.TargetCopiedBytes)
+	fmt.Fprintf(tw, "delta size:\t%d\n", s.DeltaSize)
+	if s.CompressionRatio > 0 {
+		fmt.Fprintf(tw, "compression ratio:\t%.4f\n", s.CompressionRatio)
+	} else {
+		fmt.Fprintf(tw, "compression ratio:\tunknown (provide new file)\n")
+	}
+	return tw.Flush()
+}
+
+func WriteChunks(w io.Writer, entries []ChunkEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOFFSET\tROLLING HASH\tSTRONG HASH")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%d\t%d\t%08x\t%s\n", e.Id, e.Offset, e.RollingHash, e.StrongHash)
+	}
+	return tw.Flush()
+}
+
+func WriteDeltas(w io.Writer, entries []DeltaEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOPERATION\tCHUNK\tOFFSET\tSIZE")
+	for _, e := range entries {
+		chunk, offset := "-", "-"
+		if e.Chunk != nil {
+			chunk = fmt.Sprint(*e.Chunk)
+			if e.Basis != 0 {
+				chunk = fmt.Sprintf("%d:%d", e.Basis, *e.Chunk)
+			}
+		}
+		if e.Offset != nil {
+			offset = fmt.Sprint(*e.Offset)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\n", e.Id, e.Operation, chunk, offset, e.Size)
+	}
+	return tw.Flush()
+}
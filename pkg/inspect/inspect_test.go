@@ -0,0 +1,97 @@
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SummarizeSignatureCountsDuplicatesAndCollisions(t *testing.T) {
+	chunks := []sync.Chunk{
+		{Id: 0, RollingHash: 1, StrongHash: []byte{1, 1}},
+		{Id: 1, RollingHash: 2, StrongHash: []byte{2, 2}},
+		{Id: 2, RollingHash: 1, StrongHash: []byte{1, 1}},
+		{Id: 3, RollingHash: 2, StrongHash: []byte{3, 3}},
+	}
+
+	require.Equal(t, SignatureSummary{
+		ChunkSize:             16,
+		Chunks:                4,
+		MaxBasisSize:          64,
+		StrongHashLength:      2,
+		DuplicateChunks:       1,
+		RollingHashCollisions: 1,
+	}, SummarizeSignature(chunks))
+}
+
+func Test_SummarizeDeltaCountsBytesOfOperations(t *testing.T) {
+	deltas := []sync.Delta{
+		{Id: 0, Operation: sync.ExistingData, Data: []byte{0, 0, 0, 1}},
+		{Id: 1, Operation: sync.NewData, Data: []byte("new data")},
+		{Id: 2, Operation: sync.Hole, Data: []byte{0, 0, 0, 0, 0, 0, 1, 0}},
+		{Id: 3, Operation: sync.ExistingData, Data: []byte{0, 0, 0, 2}},
+	}
+
+	summary := SummarizeDelta(sync.DeltaHeader{}, deltas, 100, 0)
+	require.Equal(t, map[string]int{"existing": 2, "new": 1, "hole": 1}, summary.Operations)
+	require.Equal(t, int64(8), summary.LiteralBytes)
+	require.Equal(t, int64(32), summary.CopiedBytes)
+	require.Equal(t, int64(256), summary.HoleBytes)
+	require.Equal(t, "none", summary.Compression)
+	require.InDelta(t, 100.0/296, summary.CompressionRatio, 0.0001)
+
+	compressed := append(deltas, sync.Delta{Id: 4, Operation: sync.CompressedData, Data: []byte{2, 5, 5, 5}})
+	summary = SummarizeDelta(sync.NewDeltaHeader(sync.Zstd), compressed, 100, 0)
+	require.Equal(t, "zstd", summary.Compression)
+	require.Equal(t, int64(11), summary.LiteralBytes)
+	require.Zero(t, summary.CompressionRatio)
+
+	summary = SummarizeDelta(sync.NewDeltaHeader(sync.Zstd), compressed, 100, 400)
+	require.Equal(t, 0.25, summary.CompressionRatio)
+}
+
+func Test_ListDeltasDescribesEveryDelta(t *testing.T) {
+	deltas := []sync.Delta{
+		{Id: 0, Operation: sync.ExistingData, Data: []byte{0, 0, 1, 0}},
+		{Id: 1, Operation: sync.NewData, Data: []byte("abc")},
+		{Id: 2, Operation: sync.CompressedData, Data: []byte{1, 9, 9}},
+		sync.CopyRangeDelta(3, 40, 100),
+	}
+
+	entries := ListDeltas(deltas)
+	chunk := uint32(256)
+	offset := int64(40)
+	require.Equal(t, []DeltaEntry{
+		{Id: 0, Operation: "existing", Chunk: &chunk, Size: 16},
+		{Id: 1, Operation: "new", Size: 3},
+		{Id: 2, Operation: "compressed", Size: 2},
+		{Id: 3, Operation: "range", Offset: &offset, Size: 100},
+	}, entries)
+
+	text := bytes.Buffer{}
+	require.Nil(t, WriteDeltas(&text, entries))
+	require.Equal(t, ""+
+		"ID  OPERATION   CHUNK  OFFSET  SIZE\n"+
+		"0   existing    256    -       16\n"+
+		"1   new         -      -       3\n"+
+		"2   compressed  -      -       2\n"+
+		"3   range       -      40      100\n", text.String())
+
+	out := bytes.Buffer{}
+	require.Nil(t, WriteJSON(&out, entries))
+	decoded := []DeltaEntry{}
+	require.Nil(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Equal(t, entries, decoded)
+}
+
+func Test_ListChunksContainsOffsetsAndHexHashes(t *testing.T) {
+	entries := ListChunks([]sync.Chunk{{Id: 2, RollingHash: 10, StrongHash: []byte{0xab, 0x01}}})
+	require.Equal(t, []ChunkEntry{{Id: 2, Offset: 32, RollingHash: 10, StrongHash: "ab01"}}, entries)
+
+	text := bytes.Buffer{}
+	require.Nil(t, WriteChunks(&text, entries))
+	require.Contains(t, text.String(), "2   32      0000000a      ab01")
+}
@@ -0,0 +1,213 @@
+package inspect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+const (
+	// Identical chunks are at the same position in both files
+	Identical = "identical"
+	// Moved chunks of second file are at other position in first file
+	Moved = "moved"
+	// Different chunks of second file are not in first file
+	Different = "different"
+)
+
+// Signature is signature read from file, header tells with which chunk size and of how big file it was calculated
+type Signature struct {
+	Header sync.SignatureHeader
+	Chunks []sync.Chunk
+}
+
+// SignatureDiff describes how second file differs from first one, when only their signatures are known.
+// Only aligned chunks can be compared, so data shifted by other number of bytes than multiple of chunk size
+// is reported as different, even if delta would find it
+type SignatureDiff struct {
+	ChunkSize       int         `json:"chunkSize"`
+	FirstChunks     int         `json:"firstChunks"`
+	SecondChunks    int         `json:"secondChunks"`
+	IdenticalChunks int         `json:"identicalChunks"`
+	MovedChunks     int         `json:"movedChunks"`
+	DifferentChunks int         `json:"differentChunks"`
+	Ranges          []DiffRange `json:"ranges"`
+	// TransferBytes is number of bytes of different chunks, which has to be sent to turn first file into second
+	TransferBytes int64 `json:"transferBytes"`
+}
+
+// DiffRange is range of consecutive chunks of second file with the same status,
+// for identical and moved ranges FirstChunk is where range starts in first file
+type DiffRange struct {
+	Status      string  `json:"status"`
+	SecondChunk uint32  `json:"secondChunk"`
+	Chunks      int     `json:"chunks"`
+	FirstChunk  *uint32 `json:"firstChunk,omitempty"`
+}
+
+// DiffSignatures compares signatures of two files, they have to be calculated with the same chunk size.
+// Chunk size is known only from signature header, so signatures without it are rejected
+func DiffSignatures(first Signature, second Signature) (SignatureDiff, error) {
+	if err := checkSignature("first", first); err != nil {
+		return SignatureDiff{}, err
+	}
+
+	if err := checkSignature("second", second); err != nil {
+		return SignatureDiff{}, err
+	}
+
+	if first.Header.ChunkSize != second.Header.ChunkSize {
+		return SignatureDiff{}, fmt.Errorf("signatures have different chunk sizes %d and %d",
+			first.Header.ChunkSize, second.Header.ChunkSize)
+	}
+
+	if len(first.Chunks) > 0 && len(second.Chunks) > 0 && len(first.Chunks[0].StrongHash) != len(second.Chunks[0].StrongHash) {
+		return SignatureDiff{}, fmt.Errorf("signatures have different strong hash lengths %d and %d",
+			len(first.Chunks[0].StrongHash), len(second.Chunks[0].StrongHash))
+	}
+
+	chunkSize := int64(second.Header.ChunkSize)
+	diff := SignatureDiff{
+		ChunkSize:    int(chunkSize),
+		FirstChunks:  len(first.Chunks),
+		SecondChunks: len(second.Chunks),
+		Ranges:       []DiffRange{},
+	}
+
+	byId := map[uint32]sync.Chunk{}
+	// first chunk with given hashes, duplicates do not change where data can be found
+	byHashes := map[string]uint32{}
+	for _, c := range first.Chunks {
+		byId[c.Id] = c
+		if _, ok := byHashes[chunkKey(c)]; !ok {
+			byHashes[chunkKey(c)] = c.Id
+		}
+	}
+
+	for _, c := range second.Chunks {
+		status, firstId := Different, uint32(0)
+		if same, ok := byId[c.Id]; ok && sameChunk(same, c) {
+			status, firstId = Identical, c.Id
+		} else if next, ok := diff.nextMoved(c); ok && sameChunk(byId[next], c) {
+			// moved range continues when following chunk of first file also matches
+			status, firstId = Moved, next
+		} else if id, ok := byHashes[chunkKey(c)]; ok {
+			status, firstId = Moved, id
+		}
+
+		switch status {
+		case Identical:
+			diff.IdenticalChunks++
+		case Moved:
+			diff.MovedChunks++
+		case Different:
+			diff.DifferentChunks++
+			length := chunkSize
+			if left := second.Header.Size - int64(c.Id)*chunkSize; left < length {
+				// last chunk is shorter
+				length = left
+			}
+			diff.TransferBytes += length
+		}
+
+		diff.add(status, c.Id, firstId)
+	}
+
+	return diff, nil
+}
+
+// checkSignature checks that signature has header and its chunks cover whole file
+func checkSignature(name string, s Signature) error {
+	if s.Header.Version == 0 || s.Header.ChunkSize == 0 {
+		return fmt.Errorf("%s signature has no header, so its chunk size is not known", name)
+	}
+
+	chunkSize := int64(s.Header.ChunkSize)
+	expected := (s.Header.Size + chunkSize - 1) / chunkSize
+	if int64(len(s.Chunks)) != expected {
+		return fmt.Errorf("%s signature has %d chunks, but file of %d bytes has %d chunks of size %d",
+			name, len(s.Chunks), s.Header.Size, expected, chunkSize)
+	}
+
+	return nil
+}
+
+// nextMoved returns chunk of first file which would continue last moved range
+func (d *SignatureDiff) nextMoved(c sync.Chunk) (uint32, bool) {
+	if len(d.Ranges) == 0 {
+		return 0, false
+	}
+
+	last := d.Ranges[len(d.Ranges)-1]
+	if last.Status != Moved || last.SecondChunk+uint32(last.Chunks) != c.Id {
+		return 0, false
+	}
+
+	return *last.FirstChunk + uint32(last.Chunks), true
+}
+
+func (d *SignatureDiff) add(status string, secondId uint32, firstId uint32) {
+	if len(d.Ranges) > 0 {
+		last := &d.Ranges[len(d.Ranges)-1]
+		continues := last.Status == status && last.SecondChunk+uint32(last.Chunks) == secondId
+		if continues && status != Different {
+			continues = *last.FirstChunk+uint32(last.Chunks) == firstId
+		}
+
+		if continues {
+			last.Chunks++
+			return
+		}
+	}
+
+	r := DiffRange{Status: status, SecondChunk: secondId, Chunks: 1}
+	if status != Different {
+		r.FirstChunk = &firstId
+	}
+	d.Ranges = append(d.Ranges, r)
+}
+
+func sameChunk(a sync.Chunk, b sync.Chunk) bool {
+	return a.RollingHash == b.RollingHash && bytes.Equal(a.StrongHash, b.StrongHash)
+}
+
+func chunkKey(c sync.Chunk) string {
+	return fmt.Sprintf("%08x%x", c.RollingHash, c.StrongHash)
+}
+
+func WriteSignatureDiff(w io.Writer, d SignatureDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "chunk size:\t%d\n", d.ChunkSize)
+	fmt.Fprintf(tw, "first chunks:\t%d\n", d.FirstChunks)
+	fmt.Fprintf(tw, "second chunks:\t%d\n", d.SecondChunks)
+	fmt.Fprintf(tw, "identical chunks:\t%d\n", d.IdenticalChunks)
+	fmt.Fprintf(tw, "moved chunks:\t%d\n", d.MovedChunks)
+	fmt.Fprintf(tw, "different chunks:\t%d\n", d.DifferentChunks)
+	fmt.Fprintf(tw, "transfer bytes:\tup to %d\n", d.TransferBytes)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATUS\tSECOND CHUNKS\tFIRST CHUNKS")
+	for _, r := range d.Ranges {
+		first := "-"
+		if r.FirstChunk != nil {
+			first = chunkRange(*r.FirstChunk, r.Chunks)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Status, chunkRange(r.SecondChunk, r.Chunks), first)
+	}
+	return tw.Flush()
+}
+
+func chunkRange(start uint32, count int) string {
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+
+	return fmt.Sprintf("%d-%d", start, start+uint32(count)-1)
+}
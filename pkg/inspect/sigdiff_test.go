@@ -0,0 +1,90 @@
+package inspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func signatureOf(t *testing.T, data string) Signature {
+	s := sync.New()
+	chunks := []sync.Chunk{}
+	require.Nil(t, s.Signature(bytes.NewReader([]byte(data)), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	}))
+
+	return Signature{Header: sync.NewSignatureHeader(sync.DefaultChunkSize(), int64(len(data))), Chunks: chunks}
+}
+
+func Test_DiffSignaturesFindsIdenticalMovedAndDifferentRanges(t *testing.T) {
+	a := "aaaaaaaaaaaaaaaa"
+	b := "bbbbbbbbbbbbbbbb"
+	c := "cccccccccccccccc"
+	d := "dddddddddddddddd"
+	x := "xxxxxxxxxxxxxxxx"
+
+	diff, err := DiffSignatures(signatureOf(t, a+b+c+d), signatureOf(t, a+c+d+x+x+"tail"))
+	require.Nil(t, err)
+
+	zero, two := uint32(0), uint32(2)
+	require.Equal(t, SignatureDiff{
+		ChunkSize:       16,
+		FirstChunks:     4,
+		SecondChunks:    6,
+		IdenticalChunks: 1,
+		MovedChunks:     2,
+		DifferentChunks: 3,
+		Ranges: []DiffRange{
+			{Status: Identical, SecondChunk: 0, Chunks: 1, FirstChunk: &zero},
+			{Status: Moved, SecondChunk: 1, Chunks: 2, FirstChunk: &two},
+			{Status: Different, SecondChunk: 3, Chunks: 3},
+		},
+		// two whole chunks and 4 bytes of last one
+		TransferBytes: 36,
+	}, diff)
+}
+
+func Test_DiffSignaturesOfTheSameFileIsSingleIdenticalRange(t *testing.T) {
+	data := "0123456789abcdef0123456789ABCDEFshort"
+	diff, err := DiffSignatures(signatureOf(t, data), signatureOf(t, data))
+	require.Nil(t, err)
+
+	require.Len(t, diff.Ranges, 1)
+	require.Equal(t, Identical, diff.Ranges[0].Status)
+	require.Equal(t, 3, diff.Ranges[0].Chunks)
+	require.Equal(t, int64(0), diff.TransferBytes)
+}
+
+func Test_DiffSignaturesRejectsDifferentStrongHashLengths(t *testing.T) {
+	header := sync.NewSignatureHeader(16, 10)
+	_, err := DiffSignatures(
+		Signature{Header: header, Chunks: []sync.Chunk{{Id: 0, StrongHash: []byte{1}}}},
+		Signature{Header: header, Chunks: []sync.Chunk{{Id: 0, StrongHash: []byte{1, 2}}}},
+	)
+	require.ErrorContains(t, err, "different strong hash lengths")
+}
+
+func Test_DiffSignaturesRequiresTheSameKnownChunkSize(t *testing.T) {
+	data := "0123456789abcdef0123456789ABCDEF"
+	signature := signatureOf(t, data)
+
+	withoutHeader := Signature{Chunks: signature.Chunks}
+	_, err := DiffSignatures(withoutHeader, signature)
+	require.ErrorContains(t, err, "first signature has no header")
+
+	s := sync.NewWithChunkSize(8)
+	chunks := []sync.Chunk{}
+	require.Nil(t, s.Signature(bytes.NewReader([]byte(data)), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	}))
+	other := Signature{Header: sync.NewSignatureHeader(8, int64(len(data))), Chunks: chunks}
+
+	_, err = DiffSignatures(signature, other)
+	require.ErrorContains(t, err, "different chunk sizes 16 and 8")
+
+	truncated := Signature{Header: signature.Header, Chunks: signature.Chunks[:1]}
+	_, err = DiffSignatures(signature, truncated)
+	require.ErrorContains(t, err, "second signature has 1 chunks, but file of 32 bytes has 2 chunks")
+}
@@ -0,0 +1,111 @@
+package librsync
+
+import (
+	"crypto"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	_ "golang.org/x/crypto/md4"
+)
+
+// rollsumCharOffset is added to every byte by librsync rollsum
+const rollsumCharOffset = 31
+
+const (
+	rabinKarpMult = 0x08104225
+	rabinKarpSeed = 1
+	// rabinKarpAdjust is rabinKarpMult - 1, it removes seed of outgoing byte
+	rabinKarpAdjust = 0x08104224
+)
+
+// weakSum is rolling checksum over window of data
+type weakSum interface {
+	Reset()
+	Update(data []byte)
+	// Rotate removes out byte from beginning of window and adds in byte at its end
+	Rotate(out byte, in byte)
+	Digest() uint32
+}
+
+// rollsum is Adler-32 like checksum used by librsync
+type rollsum struct {
+	count uint32
+	s1    uint32
+	s2    uint32
+}
+
+func (r *rollsum) Reset() {
+	*r = rollsum{}
+}
+
+func (r *rollsum) Update(data []byte) {
+	for _, b := range data {
+		r.s1 += uint32(b) + rollsumCharOffset
+		r.s2 += r.s1
+	}
+	r.count += uint32(len(data))
+}
+
+func (r *rollsum) Rotate(out byte, in byte) {
+	r.s1 += uint32(in) - uint32(out)
+	r.s2 += r.s1 - r.count*(uint32(out)+rollsumCharOffset)
+}
+
+func (r *rollsum) Digest() uint32 {
+	return r.s2<<16 | r.s1&0xffff
+}
+
+// rabinKarp is polynomial rolling hash used by newer librsync signatures
+type rabinKarp struct {
+	hash uint32
+	// mult is rabinKarpMult to the power of window length
+	mult uint32
+}
+
+func (r *rabinKarp) Reset() {
+	r.hash = rabinKarpSeed
+	r.mult = 1
+}
+
+func (r *rabinKarp) Update(data []byte) {
+	for _, b := range data {
+		r.hash = r.hash*rabinKarpMult + uint32(b)
+		r.mult *= rabinKarpMult
+	}
+}
+
+func (r *rabinKarp) Rotate(out byte, in byte) {
+	r.hash = r.hash*rabinKarpMult + uint32(in) - r.mult*(uint32(out)+rabinKarpAdjust)
+}
+
+func (r *rabinKarp) Digest() uint32 {
+	return r.hash
+}
+
+func newWeakSum(magic Magic) weakSum {
+	var sum weakSum = &rollsum{}
+	if magic == RabinKarpMD4SigMagic || magic == RabinKarpBlake2SigMagic {
+		sum = &rabinKarp{}
+	}
+
+	sum.Reset()
+	return sum
+}
+
+func newStrongHash(magic Magic) hash.Hash {
+	if magic == MD4SigMagic || magic == RabinKarpMD4SigMagic {
+		return crypto.MD4.New()
+	}
+
+	// librsync uses unkeyed BLAKE2b with 32 bytes output
+	h, _ := blake2b.New256(nil)
+	return h
+}
+
+func strongHashSize(magic Magic) uint32 {
+	if magic == MD4SigMagic || magic == RabinKarpMD4SigMagic {
+		return 16
+	}
+
+	return blake2b.Size256
+}
@@ -0,0 +1,267 @@
+package librsync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// opcodes of librsync delta format, literal and copy opcodes are followed by
+// big endian parameters of 1, 2, 4 or 8 bytes
+const (
+	opEnd       = 0x00
+	opLiteral1  = 0x01
+	opLiteral64 = 0x40
+	opLiteralN1 = 0x41
+	opLiteralN8 = 0x44
+	opCopyN1N1  = 0x45
+	opCopyN8N8  = 0x54
+)
+
+// maxLiteralLen limits size of data kept in memory for single literal command
+const maxLiteralLen = 1024 * 1024
+
+type CommandKind byte
+
+const (
+	LiteralCommand CommandKind = iota
+	CopyCommand
+)
+
+// Command of librsync delta, literal carries Data, copy refers to Length bytes at Offset of basis file
+type Command struct {
+	Kind   CommandKind
+	Offset int64
+	Length int64
+	Data   []byte
+}
+
+// DeltaWriter encodes commands in librsync delta format,
+// consecutive copies of adjacent basis ranges are merged into single command
+type DeltaWriter struct {
+	out     *bufio.Writer
+	literal []byte
+	copy    Command
+}
+
+func NewDeltaWriter(w io.Writer) (*DeltaWriter, error) {
+	writer := &DeltaWriter{out: bufio.NewWriter(w)}
+	if err := writer.writeInt(uint64(DeltaMagic), 4); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+func (w *DeltaWriter) Literal(data []byte) error {
+	if err := w.flushCopy(); err != nil {
+		return err
+	}
+
+	w.literal = append(w.literal, data...)
+	if len(w.literal) >= maxLiteralLen {
+		return w.flushLiteral()
+	}
+
+	return nil
+}
+
+func (w *DeltaWriter) Copy(offset int64, length int64) error {
+	if err := w.flushLiteral(); err != nil {
+		return err
+	}
+
+	if w.copy.Length > 0 && w.copy.Offset+w.copy.Length == offset {
+		w.copy.Length += length
+		return nil
+	}
+
+	if err := w.flushCopy(); err != nil {
+		return err
+	}
+
+	w.copy = Command{Kind: CopyCommand, Offset: offset, Length: length}
+	return nil
+}
+
+// Close writes pending commands and end of delta, it does not close underlying writer
+func (w *DeltaWriter) Close() error {
+	if err := w.flushLiteral(); err != nil {
+		return err
+	}
+
+	if err := w.flushCopy(); err != nil {
+		return err
+	}
+
+	if err := w.out.WriteByte(opEnd); err != nil {
+		return err
+	}
+
+	return w.out.Flush()
+}
+
+func (w *DeltaWriter) flushLiteral() error {
+	if len(w.literal) == 0 {
+		return nil
+	}
+
+	length := uint64(len(w.literal))
+	if length <= opLiteral64-opLiteral1+1 {
+		if err := w.out.WriteByte(byte(opLiteral1 + length - 1)); err != nil {
+			return err
+		}
+	} else {
+		size := intLen(length)
+		if err := w.out.WriteByte(byte(opLiteralN1 + sizeIndex(size))); err != nil {
+			return err
+		}
+
+		if err := w.writeInt(length, size); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.out.Write(w.literal)
+	w.literal = w.literal[:0]
+	return err
+}
+
+func (w *DeltaWriter) flushCopy() error {
+	if w.copy.Length == 0 {
+		return nil
+	}
+
+	offset, length := uint64(w.copy.Offset), uint64(w.copy.Length)
+	offsetSize, lengthSize := intLen(offset), intLen(length)
+	w.copy = Command{}
+
+	if err := w.out.WriteByte(byte(opCopyN1N1 + 4*sizeIndex(offsetSize) + sizeIndex(lengthSize))); err != nil {
+		return err
+	}
+
+	if err := w.writeInt(offset, offsetSize); err != nil {
+		return err
+	}
+
+	return w.writeInt(length, lengthSize)
+}
+
+func (w *DeltaWriter) writeInt(value uint64, size int) error {
+	data := [8]byte{}
+	binary.BigEndian.PutUint64(data[:], value)
+	_, err := w.out.Write(data[8-size:])
+	return err
+}
+
+// DeltaReader decodes commands of librsync delta
+type DeltaReader struct {
+	in *bufio.Reader
+}
+
+func NewDeltaReader(r io.Reader) (*DeltaReader, error) {
+	reader := &DeltaReader{in: bufio.NewReader(r)}
+
+	magic, err := reader.readInt(4)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read delta header. %w", err)
+	}
+
+	if Magic(magic) != DeltaMagic {
+		return nil, fmt.Errorf("not a librsync delta, unknown magic %#x", magic)
+	}
+
+	return reader, nil
+}
+
+// Next returns next command, io.EOF is returned after end command
+func (r *DeltaReader) Next() (Command, error) {
+	op, err := r.in.ReadByte()
+	if err == io.EOF {
+		return Command{}, fmt.Errorf("delta is truncated, missing end command")
+	}
+
+	if err != nil {
+		return Command{}, err
+	}
+
+	switch {
+	case op == opEnd:
+		return Command{}, io.EOF
+	case op >= opLiteral1 && op <= opLiteral64:
+		return r.literal(int64(op - opLiteral1 + 1))
+	case op >= opLiteralN1 && op <= opLiteralN8:
+		length, err := r.readInt(1 << (op - opLiteralN1))
+		if err != nil {
+			return Command{}, err
+		}
+		return r.literal(int64(length))
+	case op >= opCopyN1N1 && op <= opCopyN8N8:
+		index := op - opCopyN1N1
+		offset, err := r.readInt(1 << (index / 4))
+		if err != nil {
+			return Command{}, err
+		}
+
+		length, err := r.readInt(1 << (index % 4))
+		if err != nil {
+			return Command{}, err
+		}
+
+		return Command{Kind: CopyCommand, Offset: int64(offset), Length: int64(length)}, nil
+	}
+
+	return Command{}, fmt.Errorf("unknown delta opcode %#x", op)
+}
+
+func (r *DeltaReader) literal(length int64) (Command, error) {
+	// length comes from delta file, so buffer grows only with data which was really read
+	data, err := io.ReadAll(io.LimitReader(r.in, length))
+	if err != nil {
+		return Command{}, fmt.Errorf("unable to read literal data. %w", err)
+	}
+
+	if int64(len(data)) != length {
+		return Command{}, fmt.Errorf("unable to read literal data. %w", io.ErrUnexpectedEOF)
+	}
+
+	return Command{Kind: LiteralCommand, Length: length, Data: data}, nil
+}
+
+func (r *DeltaReader) readInt(size int) (uint64, error) {
+	data := [8]byte{}
+	if _, err := io.ReadFull(r.in, data[8-size:]); err != nil {
+		return 0, err
+	}
+
+	return binary.BigEndian.Uint64(data[:]), nil
+}
+
+// intLen returns number of bytes needed to store value, as chosen by librsync
+func intLen(value uint64) int {
+	switch {
+	case value <= 0xff:
+		return 1
+	case value <= 0xffff:
+		return 2
+	case value <= 0xffffffff:
+		return 4
+	}
+
+	return 8
+}
+
+// sizeIndex maps parameter size 1, 2, 4, 8 to opcode offset 0, 1, 2, 3
+func sizeIndex(size int) int {
+	switch size {
+	case 1:
+		return 0
+	case 2:
+		return 1
+	case 4:
+		return 2
+	}
+
+	return 3
+}
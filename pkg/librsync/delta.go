@@ -0,0 +1,126 @@
+package librsync
+
+import (
+	"bytes"
+	"hash"
+	"io"
+)
+
+// Delta calculates delta of file against signature of its previous version,
+// result is written in librsync format, so it can be applied with rdiff patch
+func Delta(signature Signature, file io.Reader, out io.Writer) error {
+	writer, err := NewDeltaWriter(out)
+	if err != nil {
+		return err
+	}
+
+	m := newMatcher(signature)
+	blockLen := int(signature.BlockLen)
+
+	// buffer keeps window and data read ahead of it
+	buffer := make([]byte, 0, 4*blockLen)
+	pos := 0
+	eof := false
+	rolling := false
+
+	for {
+		// keep at least one full window in buffer
+		if !eof && len(buffer)-pos < blockLen {
+			buffer = append(buffer[:0], buffer[pos:]...)
+			pos = 0
+
+			n, err := io.ReadFull(file, buffer[len(buffer):cap(buffer)])
+			buffer = buffer[:len(buffer)+n]
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+		}
+
+		left := len(buffer) - pos
+		if left == 0 {
+			break
+		}
+
+		window := buffer[pos:]
+		if left >= blockLen {
+			window = buffer[pos : pos+blockLen]
+			if !rolling {
+				m.weak.Reset()
+				m.weak.Update(window)
+				rolling = true
+			}
+		} else {
+			// only last block of basis can be shorter than block length
+			rolling = false
+			m.weak.Reset()
+			m.weak.Update(window)
+		}
+
+		if block, ok := m.find(window); ok {
+			if err := writer.Copy(int64(block)*int64(blockLen), int64(len(window))); err != nil {
+				return err
+			}
+
+			pos += len(window)
+			rolling = false
+			continue
+		}
+
+		if err := writer.Literal(buffer[pos : pos+1]); err != nil {
+			return err
+		}
+
+		if rolling && pos+blockLen < len(buffer) {
+			m.weak.Rotate(buffer[pos], buffer[pos+blockLen])
+		} else {
+			rolling = false
+		}
+		pos++
+	}
+
+	return writer.Close()
+}
+
+// matcher finds blocks of signature which have the same checksums as window of data
+type matcher struct {
+	signature Signature
+	blocks    map[uint32][]int
+	weak      weakSum
+	strong    hash.Hash
+}
+
+func newMatcher(signature Signature) *matcher {
+	m := &matcher{
+		signature: signature,
+		blocks:    map[uint32][]int{},
+		weak:      newWeakSum(signature.Magic),
+		strong:    newStrongHash(signature.Magic),
+	}
+
+	for i, block := range signature.Blocks {
+		m.blocks[block.Weak] = append(m.blocks[block.Weak], i)
+	}
+
+	return m
+}
+
+func (m *matcher) find(window []byte) (int, bool) {
+	candidates, ok := m.blocks[m.weak.Digest()]
+	if !ok {
+		return 0, false
+	}
+
+	m.strong.Reset()
+	m.strong.Write(window)
+	strong := m.strong.Sum(nil)[:m.signature.StrongLen]
+
+	for _, i := range candidates {
+		if bytes.Equal(strong, m.signature.Blocks[i].Strong) {
+			return i, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,233 @@
+package librsync
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RollingChecksumsMatchChecksumOfWindow(t *testing.T) {
+	data := randomData(1000, 1)
+	window := 64
+
+	for _, magic := range []Magic{MD4SigMagic, RabinKarpBlake2SigMagic} {
+		rolling := newWeakSum(magic)
+		rolling.Update(data[:window])
+
+		for i := 0; i+window < len(data); i++ {
+			rolling.Rotate(data[i], data[i+window])
+
+			direct := newWeakSum(magic)
+			direct.Update(data[i+1 : i+1+window])
+			require.Equal(t, direct.Digest(), rolling.Digest(), "magic %#x, offset %d", uint32(magic), i+1)
+		}
+	}
+}
+
+func Test_WeakChecksumsOfKnownData(t *testing.T) {
+	sum := newWeakSum(MD4SigMagic)
+	sum.Update([]byte("abc"))
+	// s1 = (97+31) + (98+31) + (99+31), s2 = 128 + 257 + 387
+	require.Equal(t, uint32(772<<16|387), sum.Digest())
+
+	sum = newWeakSum(RabinKarpMD4SigMagic)
+	sum.Update([]byte("ab"))
+	// seed * mult^2 + 'a' * mult + 'b'
+	require.Equal(t, uint32(0xb3e029c0), sum.Digest())
+}
+
+func Test_DeltaAndPatchRecreateNewFile(t *testing.T) {
+	oldData := randomData(50000, 2)
+	newData := append(append(append([]byte{}, oldData[1000:30000]...), []byte("inserted")...), oldData[30010:]...)
+
+	for _, magic := range []Magic{MD4SigMagic, Blake2SigMagic, RabinKarpMD4SigMagic, RabinKarpBlake2SigMagic} {
+		signature, err := NewSignature(bytes.NewReader(oldData), magic, 512, 8)
+		require.Nil(t, err)
+
+		encoded := &bytes.Buffer{}
+		_, err = signature.WriteTo(encoded)
+		require.Nil(t, err)
+
+		decoded, err := ReadSignature(encoded)
+		require.Nil(t, err)
+		require.Equal(t, signature, decoded)
+
+		delta := &bytes.Buffer{}
+		require.Nil(t, Delta(decoded, bytes.NewReader(newData), delta))
+		require.Less(t, delta.Len(), 2000)
+
+		out := &bytes.Buffer{}
+		require.Nil(t, Patch(bytes.NewReader(oldData), delta, out))
+		require.Equal(t, newData, out.Bytes())
+	}
+}
+
+func Test_DeltaEncodingUsesShortestOpcodes(t *testing.T) {
+	out := &bytes.Buffer{}
+	writer, err := NewDeltaWriter(out)
+	require.Nil(t, err)
+
+	require.Nil(t, writer.Literal([]byte("ab")))
+	require.Nil(t, writer.Copy(0, 16))
+	require.Nil(t, writer.Copy(16, 16))
+	require.Nil(t, writer.Literal(bytes.Repeat([]byte{'x'}, 300)))
+	require.Nil(t, writer.Copy(70000, 5))
+	require.Nil(t, writer.Close())
+
+	expected := []byte{0x72, 0x73, 0x02, 0x36}
+	expected = append(expected, 0x02, 'a', 'b')
+	// merged copy of 32 bytes from offset 0
+	expected = append(expected, 0x45, 0x00, 0x20)
+	expected = append(expected, 0x42, 0x01, 0x2c)
+	expected = append(expected, bytes.Repeat([]byte{'x'}, 300)...)
+	// 4 bytes offset, 1 byte length
+	expected = append(expected, 0x4d, 0x00, 0x01, 0x11, 0x70, 0x05)
+	expected = append(expected, 0x00)
+	require.Equal(t, expected, out.Bytes())
+
+	reader, err := NewDeltaReader(bytes.NewReader(expected))
+	require.Nil(t, err)
+
+	commands := []Command{}
+	for {
+		command, err := reader.Next()
+		if err != nil {
+			break
+		}
+		commands = append(commands, command)
+	}
+
+	require.Equal(t, []Command{
+		{Kind: LiteralCommand, Length: 2, Data: []byte("ab")},
+		{Kind: CopyCommand, Offset: 0, Length: 32},
+		{Kind: LiteralCommand, Length: 300, Data: bytes.Repeat([]byte{'x'}, 300)},
+		{Kind: CopyCommand, Offset: 70000, Length: 5},
+	}, commands)
+}
+
+func Test_InvalidInputIsRejected(t *testing.T) {
+	_, err := ReadSignature(bytes.NewReader([]byte{0x72, 0x73, 0x02, 0x36, 0, 0, 0, 1, 0, 0, 0, 1}))
+	require.ErrorContains(t, err, "not a librsync signature")
+
+	_, err = NewDeltaReader(bytes.NewReader([]byte{0x72, 0x73, 0x01, 0x36}))
+	require.ErrorContains(t, err, "not a librsync delta")
+
+	err = Patch(bytes.NewReader(nil), bytes.NewReader([]byte{0x72, 0x73, 0x02, 0x36, 0x45, 0x00, 0x10, 0x00}), &bytes.Buffer{})
+	require.ErrorContains(t, err, "outside of basis file")
+
+	err = Patch(bytes.NewReader(nil), bytes.NewReader([]byte{0x72, 0x73, 0x02, 0x36, 0x03, 'a'}), &bytes.Buffer{})
+	require.ErrorContains(t, err, "unable to read literal data")
+}
+
+var variants = map[string]Magic{
+	"md4":       MD4SigMagic,
+	"blake2":    Blake2SigMagic,
+	"rk-md4":    RabinKarpMD4SigMagic,
+	"rk-blake2": RabinKarpBlake2SigMagic,
+}
+
+// golden files are signatures of old.txt in every variant and deltas of new.txt against them,
+// they are written by rdiff with testdata/generate.sh, or by testdata/reference.py when rdiff is not available
+func Test_GoldenFiles(t *testing.T) {
+	oldData := readGolden(t, "old.txt")
+	newData := readGolden(t, "new.txt")
+
+	for name, magic := range variants {
+		signature, err := NewSignature(bytes.NewReader(oldData), magic, 64, 0)
+		require.Nil(t, err)
+
+		encoded := &bytes.Buffer{}
+		_, err = signature.WriteTo(encoded)
+		require.Nil(t, err)
+		require.Equal(t, readGolden(t, name+".sig"), encoded.Bytes(), "golden file %s.sig", name)
+
+		decoded, err := ReadSignature(bytes.NewReader(readGolden(t, name+".sig")))
+		require.Nil(t, err)
+		require.Equal(t, signature, decoded)
+
+		out := &bytes.Buffer{}
+		require.Nil(t, Patch(bytes.NewReader(oldData), bytes.NewReader(readGolden(t, name+".delta")), out))
+		require.Equal(t, newData, out.Bytes(), "golden file %s.delta", name)
+	}
+}
+
+// Test_RdiffReadsAndWritesTheSameFormat runs rdiff when it is installed
+func Test_RdiffReadsAndWritesTheSameFormat(t *testing.T) {
+	rdiff, err := exec.LookPath("rdiff")
+	if err != nil {
+		t.Skip("rdiff is not installed")
+	}
+
+	dir := t.TempDir()
+	oldData := randomData(50000, 3)
+	newData := append(append(append([]byte{}, oldData[:20000]...), randomData(700, 4)...), oldData[20100:]...)
+	oldPath, newPath := filepath.Join(dir, "old"), filepath.Join(dir, "new")
+	require.Nil(t, os.WriteFile(oldPath, oldData, 0644))
+	require.Nil(t, os.WriteFile(newPath, newData, 0644))
+
+	run := func(args ...string) {
+		output, err := exec.Command(rdiff, args...).CombinedOutput()
+		require.Nil(t, err, "rdiff %v: %s", args, output)
+	}
+
+	flags := map[string][]string{
+		"md4":       {"--hash=md4", "--rollsum=rollsum"},
+		"blake2":    {"--hash=blake2", "--rollsum=rollsum"},
+		"rk-md4":    {"--hash=md4", "--rollsum=rabinkarp"},
+		"rk-blake2": {"--hash=blake2", "--rollsum=rabinkarp"},
+	}
+
+	for name, magic := range variants {
+		sigPath := filepath.Join(dir, name+".sig")
+		run(append(append([]string{"signature", "--block-size=512"}, flags[name]...), oldPath, sigPath)...)
+
+		signature, err := NewSignature(bytes.NewReader(oldData), magic, 512, 0)
+		require.Nil(t, err)
+		encoded := &bytes.Buffer{}
+		_, err = signature.WriteTo(encoded)
+		require.Nil(t, err)
+
+		rdiffSignature, err := os.ReadFile(sigPath)
+		require.Nil(t, err)
+		require.Equal(t, rdiffSignature, encoded.Bytes(), "signature %s", name)
+
+		// delta of rdiff applied by Patch
+		rdiffDeltaPath := filepath.Join(dir, name+".rdiff-delta")
+		run("delta", sigPath, newPath, rdiffDeltaPath)
+		rdiffDelta, err := os.ReadFile(rdiffDeltaPath)
+		require.Nil(t, err)
+
+		out := &bytes.Buffer{}
+		require.Nil(t, Patch(bytes.NewReader(oldData), bytes.NewReader(rdiffDelta), out))
+		require.Equal(t, newData, out.Bytes(), "rdiff delta %s", name)
+
+		// delta of Delta applied by rdiff
+		delta := &bytes.Buffer{}
+		require.Nil(t, Delta(signature, bytes.NewReader(newData), delta))
+		deltaPath, outPath := filepath.Join(dir, name+".delta"), filepath.Join(dir, name+".out")
+		require.Nil(t, os.WriteFile(deltaPath, delta.Bytes(), 0644))
+		run("patch", oldPath, deltaPath, outPath)
+
+		patched, err := os.ReadFile(outPath)
+		require.Nil(t, err)
+		require.Equal(t, newData, patched, "delta %s", name)
+	}
+}
+
+func readGolden(t *testing.T, name string) []byte {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.Nil(t, err, "golden file %s is missing, run testdata/generate.sh with rdiff", name)
+	return data
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
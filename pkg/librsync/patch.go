@@ -0,0 +1,41 @@
+package librsync
+
+import (
+	"fmt"
+	"io"
+)
+
+// Patch applies librsync delta (for example created with rdiff delta) to basis file
+func Patch(basis io.ReaderAt, delta io.Reader, out io.Writer) error {
+	reader, err := NewDeltaReader(delta)
+	if err != nil {
+		return err
+	}
+
+	for {
+		command, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if command.Kind == LiteralCommand {
+			if _, err := out.Write(command.Data); err != nil {
+				return err
+			}
+			continue
+		}
+
+		n, err := io.Copy(out, io.NewSectionReader(basis, command.Offset, command.Length))
+		if err != nil {
+			return err
+		}
+
+		if n != command.Length {
+			return fmt.Errorf("copy of %d bytes at offset %d is outside of basis file", command.Length, command.Offset)
+		}
+	}
+}
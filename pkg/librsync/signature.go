@@ -0,0 +1,156 @@
+package librsync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Magic is first 4 bytes of librsync signature and delta files
+type Magic uint32
+
+const (
+	DeltaMagic              Magic = 0x72730236
+	MD4SigMagic             Magic = 0x72730136
+	Blake2SigMagic          Magic = 0x72730137
+	RabinKarpMD4SigMagic    Magic = 0x72730146
+	RabinKarpBlake2SigMagic Magic = 0x72730147
+)
+
+// DefaultBlockLen is block length used by rdiff for small files
+const DefaultBlockLen = 2048
+
+// Block is checksum of single block of basis file
+type Block struct {
+	Weak   uint32
+	Strong []byte
+}
+
+// Signature in librsync format, magic decides which weak and strong checksums are used
+type Signature struct {
+	Magic     Magic
+	BlockLen  uint32
+	StrongLen uint32
+	Blocks    []Block
+}
+
+func validSignatureMagic(magic Magic) bool {
+	switch magic {
+	case MD4SigMagic, Blake2SigMagic, RabinKarpMD4SigMagic, RabinKarpBlake2SigMagic:
+		return true
+	}
+
+	return false
+}
+
+// NewSignature calculates signature of file, strongLen 0 means full length of strong checksum
+func NewSignature(file io.Reader, magic Magic, blockLen uint32, strongLen uint32) (Signature, error) {
+	if !validSignatureMagic(magic) {
+		return Signature{}, fmt.Errorf("unknown signature magic %#x", uint32(magic))
+	}
+
+	if blockLen == 0 {
+		return Signature{}, fmt.Errorf("block length has to be positive")
+	}
+
+	maxStrongLen := strongHashSize(magic)
+	if strongLen == 0 {
+		strongLen = maxStrongLen
+	}
+
+	if strongLen > maxStrongLen {
+		return Signature{}, fmt.Errorf("strong checksum length %d is bigger than %d", strongLen, maxStrongLen)
+	}
+
+	signature := Signature{Magic: magic, BlockLen: blockLen, StrongLen: strongLen, Blocks: []Block{}}
+	weak := newWeakSum(magic)
+	strong := newStrongHash(magic)
+	buffer := make([]byte, blockLen)
+
+	for {
+		n, err := io.ReadFull(file, buffer)
+		if n > 0 {
+			weak.Reset()
+			weak.Update(buffer[:n])
+			strong.Reset()
+			strong.Write(buffer[:n])
+
+			signature.Blocks = append(signature.Blocks, Block{
+				Weak:   weak.Digest(),
+				Strong: strong.Sum(nil)[:strongLen],
+			})
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return signature, nil
+		}
+
+		if err != nil {
+			return signature, err
+		}
+	}
+}
+
+// WriteTo encodes signature in librsync format
+func (s Signature) WriteTo(w io.Writer) (int64, error) {
+	out := bufio.NewWriter(w)
+	header := [12]byte{}
+	binary.BigEndian.PutUint32(header[0:], uint32(s.Magic))
+	binary.BigEndian.PutUint32(header[4:], s.BlockLen)
+	binary.BigEndian.PutUint32(header[8:], s.StrongLen)
+	out.Write(header[:])
+
+	weak := [4]byte{}
+	for _, block := range s.Blocks {
+		binary.BigEndian.PutUint32(weak[:], block.Weak)
+		out.Write(weak[:])
+		out.Write(block.Strong)
+	}
+
+	written := int64(len(header)) + int64(len(s.Blocks))*int64(4+s.StrongLen)
+	return written, out.Flush()
+}
+
+// ReadSignature decodes signature written by librsync (for example with rdiff signature)
+func ReadSignature(r io.Reader) (Signature, error) {
+	in := bufio.NewReader(r)
+	header := [12]byte{}
+	if _, err := io.ReadFull(in, header[:]); err != nil {
+		return Signature{}, fmt.Errorf("unable to read signature header. %w", err)
+	}
+
+	signature := Signature{
+		Magic:     Magic(binary.BigEndian.Uint32(header[0:])),
+		BlockLen:  binary.BigEndian.Uint32(header[4:]),
+		StrongLen: binary.BigEndian.Uint32(header[8:]),
+		Blocks:    []Block{},
+	}
+
+	if !validSignatureMagic(signature.Magic) {
+		return signature, fmt.Errorf("not a librsync signature, unknown magic %#x", uint32(signature.Magic))
+	}
+
+	if signature.BlockLen == 0 || signature.StrongLen == 0 || signature.StrongLen > strongHashSize(signature.Magic) {
+		return signature, fmt.Errorf("invalid signature header, block length %d, strong checksum length %d", signature.BlockLen, signature.StrongLen)
+	}
+
+	weak := [4]byte{}
+	for {
+		_, err := io.ReadFull(in, weak[:])
+		if err == io.EOF {
+			return signature, nil
+		}
+
+		if err != nil {
+			return signature, fmt.Errorf("unable to read block %d. %w", len(signature.Blocks), err)
+		}
+
+		block := Block{Weak: binary.BigEndian.Uint32(weak[:]), Strong: make([]byte, signature.StrongLen)}
+		if _, err := io.ReadFull(in, block.Strong); err != nil {
+			return signature, fmt.Errorf("unable to read block %d. %w", len(signature.Blocks), err)
+		}
+
+		signature.Blocks = append(signature.Blocks, block)
+	}
+}
@@ -0,0 +1,20 @@
+#!/bin/sh
+# Writes golden files used by Test_GoldenFiles with rdiff from librsync 2.x:
+# signature of old.txt in every variant and delta of new.txt against it.
+# Golden files have to come from rdiff or reference.py, never from this package.
+# Committed files were written by reference.py, output of this script replaces them.
+set -e
+cd "$(dirname "$0")"
+
+rdiff --version | head -n 1
+
+# full strong sum is used, rdiff shortens it for small files by default
+gen() {
+	rdiff signature --block-size=64 --sum-size="$4" --hash="$2" --rollsum="$3" old.txt "$1.sig"
+	rdiff delta "$1.sig" new.txt "$1.delta"
+}
+
+gen md4 md4 rollsum 16
+gen blake2 blake2 rollsum 32
+gen rk-md4 md4 rabinkarp 16
+gen rk-blake2 blake2 rabinkarp 32
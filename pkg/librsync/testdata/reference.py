@@ -0,0 +1,173 @@
+#!/usr/bin/env python3
+"""Writes golden files used by Test_GoldenFiles when rdiff is not available.
+
+This is a separate implementation of librsync signature and delta formats,
+written from librsync documentation and independent of the Go package,
+so golden files still check this package against another implementation.
+Files written by generate.sh with rdiff replace them.
+"""
+import hashlib
+import os
+import struct
+
+BLOCK_LEN = 64
+
+DELTA_MAGIC = 0x72730236
+VARIANTS = {
+    "md4": (0x72730136, "md4", "rollsum"),
+    "blake2": (0x72730137, "blake2", "rollsum"),
+    "rk-md4": (0x72730146, "md4", "rabinkarp"),
+    "rk-blake2": (0x72730147, "blake2", "rabinkarp"),
+}
+
+
+def md4(data):
+    """MD4 from RFC 1320, hashlib usually does not provide it."""
+
+    def rotl(x, n):
+        x &= 0xFFFFFFFF
+        return ((x << n) | (x >> (32 - n))) & 0xFFFFFFFF
+
+    message = data + b"\x80" + b"\x00" * ((55 - len(data)) % 64) + struct.pack("<Q", len(data) * 8)
+    a, b, c, d = 0x67452301, 0xEFCDAB89, 0x98BADCFE, 0x10325476
+
+    for offset in range(0, len(message), 64):
+        x = struct.unpack("<16I", message[offset:offset + 64])
+        aa, bb, cc, dd = a, b, c, d
+
+        f = lambda x_, y, z: (x_ & y) | (~x_ & z)
+        for i, s in zip(range(16), [3, 7, 11, 19] * 4):
+            a, b, c, d = d, rotl(a + f(b, c, d) + x[i], s), b, c
+
+        g = lambda x_, y, z: (x_ & y) | (x_ & z) | (y & z)
+        for i, s in zip([0, 4, 8, 12, 1, 5, 9, 13, 2, 6, 10, 14, 3, 7, 11, 15], [3, 5, 9, 13] * 4):
+            a, b, c, d = d, rotl(a + g(b, c, d) + x[i] + 0x5A827999, s), b, c
+
+        h = lambda x_, y, z: x_ ^ y ^ z
+        for i, s in zip([0, 8, 4, 12, 2, 10, 6, 14, 1, 9, 5, 13, 3, 11, 7, 15], [3, 9, 11, 15] * 4):
+            a, b, c, d = d, rotl(a + h(b, c, d) + x[i] + 0x6ED9EBA1, s), b, c
+
+        a, b, c, d = [(v + w) & 0xFFFFFFFF for v, w in zip((a, b, c, d), (aa, bb, cc, dd))]
+
+    return struct.pack("<4I", a, b, c, d)
+
+
+def strong_sum(kind, data):
+    if kind == "md4":
+        return md4(data)
+    return hashlib.blake2b(data, digest_size=32).digest()
+
+
+def weak_sum(kind, data):
+    if kind == "rollsum":
+        # every byte is offset by 31, digest is s2 in high and s1 in low 16 bits
+        s1 = s2 = 0
+        for byte in data:
+            s1 = (s1 + byte + 31) & 0xFFFF
+            s2 = (s2 + s1) & 0xFFFF
+        return (s2 << 16) | s1
+
+    # Rabin-Karp polynomial hash with seed 1
+    value = 1
+    for byte in data:
+        value = (value * 0x08104225 + byte) & 0xFFFFFFFF
+    return value
+
+
+def blocks(data):
+    return [data[i:i + BLOCK_LEN] for i in range(0, len(data), BLOCK_LEN)]
+
+
+def signature(data, magic, strong, weak):
+    strong_len = len(strong_sum(strong, b""))
+    out = struct.pack(">III", magic, BLOCK_LEN, strong_len)
+    for block in blocks(data):
+        out += struct.pack(">I", weak_sum(weak, block)) + strong_sum(strong, block)
+    return out
+
+
+def int_size(value):
+    for size in (1, 2, 4, 8):
+        if value < 1 << (8 * size):
+            return size
+
+
+def int_bytes(value, size):
+    return value.to_bytes(size, "big")
+
+
+def literal_command(data):
+    if len(data) <= 64:
+        return bytes([len(data)]) + data
+
+    size = int_size(len(data))
+    return bytes([0x41 + (1, 2, 4, 8).index(size)]) + int_bytes(len(data), size) + data
+
+
+def copy_command(offset, length):
+    offset_size, length_size = int_size(offset), int_size(length)
+    opcode = 0x45 + 4 * (1, 2, 4, 8).index(offset_size) + (1, 2, 4, 8).index(length_size)
+    return bytes([opcode]) + int_bytes(offset, offset_size) + int_bytes(length, length_size)
+
+
+def delta(old, new, strong, weak):
+    """Greedy delta: full blocks of old found in new are copied, other bytes are literals."""
+    index = {}
+    for i, block in enumerate(blocks(old)):
+        if len(block) == BLOCK_LEN:
+            index.setdefault((weak_sum(weak, block), strong_sum(strong, block)), i * BLOCK_LEN)
+
+    commands, literal, copy = [], b"", None
+    position = 0
+    while position < len(new):
+        window = new[position:position + BLOCK_LEN]
+        offset = None
+        if len(window) == BLOCK_LEN:
+            offset = index.get((weak_sum(weak, window), strong_sum(strong, window)))
+
+        if offset is None:
+            if copy:
+                commands.append(copy_command(*copy))
+                copy = None
+            literal += new[position:position + 1]
+            position += 1
+            continue
+
+        if literal:
+            commands.append(literal_command(literal))
+            literal = b""
+
+        if copy and copy[0] + copy[1] == offset:
+            copy = (copy[0], copy[1] + BLOCK_LEN)
+        else:
+            if copy:
+                commands.append(copy_command(*copy))
+            copy = (offset, BLOCK_LEN)
+        position += BLOCK_LEN
+
+    if copy:
+        commands.append(copy_command(*copy))
+    if literal:
+        commands.append(literal_command(literal))
+
+    return struct.pack(">I", DELTA_MAGIC) + b"".join(commands) + b"\x00"
+
+
+def main():
+    os.chdir(os.path.dirname(os.path.abspath(__file__)))
+    assert md4(b"abc").hex() == "a448017aaf21d8525fc10ae87aa6729d"
+
+    with open("old.txt", "rb") as f:
+        old = f.read()
+    with open("new.txt", "rb") as f:
+        new = f.read()
+
+    for name, (magic, strong, weak) in VARIANTS.items():
+        with open(name + ".sig", "wb") as f:
+            f.write(signature(old, magic, strong, weak))
+        with open(name + ".delta", "wb") as f:
+            f.write(delta(old, new, strong, weak))
+
+
+if __name__ == "__main__":
+    main()
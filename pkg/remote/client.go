@@ -0,0 +1,45 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Push sends localPath to server, where it is saved as remotePath
+func Push(conn io.ReadWriter, localPath string, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("unable to open '%s'. %w", localPath, err)
+	}
+	defer file.Close()
+
+	return PushData(conn, file, remotePath)
+}
+
+// PushData sends data to server, where it is saved as remotePath
+func PushData(conn io.ReadWriter, data io.Reader, remotePath string) error {
+	if err := start(conn, Request{Operation: PushOperation, Path: remotePath}); err != nil {
+		return err
+	}
+
+	return send(conn, data)
+}
+
+// Pull updates localPath with content of remotePath from server
+func Pull(conn io.ReadWriter, remotePath string, localPath string) error {
+	if err := start(conn, Request{Operation: PullOperation, Path: remotePath}); err != nil {
+		return err
+	}
+
+	return receive(conn, localPath)
+}
+
+func start(conn io.ReadWriter, request Request) error {
+	if err := writeFrame(conn, frameRequest, request); err != nil {
+		return err
+	}
+
+	_, err := expect(conn, frameAccept)
+	return err
+}
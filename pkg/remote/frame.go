@@ -0,0 +1,125 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+)
+
+// every frame starts with 1 byte of type and 4 bytes of payload length,
+// payload is gob encoded value specific for frame type
+type frameType byte
+
+const (
+	// client -> server, payload is Request
+	frameRequest frameType = iota + 1
+	// server -> client, request was accepted, no payload
+	frameAccept
+	// receiver -> sender, payload is batch of sync.Chunk
+	frameSignature
+	frameSignatureEnd
+	// sender -> receiver, payload is batch of sync.Delta
+	frameDeltas
+	frameDeltasEnd
+	// receiver -> sender, file was patched, no payload
+	frameDone
+	// any side, payload is error message
+	frameError
+)
+
+const frameHeaderSize = 5
+
+// maxFrameSize protects from allocating huge buffers for corrupted frames
+const maxFrameSize = 64 * 1024 * 1024
+
+// how many chunks or deltas are sent in single frame
+const batchSize = 1024
+
+type frame struct {
+	Type    frameType
+	Payload []byte
+}
+
+func writeFrame(w io.Writer, t frameType, value interface{}) error {
+	payload := []byte{}
+	if value != nil {
+		var buffer bytes.Buffer
+		if err := gob.NewEncoder(&buffer).Encode(value); err != nil {
+			return fmt.Errorf("unable to encode frame. %w", err)
+		}
+		payload = buffer.Bytes()
+	}
+
+	header := make([]byte, frameHeaderSize)
+	header[0] = byte(t)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+
+	if _, err := w.Write(append(header, payload...)); err != nil {
+		return fmt.Errorf("unable to write frame. %w", err)
+	}
+
+	return nil
+}
+
+func readFrame(r io.Reader) (frame, error) {
+	header := make([]byte, frameHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return frame{}, fmt.Errorf("unable to read frame. %w", err)
+	}
+
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxFrameSize {
+		return frame{}, fmt.Errorf("frame of size %d exceeds limit", size)
+	}
+
+	f := frame{
+		Type:    frameType(header[0]),
+		Payload: make([]byte, size),
+	}
+
+	if _, err := io.ReadFull(r, f.Payload); err != nil {
+		return frame{}, fmt.Errorf("unable to read frame payload. %w", err)
+	}
+
+	if f.Type == frameError {
+		message := ""
+		if err := f.decode(&message); err != nil {
+			return f, err
+		}
+		return f, fmt.Errorf("remote error: %s", message)
+	}
+
+	return f, nil
+}
+
+func (f frame) decode(value interface{}) error {
+	if err := gob.NewDecoder(bytes.NewReader(f.Payload)).Decode(value); err != nil {
+		return fmt.Errorf("unable to decode frame. %w", err)
+	}
+
+	return nil
+}
+
+// expect reads next frame and checks if it has one of expected types
+func expect(r io.Reader, types ...frameType) (frame, error) {
+	f, err := readFrame(r)
+	if err != nil {
+		return f, err
+	}
+
+	for _, t := range types {
+		if f.Type == t {
+			return f, nil
+		}
+	}
+
+	return f, fmt.Errorf("unexpected frame type %d", f.Type)
+}
+
+// sendError informs other side about failure, err is returned so it can be also handled locally
+func sendError(w io.Writer, err error) error {
+	writeFrame(w, frameError, err.Error())
+	return err
+}
@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type stdio struct{}
+
+// Stdio is connection which reads frames from stdin and writes them to stdout,
+// so any byte pipe (ssh, kubectl exec, docker exec) can be used as transport
+func Stdio() io.ReadWriter {
+	return stdio{}
+}
+
+func (stdio) Read(data []byte) (int, error) {
+	return os.Stdin.Read(data)
+}
+
+func (stdio) Write(data []byte) (int, error) {
+	return os.Stdout.Write(data)
+}
+
+// Process is connection to spawned command, which talks protocol on its stdin and stdout
+type Process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout io.ReadCloser
+}
+
+// Spawn starts command, arguments are separated by whitespaces (like rsync -e option).
+// Stderr of command is passed through to stderr of current process.
+func Spawn(command string) (*Process, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("command is empty")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("unable to start '%s'. %w", command, err)
+	}
+
+	return &Process{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: stdout,
+	}, nil
+}
+
+func (p *Process) Read(data []byte) (int, error) {
+	return p.stdout.Read(data)
+}
+
+func (p *Process) Write(data []byte) (int, error) {
+	return p.stdin.Write(data)
+}
+
+// Close closes stdin of command and waits until it exits
+func (p *Process) Close() error {
+	p.stdin.Close()
+	if err := p.cmd.Wait(); err != nil {
+		return fmt.Errorf("remote command failed. %w", err)
+	}
+
+	return nil
+}
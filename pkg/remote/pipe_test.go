@@ -0,0 +1,61 @@
+package remote
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// when set, test binary works as server on stdin and stdout
+const serverRootEnv = "REMOTE_TEST_SERVER_ROOT"
+
+func TestMain(m *testing.M) {
+	if root := os.Getenv(serverRootEnv); root != "" {
+		if err := NewServer(root).Handle(Stdio()); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func Test_PushAndPullOverSpawnedProcess(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(serverRootEnv, root)
+
+	local := filepath.Join(t.TempDir(), "file")
+	data := randomData(3000, 4)
+	require.Nil(t, os.WriteFile(local, data, 0644))
+
+	process, err := Spawn(os.Args[0])
+	require.Nil(t, err)
+	require.Nil(t, Push(process, local, "file"))
+	require.Nil(t, process.Close())
+	requireFileContent(t, filepath.Join(root, "file"), data)
+
+	data = append(data, []byte("appended")...)
+	require.Nil(t, os.WriteFile(filepath.Join(root, "file"), data, 0644))
+
+	process, err = Spawn(os.Args[0])
+	require.Nil(t, err)
+	require.Nil(t, Pull(process, "file", local))
+	require.Nil(t, process.Close())
+	requireFileContent(t, local, data)
+}
+
+func Test_SpawnReportsFailedCommand(t *testing.T) {
+	t.Setenv(serverRootEnv, t.TempDir())
+
+	process, err := Spawn(os.Args[0])
+	require.Nil(t, err)
+
+	err = Pull(process, "missing", filepath.Join(t.TempDir(), "file"))
+	require.ErrorContains(t, err, "unable to open 'missing'")
+	require.ErrorContains(t, process.Close(), "remote command failed")
+
+	_, err = Spawn("  ")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,132 @@
+package remote
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PushCreatesAndUpdatesFileOnServer(t *testing.T) {
+	root := t.TempDir()
+	address := startServer(t, root)
+
+	local := filepath.Join(t.TempDir(), "file")
+	data := randomData(5000, 1)
+	require.Nil(t, os.WriteFile(local, data, 0644))
+
+	require.Nil(t, Push(dial(t, address), local, "dir/file"))
+	requireFileContent(t, filepath.Join(root, "dir/file"), data)
+
+	data = append(append(append([]byte{}, data[:1000]...), []byte("changed part")...), data[1200:]...)
+	require.Nil(t, os.WriteFile(local, data, 0644))
+
+	require.Nil(t, Push(dial(t, address), local, "dir/file"))
+	requireFileContent(t, filepath.Join(root, "dir/file"), data)
+}
+
+func Test_PullUpdatesLocalFile(t *testing.T) {
+	root := t.TempDir()
+	address := startServer(t, root)
+
+	data := randomData(5000, 2)
+	require.Nil(t, os.WriteFile(filepath.Join(root, "file"), data, 0600))
+
+	local := filepath.Join(t.TempDir(), "file")
+	require.Nil(t, os.WriteFile(local, data[500:], 0600))
+
+	require.Nil(t, Pull(dial(t, address), "file", local))
+	requireFileContent(t, local, data)
+
+	info, err := os.Stat(local)
+	require.Nil(t, err)
+	require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	newLocal := filepath.Join(t.TempDir(), "new_file")
+	require.Nil(t, Pull(dial(t, address), "file", newLocal))
+	requireFileContent(t, newLocal, data)
+}
+
+func Test_ServerRejectsInvalidRequests(t *testing.T) {
+	root := t.TempDir()
+	address := startServer(t, root)
+
+	local := filepath.Join(t.TempDir(), "file")
+	require.Nil(t, os.WriteFile(local, randomData(10, 3), 0644))
+
+	err := Push(dial(t, address), local, "../outside")
+	require.ErrorContains(t, err, "invalid path")
+
+	err = Pull(dial(t, address), "missing", local)
+	require.ErrorContains(t, err, "unable to open 'missing'")
+	requireFileContent(t, local, randomData(10, 3))
+
+	// symbolic links could point outside of root
+	outside := t.TempDir()
+	require.Nil(t, os.WriteFile(filepath.Join(outside, "secret"), randomData(10, 4), 0644))
+	require.Nil(t, os.Symlink(outside, filepath.Join(root, "dir")))
+	require.Nil(t, os.Symlink(filepath.Join(outside, "secret"), filepath.Join(root, "link")))
+
+	err = Push(dial(t, address), local, "dir/secret")
+	require.ErrorContains(t, err, "goes through symbolic link")
+	requireFileContent(t, filepath.Join(outside, "secret"), randomData(10, 4))
+
+	for _, path := range []string{"dir/secret", "link"} {
+		err = Pull(dial(t, address), path, local)
+		require.ErrorContains(t, err, "goes through symbolic link")
+	}
+	requireFileContent(t, local, randomData(10, 3))
+}
+
+func Test_FramesAreReadInOrder(t *testing.T) {
+	buffer := bytes.Buffer{}
+	require.Nil(t, writeFrame(&buffer, frameRequest, Request{Operation: PullOperation, Path: "a/b"}))
+	require.Nil(t, writeFrame(&buffer, frameDone, nil))
+	require.Nil(t, writeFrame(&buffer, frameError, "failure"))
+
+	f, err := expect(&buffer, frameRequest)
+	require.Nil(t, err)
+	request := Request{}
+	require.Nil(t, f.decode(&request))
+	require.Equal(t, Request{Operation: PullOperation, Path: "a/b"}, request)
+
+	_, err = expect(&buffer, frameAccept)
+	require.ErrorContains(t, err, "unexpected frame type")
+
+	_, err = readFrame(&buffer)
+	require.ErrorContains(t, err, "remote error: failure")
+}
+
+func startServer(t *testing.T, root string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go NewServer(root).Serve(listener)
+	return listener.Addr().String()
+}
+
+func dial(t *testing.T, address string) net.Conn {
+	conn, err := net.Dial("tcp", address)
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func requireFileContent(t *testing.T, path string, expected []byte) {
+	data, err := os.ReadFile(path)
+	require.Nil(t, err)
+	require.Equal(t, expected, data)
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
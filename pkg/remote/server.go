@@ -0,0 +1,122 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type Operation byte
+
+const (
+	// PushOperation sends new version of file from client to server
+	PushOperation Operation = iota
+	// PullOperation sends new version of file from server to client
+	PullOperation
+)
+
+// Request starts session, Path is slash separated path relative to server root
+type Request struct {
+	Operation Operation
+	Path      string
+}
+
+// Server keeps basis files in Root directory
+type Server struct {
+	Root string
+}
+
+func NewServer(root string) *Server {
+	return &Server{Root: root}
+}
+
+// Serve handles connections until listener is closed, every connection in separate goroutine
+func (s *Server) Serve(listener net.Listener) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go func() {
+			defer conn.Close()
+			if err := s.Handle(conn); err != nil {
+				log.Printf("session with %s failed. %v", conn.RemoteAddr(), err)
+			}
+		}()
+	}
+}
+
+// Handle runs single session on conn
+func (s *Server) Handle(conn io.ReadWriter) error {
+	f, err := expect(conn, frameRequest)
+	if err != nil {
+		return err
+	}
+
+	request := Request{}
+	if err := f.decode(&request); err != nil {
+		return sendError(conn, err)
+	}
+
+	path, err := s.resolvePath(request.Path)
+	if err != nil {
+		return sendError(conn, err)
+	}
+
+	switch request.Operation {
+	case PushOperation:
+		if err := writeFrame(conn, frameAccept, nil); err != nil {
+			return err
+		}
+
+		return receive(conn, path)
+	case PullOperation:
+		file, err := os.Open(path)
+		if err != nil {
+			return sendError(conn, fmt.Errorf("unable to open '%s'. %w", request.Path, err))
+		}
+		defer file.Close()
+
+		if err := writeFrame(conn, frameAccept, nil); err != nil {
+			return err
+		}
+
+		return send(conn, file)
+	}
+
+	return sendError(conn, fmt.Errorf("unknown operation %d", request.Operation))
+}
+
+// resolvePath returns path of requested file in Root. Path cannot leave Root, also through symbolic link
+// in one of its parent directories. File itself is opened by server, so it cannot be symbolic link either
+func (s *Server) resolvePath(path string) (string, error) {
+	if !fs.ValidPath(path) || path == "." || filepath.IsAbs(filepath.FromSlash(path)) {
+		return "", fmt.Errorf("invalid path '%s'", path)
+	}
+
+	root := filepath.Clean(s.Root)
+	resolved := filepath.Join(root, filepath.FromSlash(path))
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path '%s', it is outside of served directory", path)
+	}
+
+	for entry := resolved; entry != root; entry = filepath.Dir(entry) {
+		info, err := os.Lstat(entry)
+		if err != nil {
+			continue
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("invalid path '%s', it goes through symbolic link", path)
+		}
+	}
+
+	return resolved, nil
+}
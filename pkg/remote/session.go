@@ -0,0 +1,185 @@
+package remote
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// send is side of session which has new version of file:
+// it reads signature from receiver and streams back deltas calculated for data
+func send(conn io.ReadWriter, data io.Reader) error {
+	chunks := []sync.Chunk{}
+	for {
+		f, err := expect(conn, frameSignature, frameSignatureEnd)
+		if err != nil {
+			return err
+		}
+
+		if f.Type == frameSignatureEnd {
+			break
+		}
+
+		batch := []sync.Chunk{}
+		if err := f.decode(&batch); err != nil {
+			return err
+		}
+		chunks = append(chunks, batch...)
+	}
+
+	s := sync.New()
+	batch := []sync.Delta{}
+	var writeErr error
+	err := s.DeltaFromChunks(data, chunks, sync.Checkpoint{}, func(d sync.Delta) {
+		batch = append(batch, d)
+		if len(batch) < batchSize || writeErr != nil {
+			return
+		}
+
+		writeErr = writeFrame(conn, frameDeltas, batch)
+		batch = batch[:0]
+	}, nil)
+
+	if err != nil {
+		return sendError(conn, fmt.Errorf("unable to calculate delta. %w", err))
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if len(batch) > 0 {
+		if err := writeFrame(conn, frameDeltas, batch); err != nil {
+			return err
+		}
+	}
+
+	if err := writeFrame(conn, frameDeltasEnd, nil); err != nil {
+		return err
+	}
+
+	_, err = expect(conn, frameDone)
+	return err
+}
+
+// receive is side of session which has old version of file (or no file at all):
+// it streams signature of basisPath and patches it with received deltas.
+// New version of file is written to temporary file and moved to basisPath when all deltas are applied.
+func receive(conn io.ReadWriter, basisPath string) error {
+	var basis io.ReadSeeker = bytes.NewReader([]byte{})
+	mode := os.FileMode(0644)
+
+	basisFile, err := os.Open(basisPath)
+	if err == nil {
+		defer basisFile.Close()
+		basis = basisFile
+
+		if info, err := basisFile.Stat(); err == nil {
+			mode = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		return sendError(conn, fmt.Errorf("unable to open basis file. %w", err))
+	}
+
+	if err := sendSignature(conn, basis); err != nil {
+		return err
+	}
+
+	if _, err := basis.Seek(0, io.SeekStart); err != nil {
+		return sendError(conn, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(basisPath), 0755); err != nil {
+		return sendError(conn, fmt.Errorf("unable to create directory. %w", err))
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(basisPath), filepath.Base(basisPath)+".sync-*")
+	if err != nil {
+		return sendError(conn, fmt.Errorf("unable to create temporary file. %w", err))
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	writer, err := sync.NewSparseFile(out)
+	if err != nil {
+		return sendError(conn, err)
+	}
+
+	s := sync.New()
+	patcher := s.NewPatcher(basis, writer)
+	for {
+		f, err := expect(conn, frameDeltas, frameDeltasEnd)
+		if err != nil {
+			return err
+		}
+
+		if f.Type == frameDeltasEnd {
+			break
+		}
+
+		batch := []sync.Delta{}
+		if err := f.decode(&batch); err != nil {
+			return err
+		}
+
+		for _, d := range batch {
+			if err := patcher.Apply(d); err != nil {
+				return sendError(conn, err)
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return sendError(conn, err)
+	}
+
+	if err := out.Chmod(mode); err != nil {
+		return sendError(conn, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return sendError(conn, err)
+	}
+
+	if err := os.Rename(out.Name(), basisPath); err != nil {
+		return sendError(conn, fmt.Errorf("unable to replace basis file. %w", err))
+	}
+
+	return writeFrame(conn, frameDone, nil)
+}
+
+func sendSignature(conn io.Writer, basis io.Reader) error {
+	s := sync.New()
+	batch := []sync.Chunk{}
+	var writeErr error
+
+	err := s.Signature(basis, func(c sync.Chunk) {
+		batch = append(batch, c)
+		if len(batch) < batchSize || writeErr != nil {
+			return
+		}
+
+		writeErr = writeFrame(conn, frameSignature, batch)
+		batch = batch[:0]
+	})
+
+	if err != nil {
+		return sendError(conn, fmt.Errorf("unable to calculate signature. %w", err))
+	}
+
+	if writeErr != nil {
+		return writeErr
+	}
+
+	if len(batch) > 0 {
+		if err := writeFrame(conn, frameSignature, batch); err != nil {
+			return err
+		}
+	}
+
+	return writeFrame(conn, frameSignatureEnd, nil)
+}
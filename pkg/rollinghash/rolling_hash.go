@@ -3,7 +3,9 @@ package rollinghash
 const moduloVal uint32 = 1 << 16
 
 type RollingHash struct {
+	// buffer is ring buffer of window, oldest byte is at start
 	buffer             []byte
+	start              int
 	addOperationsCount int
 	a                  uint32
 	b                  uint32
@@ -18,14 +20,15 @@ func New(bufferSize uint32) *RollingHash {
 }
 
 func (r *RollingHash) Add(b byte) *RollingHash {
-	r.a = (r.a - uint32(r.buffer[0]) + uint32(b)) % moduloVal
-	r.b = (r.b - (r.l)*uint32(r.buffer[0]) + r.a) % moduloVal
+	oldest := r.buffer[r.start]
+	r.a = (r.a - uint32(oldest) + uint32(b)) % moduloVal
+	r.b = (r.b - (r.l)*uint32(oldest) + r.a) % moduloVal
 
-	for i := 0; i < int(r.l)-1; i++ {
-		r.buffer[i] = r.buffer[i+1]
+	r.buffer[r.start] = b
+	r.start++
+	if r.start == len(r.buffer) {
+		r.start = 0
 	}
-
-	r.buffer[r.l-1] = b
 	r.addOperationsCount += 1
 	return r
 }
@@ -39,6 +42,7 @@ func (r *RollingHash) AddBuffer(data []byte) *RollingHash {
 
 func (r *RollingHash) Reset() {
 	r.buffer = make([]byte, r.l)
+	r.start = 0
 	r.a = 0
 	r.b = 0
 	r.addOperationsCount = 0
@@ -50,12 +54,46 @@ func (r *RollingHash) Hash() uint32 {
 }
 
 func (r RollingHash) Buffer() []byte {
-	if len(r.buffer) > r.addOperationsCount {
-		return append([]byte{}, r.buffer[len(r.buffer)-r.addOperationsCount:]...)
+	window := r.window()
+	if len(window) > r.addOperationsCount {
+		return window[len(window)-r.addOperationsCount:]
 	}
 
-	// maybe we should consider creating internal buffer
-	// if someones edits it it may break rolling hash internal state
-	// but will be faster
-	return append([]byte{}, r.buffer...)
+	return window
+}
+
+// window returns copy of buffer ordered from oldest byte
+func (r RollingHash) window() []byte {
+	return append(append([]byte{}, r.buffer[r.start:]...), r.buffer[:r.start]...)
+}
+
+// State is snapshot of rolling hash internals, it allows to continue
+// calculations later (for example after process restart)
+type State struct {
+	Buffer             []byte
+	AddOperationsCount int
+	A                  uint32
+	B                  uint32
+}
+
+func (r *RollingHash) State() State {
+	return State{
+		Buffer:             r.window(),
+		AddOperationsCount: r.addOperationsCount,
+		A:                  r.a,
+		B:                  r.b,
+	}
+}
+
+func (r *RollingHash) Restore(s State) *RollingHash {
+	r.Reset()
+	if len(s.Buffer) != int(r.l) {
+		return r
+	}
+
+	copy(r.buffer, s.Buffer)
+	r.addOperationsCount = s.AddOperationsCount
+	r.a = s.A
+	r.b = s.B
+	return r
 }
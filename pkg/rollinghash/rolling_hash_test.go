@@ -169,3 +169,26 @@ func Test_BufferHasCorrectOrder(t *testing.T) {
 	expected5 := append(expected4[2:], input5...)
 	assert.Equal(t, expected5, h1.Buffer())
 }
+
+func Test_RestoredStateContinuesWithSameHash(t *testing.T) {
+	input := []byte{34, 23, 82, 234, 11}
+
+	h1 := New(4).AddBuffer(input)
+	h2 := New(4).Restore(h1.State())
+
+	assert.Equal(t, h1.Hash(), h2.Hash())
+	assert.Equal(t, h1.Buffer(), h2.Buffer())
+
+	h1.AddBuffer([]byte{76, 221})
+	h2.AddBuffer([]byte{76, 221})
+
+	assert.Equal(t, h1.Hash(), h2.Hash())
+}
+
+func Test_RestoreIgnoresStateWithDifferentBufferSize(t *testing.T) {
+	h1 := New(8).AddBuffer([]byte{1, 2, 3})
+	h2 := New(4).AddBuffer([]byte{4, 5}).Restore(h1.State())
+
+	assert.Equal(t, uint32(0), h2.Hash())
+	assert.Equal(t, []byte{}, h2.Buffer())
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"bytes"
+	"crypto"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	_ "golang.org/x/crypto/md4"
+)
+
+// BackupStats describes how much data backup added to store
+type BackupStats struct {
+	Chunks    int
+	NewChunks int
+	NewBytes  int64
+}
+
+// Backup splits data into chunks, stores chunks which are not yet in store and saves snapshot of data under name
+func (s *Store) Backup(name string, data io.Reader) (Snapshot, BackupStats, error) {
+	snapshot := Snapshot{
+		Name:   name,
+		Time:   time.Now(),
+		Chunks: []Key{},
+	}
+	stats := BackupStats{}
+
+	chunker := sync.NewWithChunkSize(s.config.ChunkSize)
+	var writeErr error
+	err := chunker.SignatureWithData(data, func(c sync.Chunk, chunkData []byte) {
+		if writeErr != nil {
+			return
+		}
+
+		key := Key{}
+		copy(key[:], c.StrongHash)
+		snapshot.Chunks = append(snapshot.Chunks, key)
+		snapshot.Size += int64(len(chunkData))
+		stats.Chunks++
+
+		if _, ok := s.index.get(key); ok {
+			return
+		}
+
+		location, err := s.packs.write(chunkData)
+		if err != nil {
+			writeErr = err
+			return
+		}
+
+		s.index.put(key, location)
+		stats.NewChunks++
+		stats.NewBytes += int64(len(chunkData))
+	})
+
+	if err == nil {
+		err = writeErr
+	}
+
+	if err != nil {
+		return snapshot, stats, fmt.Errorf("unable to store chunks. %w", err)
+	}
+
+	// snapshot can only be saved when all its chunks are in index, and index only when chunks are on disk
+	if err := s.packs.sync(); err != nil {
+		return snapshot, stats, fmt.Errorf("unable to write pack file. %w", err)
+	}
+
+	if err := s.index.save(); err != nil {
+		return snapshot, stats, fmt.Errorf("unable to write store index. %w", err)
+	}
+
+	if err := s.saveSnapshot(&snapshot); err != nil {
+		return snapshot, stats, err
+	}
+
+	return snapshot, stats, nil
+}
+
+// Restore writes data of snapshot to out
+func (s *Store) Restore(id uint32, out io.Writer) error {
+	snapshot, err := s.Snapshot(id)
+	if err != nil {
+		return err
+	}
+
+	for i, key := range snapshot.Chunks {
+		data, err := s.chunk(key)
+		if err != nil {
+			return fmt.Errorf("unable to restore chunk %d (%s) of snapshot %d. %w", i, key, id, err)
+		}
+
+		if _, err := out.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chunk reads data of chunk and verifies that it matches key
+func (s *Store) chunk(key Key) ([]byte, error) {
+	location, ok := s.index.get(key)
+	if !ok {
+		return nil, fmt.Errorf("chunk is not in index")
+	}
+
+	data, err := s.packs.read(location)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(hashChunk(data), key[:]) {
+		return nil, fmt.Errorf("data of chunk does not match its hash")
+	}
+
+	return data, nil
+}
+
+// hashChunk calculates key of chunk data, it is the same hash as strong hash of signature
+func hashChunk(data []byte) []byte {
+	hasher := crypto.MD4.New()
+	hasher.Write(data)
+	return hasher.Sum(nil)
+}
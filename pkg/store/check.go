@@ -0,0 +1,73 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+// CheckReport lists problems found in store
+type CheckReport struct {
+	Chunks    int
+	Snapshots int
+	// Corrupted are chunks which cannot be read or whose data does not match key
+	Corrupted []CorruptedChunk
+	// Missing are chunks referenced by snapshots which are not in index
+	Missing []MissingChunk
+}
+
+type CorruptedChunk struct {
+	Key Key
+	Err error
+}
+
+type MissingChunk struct {
+	Snapshot uint32
+	Key      Key
+}
+
+func (r CheckReport) Ok() bool {
+	return len(r.Corrupted) == 0 && len(r.Missing) == 0
+}
+
+// Check reads every stored chunk, verifies its hash and checks that all chunks of snapshots are stored
+func (s *Store) Check() (CheckReport, error) {
+	report := CheckReport{}
+
+	keys := make([]Key, 0, len(s.index.locations))
+	for key := range s.index.locations {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i][:], keys[j][:]) < 0 })
+
+	if err := s.packs.sync(); err != nil {
+		return report, fmt.Errorf("unable to write pack file. %w", err)
+	}
+
+	for _, key := range keys {
+		report.Chunks++
+		if _, err := s.chunk(key); err != nil {
+			report.Corrupted = append(report.Corrupted, CorruptedChunk{Key: key, Err: err})
+		}
+	}
+
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return report, err
+	}
+
+	for _, snapshot := range snapshots {
+		report.Snapshots++
+		reported := map[Key]bool{}
+		for _, key := range snapshot.Chunks {
+			if _, ok := s.index.get(key); ok || reported[key] {
+				continue
+			}
+
+			reported[key] = true
+			report.Missing = append(report.Missing, MissingChunk{Snapshot: snapshot.Id, Key: key})
+		}
+	}
+
+	return report, nil
+}
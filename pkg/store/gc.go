@@ -0,0 +1,138 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// DefaultRepackThreshold is share of live data below which pack is rewritten by GC
+const DefaultRepackThreshold = 0.5
+
+// GCStats describes what was removed by GC
+type GCStats struct {
+	RemovedChunks  int
+	RemovedPacks   int
+	RepackedPacks  int
+	ReclaimedBytes int64
+}
+
+// DeleteSnapshot removes snapshot, its chunks are removed by next GC if no other snapshot uses them
+func (s *Store) DeleteSnapshot(id uint32) error {
+	err := os.Remove(s.snapshotPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("snapshot %d does not exist", id)
+	}
+
+	return err
+}
+
+// GC removes chunks which are not referenced by any snapshot (mark and sweep).
+// Packs without live chunks are deleted, and packs where share of live data is below repackThreshold
+// have their live chunks copied to new pack. New packs and index are saved before old packs are deleted,
+// so interrupted GC never loses live chunks.
+func (s *Store) GC(repackThreshold float64) (GCStats, error) {
+	stats := GCStats{}
+
+	snapshots, err := s.Snapshots()
+	if err != nil {
+		return stats, err
+	}
+
+	live := map[Key]bool{}
+	for _, snapshot := range snapshots {
+		for _, key := range snapshot.Chunks {
+			live[key] = true
+		}
+	}
+
+	// current pack of this session is treated like any other
+	if err := s.packs.finish(); err != nil {
+		return stats, fmt.Errorf("unable to write pack file. %w", err)
+	}
+
+	packSizes, err := s.packs.sizes()
+	if err != nil {
+		return stats, err
+	}
+
+	liveBytes := map[uint32]int64{}
+	for key, location := range s.index.locations {
+		if !live[key] {
+			delete(s.index.locations, key)
+			stats.RemovedChunks++
+			continue
+		}
+		liveBytes[location.Pack] += int64(location.Length)
+	}
+
+	removed := []uint32{}
+	repack := map[uint32]bool{}
+	for id, size := range packSizes {
+		switch {
+		case liveBytes[id] == 0:
+			removed = append(removed, id)
+			stats.RemovedPacks++
+			stats.ReclaimedBytes += size
+		case float64(liveBytes[id]) < repackThreshold*float64(size):
+			removed = append(removed, id)
+			repack[id] = true
+			stats.RepackedPacks++
+			stats.ReclaimedBytes += size - liveBytes[id]
+		}
+	}
+
+	if err := s.repack(repack); err != nil {
+		return stats, err
+	}
+
+	if err := s.packs.sync(); err != nil {
+		return stats, fmt.Errorf("unable to write pack file. %w", err)
+	}
+
+	if err := s.index.save(); err != nil {
+		return stats, fmt.Errorf("unable to write store index. %w", err)
+	}
+
+	for _, id := range removed {
+		if err := s.packs.remove(id); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// repack copies chunks from packs to new pack, chunks are copied in order of their position
+func (s *Store) repack(packs map[uint32]bool) error {
+	keys := []Key{}
+	for key, location := range s.index.locations {
+		if packs[location.Pack] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := s.index.locations[keys[i]], s.index.locations[keys[j]]
+		if a.Pack != b.Pack {
+			return a.Pack < b.Pack
+		}
+		return a.Offset < b.Offset
+	})
+
+	for _, key := range keys {
+		data, err := s.chunk(key)
+		if err != nil {
+			return fmt.Errorf("unable to repack chunk %s. %w", key, err)
+		}
+
+		location, err := s.packs.write(data)
+		if err != nil {
+			return err
+		}
+		s.index.put(key, location)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GCRemovesChunksOfDeletedSnapshots(t *testing.T) {
+	root := t.TempDir()
+	s, err := Init(root, 64)
+	require.Nil(t, err)
+	defer s.Close()
+
+	first := randomData(6400, 1)
+	second := randomData(6400, 2)
+
+	_, _, err = s.Backup("first", bytes.NewReader(first))
+	require.Nil(t, err)
+	require.Nil(t, s.packs.finish())
+
+	// second pack shares half of data with first
+	shared := append(append([]byte{}, first[:3200]...), second[:3200]...)
+	_, _, err = s.Backup("shared", bytes.NewReader(shared))
+	require.Nil(t, err)
+	require.Nil(t, s.packs.finish())
+
+	_, _, err = s.Backup("second", bytes.NewReader(second))
+	require.Nil(t, err)
+
+	stats, err := s.GC(DefaultRepackThreshold)
+	require.Nil(t, err)
+	require.Equal(t, GCStats{}, stats)
+
+	require.Nil(t, s.DeleteSnapshot(1))
+	require.Nil(t, s.DeleteSnapshot(3))
+	require.ErrorContains(t, s.DeleteSnapshot(3), "snapshot 3 does not exist")
+
+	// first pack keeps half of its data, so it is repacked, second pack is fully live and third is removed
+	stats, err = s.GC(0.6)
+	require.Nil(t, err)
+	require.Equal(t, 100, stats.RemovedChunks)
+	require.Equal(t, 1, stats.RemovedPacks)
+	require.Equal(t, 1, stats.RepackedPacks)
+	require.Equal(t, int64(3200+3200), stats.ReclaimedBytes)
+
+	sizes, err := s.packs.sizes()
+	require.Nil(t, err)
+	require.Equal(t, map[uint32]int64{2: 3200, 4: 3200}, sizes)
+
+	require.Equal(t, shared, restore(t, s, 2))
+	require.Nil(t, s.Close())
+
+	s, err = Open(root)
+	require.Nil(t, err)
+	require.Equal(t, shared, restore(t, s, 2))
+
+	report, err := s.Check()
+	require.Nil(t, err)
+	require.True(t, report.Ok())
+	require.Equal(t, 100, report.Chunks)
+}
+
+func Test_CheckReportsCorruptedAndMissingChunks(t *testing.T) {
+	root := t.TempDir()
+	s, err := Init(root, 64)
+	require.Nil(t, err)
+	defer s.Close()
+
+	data := randomData(640, 3)
+	_, _, err = s.Backup("file", bytes.NewReader(data))
+	require.Nil(t, err)
+	require.Nil(t, s.packs.finish())
+
+	pack := filepath.Join(root, packsDir, formatId(1)+packExtension)
+	file, err := os.OpenFile(pack, os.O_WRONLY, 0)
+	require.Nil(t, err)
+	_, err = file.WriteAt([]byte("broken"), 70)
+	require.Nil(t, err)
+	require.Nil(t, file.Close())
+
+	snapshot, err := s.Snapshot(1)
+	require.Nil(t, err)
+	delete(s.index.locations, snapshot.Chunks[5])
+
+	report, err := s.Check()
+	require.Nil(t, err)
+	require.False(t, report.Ok())
+	require.Equal(t, 9, report.Chunks)
+	require.Len(t, report.Corrupted, 1)
+	require.Equal(t, snapshot.Chunks[1], report.Corrupted[0].Key)
+	require.ErrorContains(t, report.Corrupted[0].Err, "does not match its hash")
+	require.Equal(t, []MissingChunk{{Snapshot: 1, Key: snapshot.Chunks[5]}}, report.Missing)
+}
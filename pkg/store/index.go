@@ -0,0 +1,40 @@
+package store
+
+// Location of chunk data in pack file
+type Location struct {
+	Pack   uint32
+	Offset int64
+	Length uint32
+}
+
+// index keeps location of every chunk, it is saved as a whole after chunks are written to packs,
+// so it never points to data which was not stored
+type index struct {
+	path      string
+	locations map[Key]Location
+}
+
+func loadIndex(path string) (*index, error) {
+	locations := map[Key]Location{}
+	if err := readGob(path, &locations); err != nil {
+		return nil, err
+	}
+
+	return &index{
+		path:      path,
+		locations: locations,
+	}, nil
+}
+
+func (i *index) get(key Key) (Location, bool) {
+	location, ok := i.locations[key]
+	return location, ok
+}
+
+func (i *index) put(key Key, location Location) {
+	i.locations[key] = location
+}
+
+func (i *index) save() error {
+	return writeGob(i.path, i.locations)
+}
@@ -0,0 +1,187 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxPackSize is size after which new pack file is started
+const maxPackSize = 64 * 1024 * 1024
+
+const packExtension = ".pack"
+
+// packs appends chunks to pack files, new store session always starts new pack
+type packs struct {
+	dir     string
+	readers map[uint32]*os.File
+	writer  *os.File
+	current uint32
+	size    int64
+	nextId  uint32
+}
+
+func openPacks(dir string) (*packs, error) {
+	ids, err := listIds(dir, packExtension)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pack files. %w", err)
+	}
+
+	nextId := uint32(1)
+	if len(ids) > 0 {
+		nextId = ids[len(ids)-1] + 1
+	}
+
+	return &packs{
+		dir:     dir,
+		readers: map[uint32]*os.File{},
+		nextId:  nextId,
+	}, nil
+}
+
+func (p *packs) write(data []byte) (Location, error) {
+	if p.writer == nil || p.size+int64(len(data)) > maxPackSize {
+		if err := p.finish(); err != nil {
+			return Location{}, err
+		}
+
+		writer, err := os.OpenFile(p.path(p.nextId), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return Location{}, fmt.Errorf("unable to create pack file. %w", err)
+		}
+
+		p.writer = writer
+		p.current = p.nextId
+		p.size = 0
+		p.nextId++
+	}
+
+	if _, err := p.writer.Write(data); err != nil {
+		return Location{}, fmt.Errorf("unable to write pack file. %w", err)
+	}
+
+	location := Location{Pack: p.current, Offset: p.size, Length: uint32(len(data))}
+	p.size += int64(len(data))
+	return location, nil
+}
+
+// sync flushes written chunks to disk, it has to be called before index pointing to them is saved
+func (p *packs) sync() error {
+	if p.writer == nil {
+		return nil
+	}
+
+	return p.writer.Sync()
+}
+
+// finish closes currently written pack, next write starts new pack
+func (p *packs) finish() error {
+	if p.writer == nil {
+		return nil
+	}
+
+	err := p.writer.Sync()
+	if closeErr := p.writer.Close(); err == nil {
+		err = closeErr
+	}
+
+	p.writer = nil
+	return err
+}
+
+func (p *packs) read(location Location) ([]byte, error) {
+	reader, ok := p.readers[location.Pack]
+	if !ok {
+		var err error
+		reader, err = os.Open(p.path(location.Pack))
+		if err != nil {
+			return nil, fmt.Errorf("unable to open pack file. %w", err)
+		}
+		p.readers[location.Pack] = reader
+	}
+
+	data := make([]byte, location.Length)
+	if _, err := reader.ReadAt(data, location.Offset); err != nil {
+		return nil, fmt.Errorf("unable to read chunk from pack %d at %d. %w", location.Pack, location.Offset, err)
+	}
+
+	return data, nil
+}
+
+// sizes returns size of every pack file
+func (p *packs) sizes() (map[uint32]int64, error) {
+	ids, err := listIds(p.dir, packExtension)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pack files. %w", err)
+	}
+
+	sizes := map[uint32]int64{}
+	for _, id := range ids {
+		info, err := os.Stat(p.path(id))
+		if err != nil {
+			return nil, err
+		}
+		sizes[id] = info.Size()
+	}
+
+	return sizes, nil
+}
+
+func (p *packs) remove(id uint32) error {
+	if reader, ok := p.readers[id]; ok {
+		reader.Close()
+		delete(p.readers, id)
+	}
+
+	if err := os.Remove(p.path(id)); err != nil {
+		return fmt.Errorf("unable to remove pack file. %w", err)
+	}
+
+	return nil
+}
+
+func (p *packs) close() error {
+	err := p.finish()
+	for id, reader := range p.readers {
+		reader.Close()
+		delete(p.readers, id)
+	}
+
+	return err
+}
+
+func (p *packs) path(id uint32) string {
+	return filepath.Join(p.dir, formatId(id)+packExtension)
+}
+
+func formatId(id uint32) string {
+	return fmt.Sprintf("%08d", id)
+}
+
+// listIds returns sorted ids of files with extension in dir
+func listIds(dir string, extension string) ([]uint32, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []uint32{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, extension) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, extension), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, uint32(id))
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
@@ -0,0 +1,80 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const snapshotExtension = ".snap"
+
+// Snapshot is recipe of backed up file, file is restored by joining data of its chunks
+type Snapshot struct {
+	Id     uint32
+	Name   string
+	Time   time.Time
+	Size   int64
+	Chunks []Key
+}
+
+// Snapshots returns all snapshots ordered by id
+func (s *Store) Snapshots() ([]Snapshot, error) {
+	ids, err := listIds(s.snapshotsDir(), snapshotExtension)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list snapshots. %w", err)
+	}
+
+	snapshots := make([]Snapshot, 0, len(ids))
+	for _, id := range ids {
+		snapshot, err := s.Snapshot(id)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+func (s *Store) Snapshot(id uint32) (Snapshot, error) {
+	snapshot := Snapshot{}
+	err := readGob(s.snapshotPath(id), &snapshot)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot, fmt.Errorf("snapshot %d does not exist", id)
+	}
+
+	if err != nil {
+		return snapshot, fmt.Errorf("unable to read snapshot %d. %w", id, err)
+	}
+
+	return snapshot, nil
+}
+
+// saveSnapshot assigns next id to snapshot and saves it
+func (s *Store) saveSnapshot(snapshot *Snapshot) error {
+	ids, err := listIds(s.snapshotsDir(), snapshotExtension)
+	if err != nil {
+		return fmt.Errorf("unable to list snapshots. %w", err)
+	}
+
+	snapshot.Id = 1
+	if len(ids) > 0 {
+		snapshot.Id = ids[len(ids)-1] + 1
+	}
+
+	if err := writeGob(s.snapshotPath(snapshot.Id), snapshot); err != nil {
+		return fmt.Errorf("unable to write snapshot. %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) snapshotsDir() string {
+	return filepath.Join(s.root, snapshotsDir)
+}
+
+func (s *Store) snapshotPath(id uint32) string {
+	return filepath.Join(s.snapshotsDir(), formatId(id)+snapshotExtension)
+}
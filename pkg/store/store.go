@@ -0,0 +1,170 @@
+// Package store keeps deduplicated backups of files in local directory.
+// Files are split into chunks like in signature, every unique chunk is kept once in pack files
+// and index maps strong hash of chunk to its location. Snapshot is recipe of file, ordered list of its chunk hashes.
+//
+//	config              chunk size of store
+//	index               location of every chunk
+//	packs/<id>.pack     chunk data written one after another
+//	snapshots/<id>.snap recipes of backed up files
+//
+// Store is not safe for concurrent use, also by different processes.
+package store
+
+import (
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DefaultChunkSize is chunk size of new stores, it is bigger than chunk size of signatures,
+// as every chunk needs index entry
+const DefaultChunkSize = 4 * 1024
+
+const storeVersion = 1
+
+const (
+	configFile   = "config"
+	indexFile    = "index"
+	packsDir     = "packs"
+	snapshotsDir = "snapshots"
+)
+
+// Key identifies chunk, it is strong hash of chunk data
+type Key [16]byte
+
+func (k Key) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+type Config struct {
+	Version   int
+	ChunkSize int
+}
+
+type Store struct {
+	root   string
+	config Config
+	index  *index
+	packs  *packs
+}
+
+// Init creates new store in root directory, directory can exist but must not contain store
+func Init(root string, chunkSize int) (*Store, error) {
+	if chunkSize <= 0 {
+		return nil, fmt.Errorf("chunk size has to be positive, got %d", chunkSize)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, configFile)); err == nil {
+		return nil, fmt.Errorf("store already exists in '%s'", root)
+	}
+
+	for _, dir := range []string{packsDir, snapshotsDir} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0755); err != nil {
+			return nil, fmt.Errorf("unable to create store directory. %w", err)
+		}
+	}
+
+	config := Config{Version: storeVersion, ChunkSize: chunkSize}
+	if err := writeGob(filepath.Join(root, configFile), config); err != nil {
+		return nil, fmt.Errorf("unable to write store config. %w", err)
+	}
+
+	if err := writeGob(filepath.Join(root, indexFile), map[Key]Location{}); err != nil {
+		return nil, fmt.Errorf("unable to write store index. %w", err)
+	}
+
+	return Open(root)
+}
+
+// Open opens existing store
+func Open(root string) (*Store, error) {
+	config := Config{}
+	if err := readGob(filepath.Join(root, configFile), &config); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("store does not exist in '%s'", root)
+		}
+		return nil, fmt.Errorf("unable to read store config. %w", err)
+	}
+
+	if config.Version != storeVersion {
+		return nil, fmt.Errorf("unsupported store version %d", config.Version)
+	}
+
+	index, err := loadIndex(filepath.Join(root, indexFile))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read store index. %w", err)
+	}
+
+	packs, err := openPacks(filepath.Join(root, packsDir))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		root:   root,
+		config: config,
+		index:  index,
+		packs:  packs,
+	}, nil
+}
+
+// OpenOrInit opens store, or creates it with chunkSize when root does not contain store
+func OpenOrInit(root string, chunkSize int) (*Store, error) {
+	if _, err := os.Stat(filepath.Join(root, configFile)); errors.Is(err, os.ErrNotExist) {
+		return Init(root, chunkSize)
+	}
+
+	return Open(root)
+}
+
+func (s *Store) Config() Config {
+	return s.config
+}
+
+// Close releases opened pack files
+func (s *Store) Close() error {
+	return s.packs.close()
+}
+
+// writeGob atomically replaces file with gob encoded value
+func writeGob(path string, value interface{}) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := gob.NewEncoder(file).Encode(value); err != nil {
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
+
+func readGob(path string, value interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = gob.NewDecoder(file).Decode(value)
+	if err == io.EOF {
+		return fmt.Errorf("file '%s' is empty", path)
+	}
+
+	return err
+}
@@ -0,0 +1,96 @@
+package store
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SnapshotsOfSimilarFilesShareChunks(t *testing.T) {
+	root := t.TempDir()
+	s, err := Init(root, 64)
+	require.Nil(t, err)
+
+	first := randomData(10000, 1)
+	second := append([]byte{}, first...)
+	copy(second[5000:], []byte("changed"))
+	second = append(second, []byte("appended")...)
+
+	snapshot, stats, err := s.Backup("file", bytes.NewReader(first))
+	require.Nil(t, err)
+	require.Equal(t, uint32(1), snapshot.Id)
+	require.Equal(t, int64(len(first)), snapshot.Size)
+	require.Equal(t, BackupStats{Chunks: 157, NewChunks: 157, NewBytes: 10000}, stats)
+
+	snapshot, stats, err = s.Backup("file", bytes.NewReader(second))
+	require.Nil(t, err)
+	require.Equal(t, uint32(2), snapshot.Id)
+	// changed chunk and last chunk which was extended
+	require.Equal(t, 2, stats.NewChunks)
+	require.Nil(t, s.Close())
+
+	s, err = Open(root)
+	require.Nil(t, err)
+	defer s.Close()
+
+	require.Equal(t, first, restore(t, s, 1))
+	require.Equal(t, second, restore(t, s, 2))
+
+	snapshots, err := s.Snapshots()
+	require.Nil(t, err)
+	require.Len(t, snapshots, 2)
+	require.Equal(t, "file", snapshots[1].Name)
+
+	// new session writes to new pack file
+	_, stats, err = s.Backup("other", bytes.NewReader([]byte("small file")))
+	require.Nil(t, err)
+	require.Equal(t, 1, stats.NewChunks)
+	require.Equal(t, []byte("small file"), restore(t, s, 3))
+}
+
+func Test_EmptyFileCanBeRestored(t *testing.T) {
+	s, err := Init(t.TempDir(), DefaultChunkSize)
+	require.Nil(t, err)
+	defer s.Close()
+
+	snapshot, _, err := s.Backup("empty", bytes.NewReader(nil))
+	require.Nil(t, err)
+	require.Empty(t, snapshot.Chunks)
+	require.Equal(t, []byte{}, restore(t, s, snapshot.Id))
+}
+
+func Test_StoreErrors(t *testing.T) {
+	root := t.TempDir()
+
+	_, err := Open(root)
+	require.ErrorContains(t, err, "store does not exist")
+
+	s, err := OpenOrInit(root, 128)
+	require.Nil(t, err)
+	require.Equal(t, 128, s.Config().ChunkSize)
+	defer s.Close()
+
+	_, err = Init(root, 128)
+	require.ErrorContains(t, err, "store already exists")
+
+	_, err = Init(t.TempDir(), 0)
+	require.ErrorContains(t, err, "chunk size has to be positive")
+
+	err = s.Restore(7, &bytes.Buffer{})
+	require.ErrorContains(t, err, "snapshot 7 does not exist")
+}
+
+func restore(t *testing.T, s *Store, id uint32) []byte {
+	out := bytes.Buffer{}
+	require.Nil(t, s.Restore(id, &out))
+	return append([]byte{}, out.Bytes()...)
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}
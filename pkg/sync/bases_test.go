@@ -0,0 +1,55 @@
+package sync
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DeltaFromBasesCopiesChunksOfEveryBasis(t *testing.T) {
+	release := []byte("0123456789abcdef0123456789ABCDEF")
+	library := []byte("library data 16blibrary data 32b")
+	newData := append(append(append([]byte{}, library[16:]...), "new"...), release[:16]...)
+
+	s := New()
+	deltas := []Delta{}
+	err := s.DeltaFromBases(bytes.NewReader(newData), []BasisSignature{
+		{Basis: 0, Chunks: signatureOf(t, &s, release)},
+		{Basis: 7, Chunks: signatureOf(t, &s, library)},
+	}, func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	require.Nil(t, err)
+
+	require.Equal(t, ExistingDataDelta(0, 7, 1), deltas[0])
+	require.Equal(t, ExistingDataDelta(deltas[len(deltas)-1].Id, 0, 0), deltas[len(deltas)-1])
+	require.Len(t, deltas[len(deltas)-1].Data, 4)
+
+	out := bytes.Buffer{}
+	err = s.PatchFromBases(map[uint32]io.ReadSeeker{
+		0: bytes.NewReader(release),
+		7: bytes.NewReader(library),
+	}, deltas, &out)
+	require.Nil(t, err)
+	require.Equal(t, newData, out.Bytes())
+}
+
+func Test_PatchFromBasesFailsWhenBasisIsMissing(t *testing.T) {
+	s := New()
+	err := s.PatchFromBases(map[uint32]io.ReadSeeker{
+		0: bytes.NewReader([]byte("basis")),
+	}, []Delta{ExistingDataDelta(0, 3, 0)}, &bytes.Buffer{})
+	require.NotNil(t, err)
+}
+
+func Test_ExistingDataOfReadsBasisAndChunk(t *testing.T) {
+	basis, chunk := ExistingDataOf(ExistingDataDelta(0, 2, 5))
+	require.Equal(t, uint32(2), basis)
+	require.Equal(t, uint32(5), chunk)
+
+	basis, chunk = ExistingDataOf(Delta{Operation: ExistingData, Data: uint32ToBytes(5)})
+	require.Equal(t, uint32(0), basis)
+	require.Equal(t, uint32(5), chunk)
+}
@@ -0,0 +1,74 @@
+package sync
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/rollinghash"
+)
+
+// Checkpoint describes how far delta calculation or patching got,
+// so it can be continued after interruption
+type Checkpoint struct {
+	// InputSize and SourceDigest describe files for which checkpoint was created.
+	// For delta those are size of input file and digest of signature file,
+	// for patch size of basis file and digest of delta file.
+	InputSize    int64
+	SourceDigest []byte
+
+	InputOffset  int64
+	DeltaIndex   uint32
+	OutputOffset int64
+	RollingHash  rollinghash.State
+}
+
+type CheckpointHandler func(Checkpoint) error
+
+// Validate checks if checkpoint was created for the same files
+func (c Checkpoint) Validate(inputSize int64, sourceDigest []byte) error {
+	if c.InputSize != inputSize {
+		return fmt.Errorf("checkpoint was created for input of size %d, but current size is %d", c.InputSize, inputSize)
+	}
+
+	if !bytes.Equal(c.SourceDigest, sourceDigest) {
+		return fmt.Errorf("checkpoint was created for different signature or delta file")
+	}
+
+	return nil
+}
+
+func Digest(data io.Reader) ([]byte, error) {
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, data); err != nil {
+		return nil, err
+	}
+
+	return hasher.Sum(nil), nil
+}
+
+func DeserializeCheckpoint(checkpointReader io.Reader) (Checkpoint, error) {
+	checkpoint := Checkpoint{}
+
+	enc := gob.NewDecoder(checkpointReader)
+	err := enc.Decode(&checkpoint)
+	if err != nil {
+		return checkpoint, err
+	}
+
+	return checkpoint, nil
+}
+
+func SerializeCheckpoint(checkpoint Checkpoint) (io.Reader, error) {
+	var buffer bytes.Buffer
+	enc := gob.NewEncoder(&buffer)
+
+	err := enc.Encode(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buffer, nil
+}
@@ -0,0 +1,106 @@
+package sync
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DeltaFromCheckpointGivesSameDeltasAsFullRun(t *testing.T) {
+	oldData, _ := dataGenerateRandom(300)
+	newPart, _ := dataGenerateRandomWithSeed(25, 300)
+	newData := append(append(append([]byte{}, oldData[:70]...), newPart...), oldData[70:]...)
+
+	s := New()
+	chunks := []Chunk{}
+	s.Signature(bytes.NewReader(oldData), func(c Chunk) {
+		chunks = append(chunks, c)
+	})
+	signature, err := SerializeChunks(chunks)
+	require.Nil(t, err)
+	signatureBytes := signature.(*bytes.Buffer).Bytes()
+
+	expected := []Delta{}
+	checkpoints := []Checkpoint{}
+	err = s.DeltaFrom(bytes.NewReader(newData), bytes.NewReader(signatureBytes), Checkpoint{}, func(d Delta) {
+		expected = append(expected, d)
+	}, func(c Checkpoint) error {
+		checkpoints = append(checkpoints, c)
+		return nil
+	})
+	require.Nil(t, err)
+	require.True(t, len(checkpoints) > 2)
+
+	for _, from := range checkpoints {
+		resumed := append([]Delta{}, expected[:from.DeltaIndex]...)
+
+		s := New()
+		err := s.DeltaFrom(bytes.NewReader(newData[from.InputOffset:]), bytes.NewReader(signatureBytes), from, func(d Delta) {
+			resumed = append(resumed, d)
+		}, nil)
+
+		require.Nil(t, err)
+		require.Equal(t, expected, resumed, "mismatch when resuming from offset %d", from.InputOffset)
+	}
+}
+
+func Test_CheckpointValidationDetectsChangedFiles(t *testing.T) {
+	digest, err := Digest(bytes.NewReader([]byte{1, 2, 3}))
+	require.Nil(t, err)
+
+	checkpoint := Checkpoint{InputSize: 10, SourceDigest: digest}
+
+	require.Nil(t, checkpoint.Validate(10, digest))
+	require.NotNil(t, checkpoint.Validate(11, digest))
+
+	otherDigest, err := Digest(bytes.NewReader([]byte{1, 2, 4}))
+	require.Nil(t, err)
+	require.NotNil(t, checkpoint.Validate(10, otherDigest))
+}
+
+func Test_CheckpointSerializationShouldWorkBothWays(t *testing.T) {
+	checkpoint := Checkpoint{
+		InputSize:    200,
+		SourceDigest: []byte{1, 2, 3},
+		InputOffset:  48,
+		DeltaIndex:   12,
+		OutputOffset: 520,
+	}
+
+	reader, err := SerializeCheckpoint(checkpoint)
+	require.Nil(t, err)
+
+	readCheckpoint, err := DeserializeCheckpoint(reader)
+	require.Nil(t, err)
+	require.Equal(t, checkpoint, readCheckpoint)
+}
+
+func Test_DeltasBatchesCanBeReadAfterAppending(t *testing.T) {
+	first := []Delta{{Id: 0, Operation: NewData, Data: []byte{1}}}
+	second := []Delta{{Id: 1, Operation: ExistingData, Data: uint32ToBytes(4)}, {Id: 2, Operation: NewData, Data: []byte{5}}}
+
+	file := bytes.Buffer{}
+	for _, batch := range [][]Delta{first, second} {
+		data, err := SerializeDeltasBatch(batch)
+		require.Nil(t, err)
+		file.Write(data)
+	}
+
+	deltas, err := DeserializeDeltasBatches(bytes.NewReader(file.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, append(first, second...), deltas)
+
+	// decoding stops at first error of handler
+	decoded := []Delta{}
+	err = DecodeDeltasBatches(bytes.NewReader(file.Bytes()), func(d Delta) error {
+		decoded = append(decoded, d)
+		if d.Id == 1 {
+			return fmt.Errorf("stop")
+		}
+		return nil
+	})
+	require.ErrorContains(t, err, "stop")
+	require.Equal(t, append(first, second[0]), decoded)
+}
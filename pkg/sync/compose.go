@@ -0,0 +1,212 @@
+package sync
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+func CopyRangeDelta(id uint32, offset int64, length int64) Delta {
+	data := make([]byte, 16)
+	binary.BigEndian.PutUint64(data, uint64(offset))
+	binary.BigEndian.PutUint64(data[8:], uint64(length))
+
+	return Delta{
+		Id:        id,
+		Operation: CopyRange,
+		Data:      data,
+	}
+}
+
+// CopyRangeOf returns offset and length of basis range copied by CopyRange delta
+func CopyRangeOf(d Delta) (int64, int64) {
+	return int64(binary.BigEndian.Uint64(d.Data)), int64(binary.BigEndian.Uint64(d.Data[8:]))
+}
+
+// segment is part of file created by single delta
+type segment struct {
+	start int64
+	delta Delta
+	// length is nominal, copy at the end of file can be shorter
+	length int64
+}
+
+// ComposeDeltas merges first (basis -> middle file) and second (middle file -> new file) deltas
+// into single delta from basis to new file, without reading any of files.
+// Copies in second delta are resolved to literals of first delta or to ranges of basis.
+// Copy of last basis chunk can be shorter than chunk size, but it can be only at the end of file,
+// so copies of it are limited by end of basis in the same way.
+func ComposeDeltas(first []Delta, second []Delta) ([]Delta, error) {
+	segments := []segment{}
+	var size int64
+	for _, d := range first {
+		length, err := nominalLength(d)
+		if err != nil {
+			return nil, err
+		}
+
+		segments = append(segments, segment{start: size, delta: d, length: length})
+		size += length
+	}
+
+	composer := &composer{}
+	for _, d := range second {
+		switch d.Operation {
+		case NewData:
+			composer.literal(d.Data)
+		case Hole:
+			composer.hole(HoleSize(d))
+		case ExistingData:
+			basis, chunk := ExistingDataOf(d)
+			if basis != 0 {
+				return nil, fmt.Errorf("multi-basis deltas cannot be composed")
+			}
+
+			start := int64(chunk) * defaultChunkSize
+			composer.resolve(segments, size, start, defaultChunkSize)
+		case CopyRange:
+			start, length := CopyRangeOf(d)
+			composer.resolve(segments, size, start, length)
+		case CompressedData:
+			return nil, fmt.Errorf("compressed deltas cannot be composed")
+		case TargetCopy:
+			return nil, fmt.Errorf("deltas with target copies cannot be composed")
+		default:
+			return nil, fmt.Errorf("unknown operation %d", d.Operation)
+		}
+	}
+
+	return composer.finish(), nil
+}
+
+func nominalLength(d Delta) (int64, error) {
+	switch d.Operation {
+	case NewData:
+		return int64(len(d.Data)), nil
+	case ExistingData:
+		if basis, _ := ExistingDataOf(d); basis != 0 {
+			return 0, fmt.Errorf("multi-basis deltas cannot be composed")
+		}
+		return defaultChunkSize, nil
+	case Hole:
+		return HoleSize(d), nil
+	case CopyRange:
+		_, length := CopyRangeOf(d)
+		return length, nil
+	case CompressedData:
+		return 0, fmt.Errorf("compressed deltas cannot be composed")
+	case TargetCopy:
+		return 0, fmt.Errorf("deltas with target copies cannot be composed")
+	}
+
+	return 0, fmt.Errorf("unknown operation %d", d.Operation)
+}
+
+// composer collects deltas of composed file, joining neighbouring deltas of the same kind
+type composer struct {
+	deltas []Delta
+}
+
+// resolve adds deltas recreating range of middle file from segments of first delta
+func (c *composer) resolve(segments []segment, size int64, start int64, length int64) {
+	end := start + length
+	if end > size {
+		end = size
+	}
+
+	i := sort.Search(len(segments), func(i int) bool {
+		return segments[i].start+segments[i].length > start
+	})
+
+	for ; i < len(segments) && start < end; i++ {
+		s := segments[i]
+		from := start - s.start
+		to := end - s.start
+		if to > s.length {
+			to = s.length
+		}
+
+		switch s.delta.Operation {
+		case NewData:
+			c.literal(s.delta.Data[from:to])
+		case Hole:
+			c.hole(to - from)
+		case ExistingData:
+			_, chunk := ExistingDataOf(s.delta)
+			c.copyRange(int64(chunk)*defaultChunkSize+from, to-from)
+		case CopyRange:
+			offset, _ := CopyRangeOf(s.delta)
+			c.copyRange(offset+from, to-from)
+		}
+
+		start = s.start + to
+	}
+}
+
+func (c *composer) last() *Delta {
+	if len(c.deltas) == 0 {
+		return nil
+	}
+
+	return &c.deltas[len(c.deltas)-1]
+}
+
+func (c *composer) literal(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	if last := c.last(); last != nil && last.Operation == NewData {
+		last.Data = append(last.Data, data...)
+		return
+	}
+
+	c.deltas = append(c.deltas, Delta{Operation: NewData, Data: append([]byte{}, data...)})
+}
+
+func (c *composer) hole(size int64) {
+	if size == 0 {
+		return
+	}
+
+	if last := c.last(); last != nil && last.Operation == Hole {
+		*last = HoleDelta(0, HoleSize(*last)+size)
+		return
+	}
+
+	c.deltas = append(c.deltas, HoleDelta(0, size))
+}
+
+func (c *composer) copyRange(offset int64, length int64) {
+	if length == 0 {
+		return
+	}
+
+	if last := c.last(); last != nil && last.Operation == CopyRange {
+		lastOffset, lastLength := CopyRangeOf(*last)
+		if lastOffset+lastLength == offset {
+			*last = CopyRangeDelta(0, lastOffset, lastLength+length)
+			return
+		}
+	}
+
+	c.deltas = append(c.deltas, CopyRangeDelta(0, offset, length))
+}
+
+// finish numbers deltas, single aligned chunks are copied with ExistingData
+func (c *composer) finish() []Delta {
+	deltas := make([]Delta, 0, len(c.deltas))
+	for i, d := range c.deltas {
+		if d.Operation == CopyRange {
+			offset, length := CopyRangeOf(d)
+			if offset%defaultChunkSize == 0 && length == defaultChunkSize {
+				d = Delta{Operation: ExistingData, Data: uint32ToBytes(uint32(offset / defaultChunkSize))}
+			}
+		}
+
+		d.Id = uint32(i)
+		deltas = append(deltas, d)
+	}
+
+	return deltas
+}
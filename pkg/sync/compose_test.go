@@ -0,0 +1,130 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test_ComposedDeltaGivesTheSameResultAsSequentialPatching checks property
+// Patch(basis, ComposeDeltas(d1, d2)) == Patch(Patch(basis, d1), d2) for randomly edited files
+func Test_ComposedDeltaGivesTheSameResultAsSequentialPatching(t *testing.T) {
+	for seed := int64(0); seed < 300; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		basis := randomBytes(random, random.Intn(2000))
+		middle := randomEdits(random, basis)
+		target := randomEdits(random, middle)
+
+		s := New()
+		first := deltasFor(t, &s, basis, middle)
+		second := deltasFor(t, &s, middle, target)
+
+		patchedMiddle := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader(basis), first, &patchedMiddle))
+		require.Equal(t, middle, append([]byte{}, patchedMiddle.Bytes()...), "seed %d", seed)
+
+		composed, err := ComposeDeltas(first, second)
+		require.Nil(t, err)
+
+		out := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader(basis), composed, &out))
+		require.Equal(t, target, append([]byte{}, out.Bytes()...), "seed %d", seed)
+
+		for i, d := range composed {
+			require.Equal(t, uint32(i), d.Id)
+		}
+
+		// composed delta can be composed again
+		next := randomEdits(random, target)
+		third := deltasFor(t, &s, target, next)
+		composed, err = ComposeDeltas(composed, third)
+		require.Nil(t, err)
+
+		out.Reset()
+		require.Nil(t, s.Patch(bytes.NewReader(basis), composed, &out))
+		require.Equal(t, next, append([]byte{}, out.Bytes()...), "seed %d", seed)
+	}
+}
+
+func Test_ComposeResolvesHolesLiteralsAndRanges(t *testing.T) {
+	basis := []byte("0123456789abcdefghijklmnopqrstuvwxyz")
+
+	first := []Delta{
+		{Operation: NewData, Data: []byte("ABCD")},
+		CopyRangeDelta(0, 10, 8),
+		HoleDelta(0, 6),
+		{Operation: ExistingData, Data: uint32ToBytes(2)},
+	}
+	middle := []byte("ABCDabcdefgh\x00\x00\x00\x00\x00\x00wxyz")
+
+	second := []Delta{
+		CopyRangeDelta(0, 2, 12),
+		{Operation: NewData, Data: []byte("new")},
+		{Operation: ExistingData, Data: uint32ToBytes(1)},
+		{Operation: ExistingData, Data: uint32ToBytes(0)},
+	}
+	target := append(append(append(append([]byte{}, middle[2:14]...), "new"...), middle[16:]...), middle[:16]...)
+
+	s := New()
+	sequential := bytes.Buffer{}
+	require.Nil(t, s.Patch(bytes.NewReader(middle), second, &sequential))
+	require.Equal(t, target, sequential.Bytes())
+
+	composed, err := ComposeDeltas(first, second)
+	require.Nil(t, err)
+	require.Equal(t, []Delta{
+		{Id: 0, Operation: NewData, Data: []byte("CD")},
+		CopyRangeDelta(1, 10, 8),
+		HoleDelta(2, 2),
+		{Id: 3, Operation: NewData, Data: []byte("new")},
+		HoleDelta(4, 2),
+		CopyRangeDelta(5, 32, 14),
+		{Id: 6, Operation: NewData, Data: []byte("ABCD")},
+		CopyRangeDelta(7, 10, 8),
+		HoleDelta(8, 4),
+	}, composed)
+
+	out := bytes.Buffer{}
+	require.Nil(t, s.Patch(bytes.NewReader(basis), composed, &out))
+	require.Equal(t, target, out.Bytes())
+
+	_, err = ComposeDeltas([]Delta{{Operation: CompressedData, Data: []byte{1}}}, second)
+	require.ErrorContains(t, err, "compressed deltas cannot be composed")
+}
+
+func randomBytes(random *rand.Rand, size int) []byte {
+	data := make([]byte, size)
+	random.Read(data)
+	return data
+}
+
+// randomEdits inserts, removes, replaces and duplicates random parts of data
+func randomEdits(random *rand.Rand, data []byte) []byte {
+	edited := append([]byte{}, data...)
+	for edits := random.Intn(5); edits > 0; edits-- {
+		at := 0
+		if len(edited) > 0 {
+			at = random.Intn(len(edited))
+		}
+		length := random.Intn(100)
+		if at+length > len(edited) {
+			length = len(edited) - at
+		}
+
+		switch random.Intn(4) {
+		case 0:
+			edited = append(edited[:at], append(randomBytes(random, random.Intn(100)), edited[at:]...)...)
+		case 1:
+			edited = append(edited[:at], edited[at+length:]...)
+		case 2:
+			copy(edited[at:at+length], randomBytes(random, length))
+		case 3:
+			part := append([]byte{}, edited[at:at+length]...)
+			edited = append(edited, part...)
+		}
+	}
+
+	return edited
+}
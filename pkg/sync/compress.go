@@ -0,0 +1,292 @@
+package sync
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec used to compress literal data of delta
+type Codec byte
+
+const (
+	NoCompression Codec = iota
+	Deflate
+	Zstd
+)
+
+var codecNames = map[Codec]string{
+	NoCompression: "none",
+	Deflate:       "deflate",
+	Zstd:          "zstd",
+}
+
+func (c Codec) String() string {
+	if name, ok := codecNames[c]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", byte(c))
+}
+
+func ParseCodec(name string) (Codec, error) {
+	for codec, codecName := range codecNames {
+		if codecName == name {
+			return codec, nil
+		}
+	}
+
+	return NoCompression, fmt.Errorf("unknown compression '%s'", name)
+}
+
+// dictionarySize is amount of data preceding literal which is used as dictionary,
+// it is the same as window of deflate
+const dictionarySize = 32 * 1024
+
+// minCompressedLength is shortest literal which is compressed, shorter are kept as NewData
+const minCompressedLength = 64
+
+// zstdDictionaryId is id of raw dictionary, 0 would mean that frame has no dictionary
+const zstdDictionaryId = 1
+
+// Compressor joins literal bytes of deltas into runs and compresses them.
+// Like rsync -z, dictionary of compressor contains data which precedes run in new file,
+// so literals referring to matched basis data compress well. Receiver has the same data,
+// as it is last part of file written by patch.
+type Compressor struct {
+	codec        Codec
+	input        io.ReaderAt
+	inputSize    int64
+	handleDeltas DeltaHandler
+	position     int64
+	literal      []byte
+	id           uint32
+	err          error
+}
+
+// NewCompressor creates compressor of deltas calculated for input,
+// it passes deltas to handleDeltas with literal runs replaced by CompressedData
+func NewCompressor(codec Codec, input io.ReaderAt, inputSize int64, handleDeltas DeltaHandler) *Compressor {
+	return &Compressor{
+		codec:        codec,
+		input:        input,
+		inputSize:    inputSize,
+		handleDeltas: handleDeltas,
+	}
+}
+
+// Handle takes next delta, it can be used as DeltaHandler. Errors are returned by Close
+func (c *Compressor) Handle(d Delta) {
+	if c.err != nil {
+		return
+	}
+
+	if d.Operation == NewData {
+		c.literal = append(c.literal, d.Data...)
+		c.position += int64(len(d.Data))
+		return
+	}
+
+	if c.err = c.flush(); c.err != nil {
+		return
+	}
+
+	switch d.Operation {
+	case ExistingData:
+		c.position += c.chunkLength()
+	case Hole:
+		c.position += HoleSize(d)
+	case TargetCopy:
+		_, length := CopyRangeOf(d)
+		c.position += length
+	case CopyRange:
+		_, length := CopyRangeOf(d)
+		if left := c.inputSize - c.position; left < length {
+			length = left
+		}
+		c.position += length
+	}
+
+	c.emit(d)
+}
+
+// Close compresses last literal run
+func (c *Compressor) Close() error {
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.flush()
+}
+
+// chunkLength returns length of copied chunk, only chunk at the end of input can be shorter
+func (c *Compressor) chunkLength() int64 {
+	if left := c.inputSize - c.position; left < defaultChunkSize {
+		return left
+	}
+
+	return defaultChunkSize
+}
+
+func (c *Compressor) emit(d Delta) {
+	d.Id = c.id
+	c.id++
+	c.handleDeltas(d)
+}
+
+func (c *Compressor) flush() error {
+	if len(c.literal) == 0 {
+		return nil
+	}
+
+	literal := c.literal
+	c.literal = nil
+
+	if len(literal) < minCompressedLength || c.codec == NoCompression {
+		c.emit(Delta{Operation: NewData, Data: literal})
+		return nil
+	}
+
+	start := c.position - int64(len(literal))
+	dictionaryStart := start - dictionarySize
+	if dictionaryStart < 0 {
+		dictionaryStart = 0
+	}
+
+	dictionary := make([]byte, start-dictionaryStart)
+	if _, err := c.input.ReadAt(dictionary, dictionaryStart); err != nil {
+		return fmt.Errorf("unable to read dictionary at %d. %w", dictionaryStart, err)
+	}
+
+	compressed, err := compress(c.codec, dictionary, literal)
+	if err != nil {
+		return err
+	}
+
+	if len(compressed) >= len(literal) {
+		c.emit(Delta{Operation: NewData, Data: literal})
+		return nil
+	}
+
+	c.emit(Delta{Operation: CompressedData, Data: append([]byte{byte(c.codec)}, compressed...)})
+	return nil
+}
+
+func compress(codec Codec, dictionary []byte, data []byte) ([]byte, error) {
+	switch codec {
+	case Deflate:
+		out := &bytes.Buffer{}
+		writer, err := flate.NewWriterDict(out, flate.BestCompression, dictionary)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	case Zstd:
+		options := []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithEncoderLevel(zstd.SpeedBetterCompression)}
+		if len(dictionary) > 0 {
+			options = append(options, zstd.WithEncoderDictRaw(zstdDictionaryId, dictionary))
+		}
+
+		encoder, err := zstd.NewWriter(nil, options...)
+		if err != nil {
+			return nil, err
+		}
+		defer encoder.Close()
+
+		return encoder.EncodeAll(data, nil), nil
+	}
+
+	return nil, fmt.Errorf("unknown compression %s", codec)
+}
+
+// decompress reverses compress, dictionary has to be the same as used for compression
+func decompress(data []byte, dictionary []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("compressed data has no codec")
+	}
+
+	codec, compressed := Codec(data[0]), data[1:]
+	switch codec {
+	case Deflate:
+		return io.ReadAll(flate.NewReaderDict(bytes.NewReader(compressed), dictionary))
+	case Zstd:
+		options := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if len(dictionary) > 0 {
+			options = append(options, zstd.WithDecoderDictRaw(zstdDictionaryId, dictionary))
+		}
+
+		decoder, err := zstd.NewReader(nil, options...)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+
+		return decoder.DecodeAll(compressed, nil)
+	}
+
+	return nil, fmt.Errorf("unknown compression %s", codec)
+}
+
+// history keeps at least last targetWindowSize bytes written by patch,
+// they are used as dictionary of compressed data and as source of TargetCopy
+type history struct {
+	data []byte
+	// written is number of all bytes written to history
+	written int64
+}
+
+func (h *history) Write(p []byte) (int, error) {
+	h.written += int64(len(p))
+	if len(p) >= targetWindowSize {
+		h.data = append(h.data[:0], p[len(p)-targetWindowSize:]...)
+		return len(p), nil
+	}
+
+	h.data = append(h.data, p...)
+	if len(h.data) > 2*targetWindowSize {
+		h.data = append(h.data[:0], h.data[len(h.data)-targetWindowSize:]...)
+	}
+
+	return len(p), nil
+}
+
+// skip adds size zeros to history, only those which can be used are written
+func (h *history) skip(size int64) error {
+	written := min64(size, targetWindowSize)
+	if _, err := io.CopyN(h, zeros{}, written); err != nil {
+		return err
+	}
+
+	h.written += size - written
+	return nil
+}
+
+// at returns copy of length bytes written at offset
+func (h *history) at(offset int64, length int64) ([]byte, error) {
+	start := h.written - int64(len(h.data))
+	if offset < start || offset+length > h.written || length < 0 {
+		return nil, fmt.Errorf("range %d-%d is outside of written data %d-%d", offset, offset+length, start, h.written)
+	}
+
+	from := offset - start
+	return append([]byte{}, h.data[from:from+length]...), nil
+}
+
+func (h *history) dictionary() []byte {
+	if len(h.data) > dictionarySize {
+		return h.data[len(h.data)-dictionarySize:]
+	}
+
+	return h.data
+}
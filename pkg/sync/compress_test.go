@@ -0,0 +1,184 @@
+package sync
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PatchRecreatesNewFileFromCompressedDeltas(t *testing.T) {
+	oldData, _ := dataGenerateRandom(5000)
+	newPart, _ := dataGenerateRandomWithSeed(400, 300)
+	text := bytes.Repeat([]byte("repeated text "), 100)
+
+	tests := []struct {
+		name    string
+		newData []byte
+	}{
+		{"same file", oldData},
+		{"inserted data", append(append(append([]byte{}, oldData[:300]...), newPart...), oldData[300:]...)},
+		{"compressible data", append(append(append([]byte{}, oldData[:300]...), text...), oldData[300:]...)},
+		{"completely new file", text},
+		{"short literal", append(append([]byte{}, oldData...), 'a', 'b')},
+		{"empty file", []byte{}},
+	}
+
+	for _, codec := range []Codec{Deflate, Zstd} {
+		for _, test := range tests {
+			t.Run(codec.String()+" "+test.name, func(t *testing.T) {
+				s := New()
+				deltas := compressedDeltasFor(t, &s, codec, oldData, test.newData)
+
+				for i, d := range deltas {
+					require.Equal(t, uint32(i), d.Id)
+				}
+
+				out := bytes.Buffer{}
+				err := s.Patch(bytes.NewReader(oldData), deltas, &out)
+
+				require.Nil(t, err)
+				require.Equal(t, test.newData, append([]byte{}, out.Bytes()...))
+			})
+		}
+	}
+}
+
+func Test_CompressionUsesPrecedingDataAsDictionary(t *testing.T) {
+	oldData, _ := dataGenerateRandom(4000)
+
+	// every 8th byte is changed, so no chunk matches, but data is similar to what precedes it
+	changed := append([]byte{}, oldData[:2000]...)
+	for i := 0; i < len(changed); i += 8 {
+		changed[i]++
+	}
+	newData := append(append([]byte{}, oldData[:2000]...), changed...)
+
+	for _, codec := range []Codec{Deflate, Zstd} {
+		t.Run(codec.String(), func(t *testing.T) {
+			s := New()
+			deltas := compressedDeltasFor(t, &s, codec, oldData, newData)
+
+			compressed := 0
+			for _, d := range deltas {
+				require.NotEqual(t, NewData, d.Operation)
+				if d.Operation == CompressedData {
+					compressed += len(d.Data)
+				}
+			}
+			require.Less(t, compressed, len(changed)/2)
+
+			out := bytes.Buffer{}
+			require.Nil(t, s.Patch(bytes.NewReader(oldData), deltas, &out))
+			require.Equal(t, newData, out.Bytes())
+		})
+	}
+}
+
+func Test_ParseCodec(t *testing.T) {
+	for _, codec := range []Codec{NoCompression, Deflate, Zstd} {
+		parsed, err := ParseCodec(codec.String())
+		require.Nil(t, err)
+		require.Equal(t, codec, parsed)
+	}
+
+	_, err := ParseCodec("lzma")
+	require.ErrorContains(t, err, "unknown compression 'lzma'")
+}
+
+func Test_DeltaHeaderIsSkippedWhenDeserializing(t *testing.T) {
+	deltas := []Delta{
+		{Id: 0, Operation: ExistingData, Data: uint32ToBytes(1)},
+		{Id: 1, Operation: NewData, Data: []byte("data")},
+	}
+
+	serialized, err := SerializeDeltas(deltas)
+	require.Nil(t, err)
+	serializedBytes, err := io.ReadAll(serialized)
+	require.Nil(t, err)
+
+	withHeader := bytes.Buffer{}
+	require.Nil(t, WriteDeltaHeader(&withHeader, NewDeltaHeader(Zstd)))
+	withHeader.Write(serializedBytes)
+
+	header, rest, err := ReadDeltaHeader(bytes.NewReader(withHeader.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, DeltaHeader{Version: 1, Codec: Zstd}, header)
+
+	restDeltas, err := DeserializeDelta(rest)
+	require.Nil(t, err)
+	require.Equal(t, deltas, restDeltas)
+
+	read, err := DeserializeDelta(bytes.NewReader(withHeader.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, deltas, read)
+
+	// deltas written before header was introduced
+	header, _, err = ReadDeltaHeader(bytes.NewReader(serializedBytes))
+	require.Nil(t, err)
+	require.Equal(t, DeltaHeader{Version: 0, Codec: NoCompression}, header)
+
+	read, err = DeserializeDelta(bytes.NewReader(serializedBytes))
+	require.Nil(t, err)
+	require.Equal(t, deltas, read)
+}
+
+func Test_UnknownDeltaHeaderIsRejected(t *testing.T) {
+	_, _, err := ReadDeltaHeader(bytes.NewReader(append(append([]byte{}, deltaMagic...), 9, 0)))
+	require.ErrorContains(t, err, "unsupported delta version 9")
+
+	_, _, err = ReadDeltaHeader(bytes.NewReader(append(append([]byte{}, deltaMagic...), 1, 7)))
+	require.ErrorContains(t, err, "unsupported delta compression unknown(7)")
+}
+
+func compressedDeltasFor(t *testing.T, s *sync, codec Codec, oldData []byte, newData []byte) []Delta {
+	deltas := []Delta{}
+	compressor := NewCompressor(codec, bytes.NewReader(newData), int64(len(newData)), func(d Delta) {
+		deltas = append(deltas, d)
+	})
+
+	for _, d := range deltasFor(t, s, oldData, newData) {
+		compressor.Handle(d)
+	}
+	require.Nil(t, compressor.Close())
+
+	return deltas
+}
+
+func Test_SignatureHeaderIsSkippedWhenDeserializing(t *testing.T) {
+	chunks := []Chunk{{Id: 0, RollingHash: 1, StrongHash: []byte{1, 2}}}
+
+	serialized, err := SerializeChunks(chunks)
+	require.Nil(t, err)
+	serializedBytes, err := io.ReadAll(serialized)
+	require.Nil(t, err)
+
+	withHeader := bytes.Buffer{}
+	require.Nil(t, WriteSignatureHeader(&withHeader, NewSignatureHeader(16, 10)))
+	withHeader.Write(serializedBytes)
+
+	header, rest, err := ReadSignatureHeader(bytes.NewReader(withHeader.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, SignatureHeader{Version: 1, ChunkSize: 16, Size: 10}, header)
+
+	restChunks, err := DeserializeChunks(rest)
+	require.Nil(t, err)
+	require.Equal(t, chunks, restChunks)
+
+	read, err := DeserializeChunks(bytes.NewReader(withHeader.Bytes()))
+	require.Nil(t, err)
+	require.Equal(t, chunks, read)
+
+	// signatures written before header was introduced
+	header, _, err = ReadSignatureHeader(bytes.NewReader(serializedBytes))
+	require.Nil(t, err)
+	require.Equal(t, SignatureHeader{}, header)
+
+	read, err = DeserializeChunks(bytes.NewReader(serializedBytes))
+	require.Nil(t, err)
+	require.Equal(t, chunks, read)
+
+	_, _, err = ReadSignatureHeader(bytes.NewReader(append(append([]byte{}, signatureMagic...), 9)))
+	require.ErrorContains(t, err, "signature header is truncated")
+}
@@ -0,0 +1,138 @@
+package sync
+
+import (
+	"io"
+)
+
+// PatchWithSignature works like Patch, but it also passes to handleChunks signature of new file, so it does not have to be read again.
+// Chunks copied whole from aligned chunk of basis reuse hashes from basisChunks (signature of basis), only other chunks are hashed.
+func (r *sync) PatchWithSignature(
+	basis io.ReadSeeker, basisChunks []Chunk, deltas []Delta, out io.Writer, handleChunks ChunkHandler,
+) error {
+	deriver := r.newSignatureDeriver(basisChunks, handleChunks)
+	patcher := r.NewPatcher(basis, out)
+	patcher.out = io.MultiWriter(patcher.out, deriver)
+	patcher.signer = deriver
+
+	for _, d := range deltas {
+		if err := patcher.Apply(d); err != nil {
+			return err
+		}
+	}
+
+	deriver.finish()
+	return nil
+}
+
+// signatureDeriver calculates signature of data written to it
+type signatureDeriver struct {
+	r            *sync
+	basisChunks  map[uint32]Chunk
+	handleChunks ChunkHandler
+	id           uint32
+	buffer       []byte
+	// previous is data of last chunk, rolling hash of shorter last chunk depends on it
+	previous []byte
+	// copying is basis offset of next written byte, -1 when written data does not come from basis
+	copying int64
+	// reused is chunk of basis with the same data as buffer
+	reused *Chunk
+}
+
+func (r *sync) newSignatureDeriver(basisChunks []Chunk, handleChunks ChunkHandler) *signatureDeriver {
+	chunks := map[uint32]Chunk{}
+	for _, c := range basisChunks {
+		chunks[c.Id] = c
+	}
+
+	return &signatureDeriver{
+		r:            r,
+		basisChunks:  chunks,
+		handleChunks: handleChunks,
+		buffer:       make([]byte, 0, r.chunkSizeInBytes),
+		previous:     make([]byte, 0, r.chunkSizeInBytes),
+		copying:      -1,
+	}
+}
+
+func (d *signatureDeriver) Write(data []byte) (int, error) {
+	written := len(data)
+	chunkSize := d.r.chunkSizeInBytes
+
+	for len(data) > 0 {
+		if len(d.buffer) == 0 {
+			d.reused = nil
+			if d.copying >= 0 && d.copying%int64(chunkSize) == 0 {
+				if c, ok := d.basisChunks[uint32(d.copying/int64(chunkSize))]; ok {
+					d.reused = &c
+				}
+			}
+		} else if d.copying < 0 {
+			d.reused = nil
+		}
+
+		n := chunkSize - len(d.buffer)
+		if n > len(data) {
+			n = len(data)
+		}
+
+		d.buffer = append(d.buffer, data[:n]...)
+		data = data[n:]
+		if d.copying >= 0 {
+			d.copying += int64(n)
+		}
+
+		if len(d.buffer) == chunkSize {
+			d.emit()
+		}
+	}
+
+	return written, nil
+}
+
+// startCopy informs that following data is copied from basis offset
+func (d *signatureDeriver) startCopy(offset int64) {
+	if d == nil {
+		return
+	}
+
+	// copy has to fill whole chunk to reuse its hashes
+	if len(d.buffer) > 0 {
+		d.reused = nil
+	}
+	d.copying = offset
+}
+
+func (d *signatureDeriver) endCopy() {
+	if d != nil {
+		d.copying = -1
+	}
+}
+
+func (d *signatureDeriver) emit() {
+	if d.reused != nil && len(d.buffer) == d.r.chunkSizeInBytes {
+		d.handleChunks(Chunk{
+			Id:          d.id,
+			RollingHash: d.reused.RollingHash,
+			StrongHash:  d.reused.StrongHash,
+		})
+	} else {
+		// like in Signature window of rolling hash contains previous chunk
+		d.r.rHash.Reset()
+		d.r.rHash.AddBuffer(d.previous)
+		d.r.processChunk(d.id, d.buffer, func(c Chunk, _ []byte) {
+			d.handleChunks(c)
+		})
+	}
+
+	d.id++
+	d.reused = nil
+	d.previous, d.buffer = d.buffer, d.previous[:0]
+}
+
+// finish hashes last chunk which can be shorter than chunk size
+func (d *signatureDeriver) finish() {
+	if len(d.buffer) > 0 {
+		d.emit()
+	}
+}
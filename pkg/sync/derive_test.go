@@ -0,0 +1,64 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func signatureOf(t *testing.T, s *sync, data []byte) []Chunk {
+	chunks := []Chunk{}
+	err := s.Signature(bytes.NewReader(data), func(c Chunk) {
+		chunks = append(chunks, c)
+	})
+	require.Nil(t, err)
+
+	return chunks
+}
+
+func Test_PatchWithSignatureEqualsSignatureOfNewFile(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		basis := randomBytes(random, random.Intn(3000))
+		newData := randomEdits(random, basis)
+
+		s := New()
+		deltas := deltasFor(t, &s, basis, newData)
+
+		derived := []Chunk{}
+		out := bytes.Buffer{}
+		err := s.PatchWithSignature(bytes.NewReader(basis), signatureOf(t, &s, basis), deltas, &out, func(c Chunk) {
+			derived = append(derived, c)
+		})
+		require.Nil(t, err)
+		require.Equal(t, newData, append([]byte{}, out.Bytes()...), "seed %d", seed)
+		require.Equal(t, signatureOf(t, &s, newData), derived, "seed %d", seed)
+	}
+}
+
+func Test_PatchWithSignatureReusesHashesOfCopiedChunks(t *testing.T) {
+	basis := []byte("0123456789abcdef0123456789ABCDEF")
+	deltas := []Delta{
+		{Operation: ExistingData, Data: uint32ToBytes(1)},
+		{Operation: NewData, Data: []byte("new")},
+		CopyRangeDelta(0, 0, 16),
+		CopyRangeDelta(0, 3, 16),
+	}
+
+	s := New()
+	basisChunks := signatureOf(t, &s, basis)
+	// hashes of basis are not recalculated, so marked hash has to show up in derived signature
+	basisChunks[1].StrongHash = []byte("reused")
+
+	derived := []Chunk{}
+	err := s.PatchWithSignature(bytes.NewReader(basis), basisChunks, deltas, &bytes.Buffer{}, func(c Chunk) {
+		derived = append(derived, c)
+	})
+	require.Nil(t, err)
+
+	expected := signatureOf(t, &s, []byte("0123456789ABCDEFnew0123456789abcdef3456789abcdef012"))
+	expected[0].StrongHash = []byte("reused")
+	require.Equal(t, expected, derived)
+}
@@ -0,0 +1,182 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+)
+
+// extendWindow is how many literal bytes preceding match can become part of it,
+// older literal bytes are passed on
+const extendWindow = 4 * 1024
+
+// basisReadSize is size of basis part read at once while extending matches forward
+const basisReadSize = 4 * 1024
+
+// MatchExtender extends chunks matched by delta with neighbouring bytes which are equal in basis.
+// Delta matches only whole chunks, so bytes just before and after match are sent as literals even if they did not change.
+// When basis is available locally, literal bytes preceding match are compared backward and following ones forward
+// with basis, and matched data is copied with byte granular CopyRange. Neighbouring literals are joined.
+// Offsets in new file do not change, so other deltas (like TargetCopy) are passed unchanged.
+type MatchExtender struct {
+	basis        io.ReaderAt
+	basisSize    int64
+	handleDeltas DeltaHandler
+	literal      []byte
+	copyOffset   int64
+	copyLength   int64
+	// ahead is part of basis read from aheadOffset
+	ahead       []byte
+	aheadOffset int64
+	id          uint32
+	err         error
+}
+
+// NewMatchExtender creates extender of deltas calculated against signature of basis,
+// it passes extended deltas to handleDeltas
+func NewMatchExtender(basis io.ReaderAt, basisSize int64, handleDeltas DeltaHandler) *MatchExtender {
+	return &MatchExtender{
+		basis:        basis,
+		basisSize:    basisSize,
+		handleDeltas: handleDeltas,
+	}
+}
+
+// Handle takes next delta, it can be used as DeltaHandler. Errors are returned by Close
+func (e *MatchExtender) Handle(d Delta) {
+	if e.err != nil {
+		return
+	}
+
+	switch d.Operation {
+	case NewData:
+		e.err = e.extendForward(d.Data)
+		return
+	case ExistingData:
+		if basis, chunk := ExistingDataOf(d); basis == 0 {
+			e.err = e.match(int64(chunk) * defaultChunkSize)
+			return
+		}
+	}
+
+	// other operations do not copy from known place of basis
+	e.flushCopy()
+	e.flushLiteral(0)
+	e.emit(d)
+}
+
+// Close passes last literal or copy
+func (e *MatchExtender) Close() error {
+	if e.err != nil {
+		return e.err
+	}
+
+	e.flushCopy()
+	e.flushLiteral(0)
+	return nil
+}
+
+// extendForward extends current copy with literal bytes equal to following bytes of basis
+func (e *MatchExtender) extendForward(data []byte) error {
+	for i, b := range data {
+		if e.copyLength > 0 {
+			next := e.copyOffset + e.copyLength
+			if next < e.basisSize {
+				basisByte, err := e.basisByte(next)
+				if err != nil {
+					return err
+				}
+
+				if basisByte == b {
+					e.copyLength++
+					continue
+				}
+			}
+			e.flushCopy()
+		}
+
+		e.literal = append(e.literal, data[i:]...)
+		e.flushLiteral(extendWindow)
+		return nil
+	}
+
+	return nil
+}
+
+// match adds copy of chunk at basis offset, preceding literal bytes equal to basis become part of it
+func (e *MatchExtender) match(offset int64) error {
+	length := min64(defaultChunkSize, e.basisSize-offset)
+	if length <= 0 {
+		return fmt.Errorf("chunk at %d is outside of basis of size %d", offset, e.basisSize)
+	}
+
+	if e.copyLength > 0 && e.copyOffset+e.copyLength == offset {
+		e.copyLength += length
+		return nil
+	}
+	e.flushCopy()
+
+	back := min64(int64(len(e.literal)), offset)
+	if back > 0 {
+		preceding := make([]byte, back)
+		if _, err := e.basis.ReadAt(preceding, offset-back); err != nil {
+			return fmt.Errorf("unable to read basis at %d. %w", offset-back, err)
+		}
+
+		matched := int64(0)
+		for matched < back && preceding[back-1-matched] == e.literal[int64(len(e.literal))-1-matched] {
+			matched++
+		}
+
+		e.literal = e.literal[:int64(len(e.literal))-matched]
+		offset -= matched
+		length += matched
+	}
+
+	e.flushLiteral(0)
+	e.copyOffset, e.copyLength = offset, length
+	return nil
+}
+
+func (e *MatchExtender) basisByte(offset int64) (byte, error) {
+	if offset < e.aheadOffset || offset >= e.aheadOffset+int64(len(e.ahead)) {
+		e.ahead = make([]byte, min64(basisReadSize, e.basisSize-offset))
+		e.aheadOffset = offset
+		if _, err := e.basis.ReadAt(e.ahead, offset); err != nil {
+			return 0, fmt.Errorf("unable to read basis at %d. %w", offset, err)
+		}
+	}
+
+	return e.ahead[offset-e.aheadOffset], nil
+}
+
+// flushLiteral passes literal bytes except last keep ones
+func (e *MatchExtender) flushLiteral(keep int) {
+	if len(e.literal) <= keep || (keep > 0 && len(e.literal) < 2*keep) {
+		return
+	}
+
+	n := len(e.literal) - keep
+	e.emit(Delta{Operation: NewData, Data: append([]byte{}, e.literal[:n]...)})
+	e.literal = append(e.literal[:0], e.literal[n:]...)
+}
+
+// flushCopy passes current copy, single aligned chunk is copied with ExistingData
+func (e *MatchExtender) flushCopy() {
+	if e.copyLength == 0 {
+		return
+	}
+
+	chunkLength := min64(defaultChunkSize, e.basisSize-e.copyOffset)
+	if e.copyOffset%defaultChunkSize == 0 && e.copyLength == chunkLength {
+		e.emit(ExistingDataDelta(0, 0, uint32(e.copyOffset/defaultChunkSize)))
+	} else {
+		e.emit(CopyRangeDelta(0, e.copyOffset, e.copyLength))
+	}
+	e.copyLength = 0
+}
+
+func (e *MatchExtender) emit(d Delta) {
+	d.Id = e.id
+	e.id++
+	e.handleDeltas(d)
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func extendedDeltasFor(t *testing.T, oldData []byte, newData []byte) []Delta {
+	s := New()
+	deltas := []Delta{}
+	extender := NewMatchExtender(bytes.NewReader(oldData), int64(len(oldData)), func(d Delta) {
+		deltas = append(deltas, d)
+	})
+
+	err := s.DeltaFromChunks(bytes.NewReader(newData), signatureOf(t, &s, oldData), Checkpoint{}, extender.Handle, nil)
+	require.Nil(t, err)
+	require.Nil(t, extender.Close())
+
+	return deltas
+}
+
+func Test_ExtendedDeltaRecreatesNewFile(t *testing.T) {
+	for seed := int64(0); seed < 300; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		basis := randomBytes(random, random.Intn(3000))
+		newData := randomEdits(random, basis)
+
+		deltas := extendedDeltasFor(t, basis, newData)
+		for i, d := range deltas {
+			require.Equal(t, uint32(i), d.Id)
+		}
+
+		s := New()
+		out := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader(basis), deltas, &out))
+		require.Equal(t, newData, append([]byte{}, out.Bytes()...), "seed %d", seed)
+	}
+}
+
+func Test_ExtendedDeltaCopiesShiftedDataAroundMatches(t *testing.T) {
+	basis := []byte("0123456789abcdefghijklmnopqrstuvABCDEFGHIJKLMNOPXYZ")
+	// data is shifted, so bytes before first matched chunk and after last one are literals without extension
+	newData := append([]byte("new"), basis[5:]...)
+
+	deltas := extendedDeltasFor(t, basis, newData)
+	require.Equal(t, []Delta{
+		{Id: 0, Operation: NewData, Data: []byte("new")},
+		CopyRangeDelta(1, 5, int64(len(basis)-5)),
+	}, deltas)
+}
+
+func Test_ExtendedDeltaWithTargetMatchingAndCompressionRecreatesNewFile(t *testing.T) {
+	random := rand.New(rand.NewSource(2))
+	basis := randomBytes(random, 64*1024)
+	newData := append(append([]byte{}, basis[100:40000]...), randomBytes(random, 2*1024*1024)...)
+	// marker is older than window of patch, literals around it are merged by extender
+	newData = append(newData, newData[targetWindowSize+16:targetWindowSize+32]...)
+	newData = append(newData, basis[50000:60000]...)
+
+	s := New()
+	s.SetTargetMatching(true)
+
+	deltas := []Delta{}
+	compressor := NewCompressor(Zstd, bytes.NewReader(newData), int64(len(newData)), func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	extender := NewMatchExtender(bytes.NewReader(basis), int64(len(basis)), compressor.Handle)
+
+	err := s.DeltaFromChunks(bytes.NewReader(newData), signatureOf(t, &s, basis), Checkpoint{}, extender.Handle, nil)
+	require.Nil(t, err)
+	require.Nil(t, extender.Close())
+	require.Nil(t, compressor.Close())
+
+	out := bytes.Buffer{}
+	require.Nil(t, s.Patch(bytes.NewReader(basis), deltas, &out))
+	require.Equal(t, newData, out.Bytes())
+}
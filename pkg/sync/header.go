@@ -0,0 +1,144 @@
+package sync
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// deltaMagic starts delta files with header. Gob stream never starts with zero byte
+// (it is length of first message), so files without header are still recognized.
+var deltaMagic = []byte{0x00, 'R', 'H', 'D'}
+
+const deltaHeaderVersion = 1
+
+const deltaHeaderSize = 6
+
+// DeltaHeader describes how delta was written, deltas without header are not compressed
+type DeltaHeader struct {
+	Version byte
+	Codec   Codec
+}
+
+// NewDeltaHeader creates header of current version for deltas compressed with codec
+func NewDeltaHeader(codec Codec) DeltaHeader {
+	return DeltaHeader{
+		Version: deltaHeaderVersion,
+		Codec:   codec,
+	}
+}
+
+// WriteDeltaHeader writes header, it should be written before serialized deltas
+func WriteDeltaHeader(w io.Writer, header DeltaHeader) error {
+	data := append(append([]byte{}, deltaMagic...), header.Version, byte(header.Codec))
+	_, err := w.Write(data)
+	return err
+}
+
+// ReadDeltaHeader reads header if delta has one, returned reader continues with serialized deltas
+func ReadDeltaHeader(r io.Reader) (DeltaHeader, io.Reader, error) {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+
+	data, err := buffered.Peek(deltaHeaderSize)
+	if err != nil && err != io.EOF {
+		return DeltaHeader{}, buffered, err
+	}
+
+	if !bytes.HasPrefix(data, deltaMagic) {
+		return DeltaHeader{Version: 0, Codec: NoCompression}, buffered, nil
+	}
+
+	if len(data) < deltaHeaderSize {
+		return DeltaHeader{}, buffered, fmt.Errorf("delta header is truncated")
+	}
+
+	header := DeltaHeader{
+		Version: data[4],
+		Codec:   Codec(data[5]),
+	}
+
+	if header.Version != deltaHeaderVersion {
+		return header, buffered, fmt.Errorf("unsupported delta version %d", header.Version)
+	}
+
+	if _, ok := codecNames[header.Codec]; !ok {
+		return header, buffered, fmt.Errorf("unsupported delta compression %s", header.Codec)
+	}
+
+	buffered.Discard(deltaHeaderSize)
+	return header, buffered, nil
+}
+
+// signatureMagic starts signature files with header, like deltaMagic it cannot start gob stream
+var signatureMagic = []byte{0x00, 'R', 'H', 'S'}
+
+const signatureHeaderVersion = 1
+
+const signatureHeaderSize = 17
+
+// SignatureHeader describes file of which signature was calculated.
+// Signatures without header have version 0, their chunk size and file size are not known
+type SignatureHeader struct {
+	Version   byte
+	ChunkSize uint32
+	Size      int64
+}
+
+// NewSignatureHeader creates header of current version for signature of file of size bytes
+func NewSignatureHeader(chunkSize int, size int64) SignatureHeader {
+	return SignatureHeader{
+		Version:   signatureHeaderVersion,
+		ChunkSize: uint32(chunkSize),
+		Size:      size,
+	}
+}
+
+// WriteSignatureHeader writes header, it should be written before serialized chunks
+func WriteSignatureHeader(w io.Writer, header SignatureHeader) error {
+	data := make([]byte, signatureHeaderSize)
+	copy(data, signatureMagic)
+	data[4] = header.Version
+	binary.BigEndian.PutUint32(data[5:9], header.ChunkSize)
+	binary.BigEndian.PutUint64(data[9:17], uint64(header.Size))
+	_, err := w.Write(data)
+	return err
+}
+
+// ReadSignatureHeader reads header if signature has one, returned reader continues with serialized chunks
+func ReadSignatureHeader(r io.Reader) (SignatureHeader, io.Reader, error) {
+	buffered, ok := r.(*bufio.Reader)
+	if !ok {
+		buffered = bufio.NewReader(r)
+	}
+
+	data, err := buffered.Peek(signatureHeaderSize)
+	if err != nil && err != io.EOF {
+		return SignatureHeader{}, buffered, err
+	}
+
+	if !bytes.HasPrefix(data, signatureMagic) {
+		return SignatureHeader{}, buffered, nil
+	}
+
+	if len(data) < signatureHeaderSize {
+		return SignatureHeader{}, buffered, fmt.Errorf("signature header is truncated")
+	}
+
+	header := SignatureHeader{
+		Version:   data[4],
+		ChunkSize: binary.BigEndian.Uint32(data[5:9]),
+		Size:      int64(binary.BigEndian.Uint64(data[9:17])),
+	}
+
+	if header.Version != signatureHeaderVersion {
+		return header, buffered, fmt.Errorf("unsupported signature version %d", header.Version)
+	}
+
+	buffered.Discard(signatureHeaderSize)
+	return header, buffered, nil
+}
@@ -0,0 +1,30 @@
+package sync
+
+import "io"
+
+// MatchHandler receives id of chunk and offset in data at which it was found
+type MatchHandler func(chunkId uint32, offset int64)
+
+// Matches runs delta in reverse, chunks describe remote file and data is local one.
+// handleMatch is called for every place in data which contains one of chunks,
+// so receiver can find out which parts of remote file it already has.
+func (r *sync) Matches(data io.Reader, chunks []Chunk, handleMatch MatchHandler) error {
+	offset := int64(0)
+
+	return r.DeltaFromChunks(data, chunks, Checkpoint{}, func(d Delta) {
+		if d.Operation == TargetCopy {
+			_, length := CopyRangeOf(d)
+			offset += length
+			return
+		}
+
+		if d.Operation != ExistingData {
+			offset += int64(len(d.Data))
+			return
+		}
+
+		_, chunk := ExistingDataOf(d)
+		handleMatch(chunk, offset)
+		offset += int64(r.chunkSizeInBytes)
+	}, nil)
+}
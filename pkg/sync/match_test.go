@@ -0,0 +1,43 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MatchesReturnsOffsetsOfRemoteChunksInLocalData(t *testing.T) {
+	remote, _ := dataGenerateRandomWithSeed(64, 1)
+	prefix, _ := dataGenerateRandomWithSeed(5, 2)
+
+	// local data contains chunk 2, shifted by prefix, and chunk 0 after it
+	local := append(append(append([]byte{}, prefix...), remote[32:48]...), remote[0:16]...)
+
+	s := New()
+	chunks := []Chunk{}
+	s.Signature(bytes.NewReader(remote), func(c Chunk) {
+		chunks = append(chunks, c)
+	})
+
+	found := map[uint32]int64{}
+	err := s.Matches(bytes.NewReader(local), chunks, func(chunkId uint32, offset int64) {
+		found[chunkId] = offset
+	})
+
+	require.Nil(t, err)
+	require.Equal(t, map[uint32]int64{2: 5, 0: 21}, found)
+}
+
+func Test_ChunksWithTheSameRollingHashAreAllKept(t *testing.T) {
+	chunks := []Chunk{
+		{Id: 0, RollingHash: 1, StrongHash: []byte{1}},
+		{Id: 1, RollingHash: 1, StrongHash: []byte{2}},
+		{Id: 2, RollingHash: 2, StrongHash: []byte{3}},
+	}
+
+	mapped := chunksListToMap(chunks)
+
+	require.Equal(t, []Chunk{chunks[0], chunks[1]}, mapped[1])
+	require.Equal(t, []Chunk{chunks[2]}, mapped[2])
+}
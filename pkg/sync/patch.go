@@ -0,0 +1,216 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+)
+
+// Patch recreates new file from basis (old version of file) and deltas
+func (r *sync) Patch(basis io.ReadSeeker, deltas []Delta, out io.Writer) error {
+	return r.PatchFrom(basis, deltas, Checkpoint{}, out, nil)
+}
+
+// PatchFrom continues patching from checkpoint, out should already point to checkpoint.OutputOffset.
+// handleCheckpoint (if not nil) is called after each applied delta
+func (r *sync) PatchFrom(
+	basis io.ReadSeeker, deltas []Delta, from Checkpoint, out io.Writer, handleCheckpoint CheckpointHandler,
+) error {
+	patcher := r.NewPatcher(basis, out)
+	patcher.Written = from.OutputOffset
+	// data written before checkpoint is not known, so it cannot be source of TargetCopy
+	patcher.history.written = from.OutputOffset
+
+	for i := int(from.DeltaIndex); i < len(deltas); i++ {
+		if err := patcher.Apply(deltas[i]); err != nil {
+			return err
+		}
+
+		if handleCheckpoint != nil {
+			err := handleCheckpoint(Checkpoint{
+				DeltaIndex:   uint32(i + 1),
+				OutputOffset: patcher.Written,
+			})
+			if err != nil {
+				return fmt.Errorf("unable to handle checkpoint. %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// PatchFromBases recreates new file from multi-basis deltas, bases are basis files by their ids
+func (r *sync) PatchFromBases(bases map[uint32]io.ReadSeeker, deltas []Delta, out io.Writer) error {
+	patcher := r.NewPatcher(bases[0], out)
+	patcher.bases = bases
+
+	for _, d := range deltas {
+		if err := patcher.Apply(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Patcher applies deltas one by one, so patching can start before all deltas are known
+type Patcher struct {
+	chunkSizeInBytes int
+	basis            io.ReadSeeker
+	// bases are basis files of multi-basis deltas by their ids
+	bases map[uint32]io.ReadSeeker
+	out   io.Writer
+	// target is out without history, used to detect HoleWriter
+	target io.Writer
+	// history is dictionary for CompressedData, it is kept for all written data
+	history *history
+	// copies are basis ranges copied to output, they are recorded only when not nil
+	copies []copiedRange
+	// signer calculates signature of output, it is used only when not nil
+	signer *signatureDeriver
+	// Written is number of bytes written to out
+	Written int64
+}
+
+func (r *sync) NewPatcher(basis io.ReadSeeker, out io.Writer) *Patcher {
+	h := &history{}
+
+	return &Patcher{
+		chunkSizeInBytes: r.chunkSizeInBytes,
+		basis:            basis,
+		out:              io.MultiWriter(out, h),
+		target:           out,
+		history:          h,
+	}
+}
+
+func (p *Patcher) Apply(d Delta) error {
+	n, err := p.applyDelta(d)
+	p.Written += n
+	if err != nil {
+		return fmt.Errorf("unable to apply delta with id %d. %w", d.Id, err)
+	}
+
+	return nil
+}
+
+func (p *Patcher) applyDelta(d Delta) (int64, error) {
+	switch d.Operation {
+	case NewData:
+		n, err := p.out.Write(d.Data)
+		return int64(n), err
+	case ExistingData:
+		basisId, chunk := ExistingDataOf(d)
+		basis, err := p.basisFile(basisId)
+		if err != nil {
+			return 0, err
+		}
+
+		offset := int64(chunk) * int64(p.chunkSizeInBytes)
+		if _, err := basis.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		// copies from other bases cannot be described by offset in basis
+		if basisId == 0 {
+			p.signer.startCopy(offset)
+		}
+		n, err := io.CopyN(p.out, basis, int64(p.chunkSizeInBytes))
+		p.signer.endCopy()
+		// last chunk of basis can be shorter than chunk size
+		if err == io.EOF {
+			err = nil
+		}
+		if basisId == 0 {
+			p.recordCopy(offset, n)
+		}
+		return n, err
+	case Hole:
+		size := HoleSize(d)
+		if holeWriter, ok := p.target.(HoleWriter); ok {
+			if err := p.history.skip(size); err != nil {
+				return 0, err
+			}
+			if p.signer != nil {
+				if _, err := io.CopyN(p.signer, zeros{}, size); err != nil {
+					return 0, err
+				}
+			}
+			return size, holeWriter.WriteHole(size)
+		}
+
+		return io.CopyN(p.out, zeros{}, size)
+	case CopyRange:
+		basis, err := p.basisFile(0)
+		if err != nil {
+			return 0, err
+		}
+
+		offset, length := CopyRangeOf(d)
+		if _, err := basis.Seek(offset, io.SeekStart); err != nil {
+			return 0, err
+		}
+
+		p.signer.startCopy(offset)
+		n, err := io.CopyN(p.out, basis, length)
+		p.signer.endCopy()
+		if err == io.EOF {
+			err = nil
+		}
+		p.recordCopy(offset, n)
+		return n, err
+	case TargetCopy:
+		offset, length := CopyRangeOf(d)
+		data, err := p.history.at(offset, length)
+		if err != nil {
+			return 0, fmt.Errorf("unable to copy written data. %w", err)
+		}
+
+		n, err := p.out.Write(data)
+		return int64(n), err
+	case CompressedData:
+		data, err := decompress(d.Data, p.history.dictionary())
+		if err != nil {
+			return 0, fmt.Errorf("unable to decompress data. %w", err)
+		}
+
+		n, err := p.out.Write(data)
+		return int64(n), err
+	}
+
+	return 0, fmt.Errorf("unknown operation %d", d.Operation)
+}
+
+func (p *Patcher) basisFile(id uint32) (io.ReadSeeker, error) {
+	if id == 0 && p.basis != nil {
+		return p.basis, nil
+	}
+
+	if basis, ok := p.bases[id]; ok && basis != nil {
+		return basis, nil
+	}
+
+	return nil, fmt.Errorf("basis %d is not provided", id)
+}
+
+func (p *Patcher) recordCopy(basisOffset int64, length int64) {
+	if p.copies != nil && length > 0 {
+		p.copies = append(p.copies, copiedRange{basisOffset: basisOffset, outputOffset: p.Written, length: length})
+	}
+}
+
+type zeros struct{}
+
+func (zeros) Read(data []byte) (int, error) {
+	for i := range data {
+		data[i] = 0
+	}
+	return len(data), nil
+}
+
+func min64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,97 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_PatchRecreatesNewFile(t *testing.T) {
+	oldData, _ := dataGenerateRandom(500)
+	newPart, _ := dataGenerateRandomWithSeed(40, 300)
+
+	tests := []struct {
+		name    string
+		newData []byte
+	}{
+		{"same file", oldData},
+		{"prepended data", append(append([]byte{}, newPart...), oldData...)},
+		{"postfixed data", append(append([]byte{}, oldData...), newPart...)},
+		{"inserted data", append(append(append([]byte{}, oldData[:30]...), newPart...), oldData[30:]...)},
+		{"removed data", append(append([]byte{}, oldData[:100]...), oldData[170:]...)},
+		{"completely new file", newPart},
+		{"empty file", []byte{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := New()
+			deltas := deltasFor(t, &s, oldData, test.newData)
+
+			out := bytes.Buffer{}
+			err := s.Patch(bytes.NewReader(oldData), deltas, &out)
+
+			require.Nil(t, err)
+			require.Equal(t, test.newData, append([]byte{}, out.Bytes()...))
+		})
+	}
+}
+
+func Test_PatchFromContinuesFromCheckpoint(t *testing.T) {
+	oldData, _ := dataGenerateRandom(200)
+	newData := append(append([]byte{}, oldData[:50]...), oldData[60:]...)
+
+	s := New()
+	deltas := deltasFor(t, &s, oldData, newData)
+
+	checkpoints := []Checkpoint{}
+	full := bytes.Buffer{}
+	err := s.PatchFrom(bytes.NewReader(oldData), deltas, Checkpoint{}, &full, func(c Checkpoint) error {
+		checkpoints = append(checkpoints, c)
+		return nil
+	})
+	require.Nil(t, err)
+	require.Len(t, checkpoints, len(deltas))
+
+	from := checkpoints[len(checkpoints)/2]
+	resumed := bytes.NewBuffer(append([]byte{}, full.Bytes()[:from.OutputOffset]...))
+	err = s.PatchFrom(bytes.NewReader(oldData), deltas, from, resumed, nil)
+
+	require.Nil(t, err)
+	require.Equal(t, newData, resumed.Bytes())
+}
+
+func deltasFor(t *testing.T, s *sync, oldData []byte, newData []byte) []Delta {
+	chunks := []Chunk{}
+	err := s.Signature(bytes.NewReader(oldData), func(c Chunk) {
+		chunks = append(chunks, c)
+	})
+	require.Nil(t, err)
+
+	chunksAsBytes, err := SerializeChunks(chunks)
+	require.Nil(t, err)
+
+	deltas := []Delta{}
+	err = s.Delta(bytes.NewReader(newData), chunksAsBytes, func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	require.Nil(t, err)
+
+	return deltas
+}
+
+func Test_PatchWritesZerosForHoleWhenWriterCannotSkipThem(t *testing.T) {
+	s := New()
+	deltas := []Delta{
+		{Id: 0, Operation: NewData, Data: []byte{1, 2}},
+		HoleDelta(1, 5),
+		{Id: 2, Operation: NewData, Data: []byte{3}},
+	}
+
+	out := bytes.Buffer{}
+	err := s.Patch(bytes.NewReader([]byte{}), deltas, &out)
+
+	require.Nil(t, err)
+	require.Equal(t, []byte{1, 2, 0, 0, 0, 0, 0, 3}, out.Bytes())
+}
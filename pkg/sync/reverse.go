@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// maxReverseLiteral is maximal size of NewData in reverse delta
+const maxReverseLiteral = 32 * 1024
+
+// copiedRange is part of basis which was copied to output by patch
+type copiedRange struct {
+	basisOffset  int64
+	outputOffset int64
+	length       int64
+}
+
+// PatchWithReverse works like Patch, but afterwards it passes to handleReverse delta which turns new file back into basis.
+// Parts of basis which were copied to new file are copied from new file, the rest (overwritten data) are literals.
+func (r *sync) PatchWithReverse(basis io.ReadSeeker, deltas []Delta, out io.Writer, handleReverse DeltaHandler) error {
+	patcher := r.NewPatcher(basis, out)
+	patcher.copies = []copiedRange{}
+
+	for _, d := range deltas {
+		if err := patcher.Apply(d); err != nil {
+			return err
+		}
+	}
+
+	size, err := basis.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("unable to read basis size. %w", err)
+	}
+
+	return reverseDelta(basis, size, patcher.copies, handleReverse)
+}
+
+// reverseDelta covers basis with copied ranges, greedily taking range which reaches furthest
+func reverseDelta(basis io.ReadSeeker, size int64, copies []copiedRange, handleDeltas DeltaHandler) error {
+	sort.Slice(copies, func(i, j int) bool {
+		return copies[i].basisOffset < copies[j].basisOffset
+	})
+
+	emitter := &reverseEmitter{handleDeltas: handleDeltas}
+	i := 0
+	var position int64
+	for position < size {
+		best := copiedRange{}
+		bestEnd := position
+		for ; i < len(copies) && copies[i].basisOffset <= position; i++ {
+			if end := copies[i].basisOffset + copies[i].length; end > bestEnd {
+				best = copies[i]
+				bestEnd = end
+			}
+		}
+
+		if bestEnd > position {
+			emitter.copyRange(best.outputOffset+position-best.basisOffset, bestEnd-position)
+			position = bestEnd
+			continue
+		}
+
+		literalEnd := size
+		if i < len(copies) {
+			literalEnd = copies[i].basisOffset
+		}
+		if literalEnd-position > maxReverseLiteral {
+			literalEnd = position + maxReverseLiteral
+		}
+
+		data := make([]byte, literalEnd-position)
+		if _, err := basis.Seek(position, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.ReadFull(basis, data); err != nil {
+			return fmt.Errorf("unable to read basis at %d. %w", position, err)
+		}
+
+		emitter.literal(data)
+		position = literalEnd
+	}
+
+	emitter.flush()
+	return nil
+}
+
+// reverseEmitter joins neighbouring copies of reverse delta
+type reverseEmitter struct {
+	handleDeltas DeltaHandler
+	id           uint32
+	copyOffset   int64
+	copyLength   int64
+}
+
+func (e *reverseEmitter) copyRange(offset int64, length int64) {
+	if e.copyLength > 0 && e.copyOffset+e.copyLength == offset {
+		e.copyLength += length
+		return
+	}
+
+	e.flush()
+	e.copyOffset, e.copyLength = offset, length
+}
+
+func (e *reverseEmitter) literal(data []byte) {
+	e.flush()
+	e.emit(Delta{Operation: NewData, Data: data})
+}
+
+func (e *reverseEmitter) flush() {
+	if e.copyLength == 0 {
+		return
+	}
+
+	if e.copyOffset%defaultChunkSize == 0 && e.copyLength == defaultChunkSize {
+		e.emit(Delta{Operation: ExistingData, Data: uint32ToBytes(uint32(e.copyOffset / defaultChunkSize))})
+	} else {
+		e.emit(CopyRangeDelta(0, e.copyOffset, e.copyLength))
+	}
+	e.copyLength = 0
+}
+
+func (e *reverseEmitter) emit(d Delta) {
+	d.Id = e.id
+	e.id++
+	e.handleDeltas(d)
+}
@@ -0,0 +1,60 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ReverseDeltaRecreatesBasisFromNewFile(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		basis := randomBytes(random, random.Intn(3000))
+		newData := randomEdits(random, basis)
+
+		s := New()
+		deltas := deltasFor(t, &s, basis, newData)
+
+		reverse := []Delta{}
+		out := bytes.Buffer{}
+		err := s.PatchWithReverse(bytes.NewReader(basis), deltas, &out, func(d Delta) {
+			reverse = append(reverse, d)
+		})
+		require.Nil(t, err)
+		require.Equal(t, newData, append([]byte{}, out.Bytes()...), "seed %d", seed)
+
+		for i, d := range reverse {
+			require.Equal(t, uint32(i), d.Id)
+		}
+
+		restored := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader(newData), reverse, &restored))
+		require.Equal(t, basis, append([]byte{}, restored.Bytes()...), "seed %d", seed)
+	}
+}
+
+func Test_ReverseDeltaCopiesMovedDataAndKeepsRemovedData(t *testing.T) {
+	basis := []byte("0123456789abcdef0123456789ABCDEFremoved")
+	deltas := []Delta{
+		{Operation: ExistingData, Data: uint32ToBytes(1)},
+		{Operation: NewData, Data: []byte("new")},
+		CopyRangeDelta(0, 0, 16),
+	}
+
+	s := New()
+	reverse := []Delta{}
+	out := bytes.Buffer{}
+	err := s.PatchWithReverse(bytes.NewReader(basis), deltas, &out, func(d Delta) {
+		reverse = append(reverse, d)
+	})
+	require.Nil(t, err)
+	require.Equal(t, "0123456789ABCDEFnew0123456789abcdef", out.String())
+
+	require.Equal(t, []Delta{
+		CopyRangeDelta(0, 19, 16),
+		{Id: 1, Operation: ExistingData, Data: uint32ToBytes(0)},
+		{Id: 2, Operation: NewData, Data: []byte("removed")},
+	}, reverse)
+}
@@ -13,8 +13,10 @@ const byteBase = 16 * 16
 func DeserializeChunks(chunksReader io.Reader) ([]Chunk, error) {
 	chunks := []Chunk{}
 
-	enc := gob.NewDecoder(chunksReader)
-	err := enc.Decode(&chunks)
+	err := NewStreamDecoder[Chunk](chunksReader).Decode(func(c Chunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
 	if err != nil {
 		return chunks, err
 	}
@@ -34,12 +36,13 @@ func SerializeChunks(chunks []Chunk) (io.Reader, error) {
 	return &buffer, nil
 }
 
-// could be generics
 func DeserializeDelta(deltasReader io.Reader) ([]Delta, error) {
 	deltas := []Delta{}
 
-	enc := gob.NewDecoder(deltasReader)
-	err := enc.Decode(&deltas)
+	err := NewStreamDecoder[Delta](deltasReader).Decode(func(d Delta) error {
+		deltas = append(deltas, d)
+		return nil
+	})
 	if err != nil {
 		return deltas, err
 	}
@@ -59,6 +62,26 @@ func SerializeDeltas(deltas []Delta) (io.Reader, error) {
 	return &buffer, nil
 }
 
+// ExistingDataDelta creates delta copying chunk of basis, chunks of first basis (0) are described only by chunk id,
+// so single basis deltas are not changed
+func ExistingDataDelta(id uint32, basis uint32, chunk uint32) Delta {
+	data := uint32ToBytes(chunk)
+	if basis != 0 {
+		data = append(uint32ToBytes(basis), data...)
+	}
+
+	return Delta{Id: id, Operation: ExistingData, Data: data}
+}
+
+// ExistingDataOf returns basis and chunk id of ExistingData delta
+func ExistingDataOf(d Delta) (uint32, uint32) {
+	if len(d.Data) == 8 {
+		return bytesToUint32(d.Data[:4]), bytesToUint32(d.Data[4:])
+	}
+
+	return 0, bytesToUint32(d.Data)
+}
+
 func uint32ToBytes(val uint32) []byte {
 	data := [4]byte{}
 
@@ -82,3 +105,58 @@ func bytesToUint32(vals []byte) uint32 {
 
 	return result
 }
+
+// SerializeDeltasBatch prefixes serialized deltas with their length,
+// so batches can be appended one after another to single file
+func SerializeDeltasBatch(deltas []Delta) ([]byte, error) {
+	serialized, err := SerializeDeltas(deltas)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(uint32ToBytes(uint32(len(data))), data...), nil
+}
+
+func DeserializeDeltasBatches(batchesReader io.Reader) ([]Delta, error) {
+	deltas := []Delta{}
+
+	err := DecodeDeltasBatches(batchesReader, func(d Delta) error {
+		deltas = append(deltas, d)
+		return nil
+	})
+
+	return deltas, err
+}
+
+// DecodeDeltasBatches calls handle for every delta of batches written with SerializeDeltasBatch,
+// only one batch is kept in memory at once
+func DecodeDeltasBatches(batchesReader io.Reader, handle func(Delta) error) error {
+	sizeBytes := make([]byte, 4)
+
+	for {
+		_, err := io.ReadFull(batchesReader, sizeBytes)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		batch, err := DeserializeDelta(io.LimitReader(batchesReader, int64(bytesToUint32(sizeBytes))))
+		if err != nil {
+			return err
+		}
+
+		for _, d := range batch {
+			if err := handle(d); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package sync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Range is part of file
+type Range struct {
+	Offset int64
+	Size   int64
+}
+
+// HoleWriter is implemented by writers which can skip range of zeros instead of writing it
+type HoleWriter interface {
+	WriteHole(size int64) error
+}
+
+// SparseDelta works like Delta but for files with holes,
+// holes are sent as Hole operations and only data around them is compared with chunks
+func (r *sync) SparseDelta(file *os.File, chunks []Chunk, handleDeltas DeltaHandler) error {
+	holes, err := Holes(file)
+	if err != nil {
+		return fmt.Errorf("unable to find holes in file. %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	from := Checkpoint{}
+	handleData := func(d Delta) {
+		from.DeltaIndex = d.Id + 1
+		handleDeltas(d)
+	}
+
+	offset := int64(0)
+	for _, hole := range append(holes, Range{Offset: size}) {
+		if hole.Offset > offset {
+			data := io.NewSectionReader(file, offset, hole.Offset-offset)
+			if err := r.DeltaFromChunks(data, chunks, from, handleData, nil); err != nil {
+				return err
+			}
+		}
+
+		if hole.Size > 0 {
+			handleData(HoleDelta(from.DeltaIndex, hole.Size))
+		}
+		offset = hole.Offset + hole.Size
+	}
+
+	return nil
+}
+
+func HoleDelta(id uint32, size int64) Delta {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, uint64(size))
+
+	return Delta{
+		Id:        id,
+		Operation: Hole,
+		Data:      data,
+	}
+}
+
+// HoleSize returns size of range of zeros described by Hole delta
+func HoleSize(d Delta) int64 {
+	return int64(binary.BigEndian.Uint64(d.Data))
+}
+
+// SparseFile buffers writes to file, holes are punched in file instead of writing zeros
+type SparseFile struct {
+	file   *os.File
+	writer *bufio.Writer
+	offset int64
+}
+
+// NewSparseFile creates writer which starts writing at current offset of file
+func NewSparseFile(file *os.File) (*SparseFile, error) {
+	offset, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SparseFile{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		offset: offset,
+	}, nil
+}
+
+func (s *SparseFile) Write(data []byte) (int, error) {
+	n, err := s.writer.Write(data)
+	s.offset += int64(n)
+	return n, err
+}
+
+func (s *SparseFile) WriteHole(size int64) error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := punchHole(s.file, s.offset, size); err != nil {
+		return fmt.Errorf("unable to punch hole. %w", err)
+	}
+
+	s.offset += size
+	_, err := s.file.Seek(s.offset, io.SeekStart)
+	return err
+}
+
+// Flush writes buffered data and extends file when it ends with hole
+func (s *SparseFile) Flush() error {
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+
+	stat, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if stat.Size() < s.offset {
+		return s.file.Truncate(s.offset)
+	}
+
+	return nil
+}
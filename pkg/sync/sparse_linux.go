@@ -0,0 +1,56 @@
+package sync
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Holes returns ranges of file which are not allocated on disk, file is rewound afterwards
+func Holes(file *os.File) ([]Range, error) {
+	holes := []Range{}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := int64(0)
+	for offset < size {
+		dataStart, err := file.Seek(offset, unix.SEEK_DATA)
+		if errors.Is(err, unix.ENXIO) {
+			// there is no more data, rest of file is hole
+			holes = append(holes, Range{Offset: offset, Size: size - offset})
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if dataStart > offset {
+			holes = append(holes, Range{Offset: offset, Size: dataStart - offset})
+		}
+
+		offset, err = file.Seek(dataStart, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = file.Seek(0, io.SeekStart)
+	return holes, err
+}
+
+// punchHole deallocates range of file, it is fine if file system does not support it
+// as range will be skipped by seek anyway
+func punchHole(file *os.File, offset int64, size int64) error {
+	err := unix.Fallocate(int(file.Fd()), unix.FALLOC_FL_PUNCH_HOLE|unix.FALLOC_FL_KEEP_SIZE, offset, size)
+	if errors.Is(err, unix.EOPNOTSUPP) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,86 @@
+package sync
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const holeSizeInTest = 64 * 1024
+
+func Test_HolesFindsUnallocatedRanges(t *testing.T) {
+	data, _ := dataGenerateRandom(100)
+	file := sparseFile(t, data)
+
+	holes, err := Holes(file)
+	require.Nil(t, err)
+
+	require.Equal(t, []Range{
+		{Offset: 0, Size: holeSizeInTest},
+		{Offset: holeSizeInTest + 4096, Size: holeSizeInTest - 4096},
+	}, holes)
+}
+
+func Test_SparseDeltaSendsHolesAndPatchPunchesThem(t *testing.T) {
+	data, _ := dataGenerateRandom(100)
+	file := sparseFile(t, data)
+
+	content, err := os.ReadFile(file.Name())
+	require.Nil(t, err)
+
+	s := New()
+	chunks := []Chunk{}
+	s.Signature(bytes.NewReader(data), func(c Chunk) {
+		chunks = append(chunks, c)
+	})
+
+	deltas := []Delta{}
+	err = s.SparseDelta(file, chunks, func(d Delta) {
+		deltas = append(deltas, d)
+	})
+	require.Nil(t, err)
+
+	holes := 0
+	for i, d := range deltas {
+		require.Equal(t, uint32(i), d.Id)
+		if d.Operation == Hole {
+			holes++
+		}
+	}
+	require.Equal(t, 2, holes)
+
+	out, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	require.Nil(t, err)
+	defer out.Close()
+
+	writer, err := NewSparseFile(out)
+	require.Nil(t, err)
+	require.Nil(t, s.Patch(bytes.NewReader(data), deltas, writer))
+	require.Nil(t, writer.Flush())
+
+	patched, err := os.ReadFile(out.Name())
+	require.Nil(t, err)
+	require.Equal(t, content, patched)
+
+	stat, err := out.Stat()
+	require.Nil(t, err)
+	allocated := stat.Sys().(*syscall.Stat_t).Blocks * 512
+	require.Less(t, allocated, int64(len(content)))
+}
+
+// sparseFile creates file with hole, then data and another hole at the end
+func sparseFile(t *testing.T, data []byte) *os.File {
+	file, err := os.Create(filepath.Join(t.TempDir(), "sparse"))
+	require.Nil(t, err)
+	t.Cleanup(func() { file.Close() })
+
+	_, err = file.WriteAt(data, holeSizeInTest)
+	require.Nil(t, err)
+	require.Nil(t, file.Truncate(2*holeSizeInTest))
+
+	return file
+}
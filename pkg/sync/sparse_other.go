@@ -0,0 +1,17 @@
+//go:build !linux
+
+package sync
+
+import (
+	"os"
+)
+
+// Holes is not supported on this platform, so whole file is treated as data
+func Holes(file *os.File) ([]Range, error) {
+	return []Range{}, nil
+}
+
+// punchHole relies on seek, which leaves unwritten range as hole on file systems supporting it
+func punchHole(file *os.File, offset int64, size int64) error {
+	return nil
+}
@@ -0,0 +1,100 @@
+package sync
+
+import (
+	"encoding/gob"
+	"io"
+)
+
+// how many values are encoded together by StreamEncoder
+const streamBatchSize = 1024
+
+// StreamEncoder writes values in batches to single gob stream, so they can be sent before all of them are calculated.
+// Stream can be read with DeserializeChunks, DeserializeDelta or StreamDecoder.
+type StreamEncoder[T Chunk | Delta] struct {
+	enc     *gob.Encoder
+	batch   []T
+	written bool
+}
+
+func NewStreamEncoder[T Chunk | Delta](w io.Writer) *StreamEncoder[T] {
+	return &StreamEncoder[T]{
+		enc:   gob.NewEncoder(w),
+		batch: []T{},
+	}
+}
+
+func (e *StreamEncoder[T]) Encode(value T) error {
+	e.batch = append(e.batch, value)
+	if len(e.batch) < streamBatchSize {
+		return nil
+	}
+
+	return e.Flush()
+}
+
+// Flush writes buffered values, it has to be called after last value
+func (e *StreamEncoder[T]) Flush() error {
+	// stream should contain at least one batch, even if it is empty
+	if len(e.batch) == 0 && e.written {
+		return nil
+	}
+
+	if err := e.enc.Encode(e.batch); err != nil {
+		return err
+	}
+
+	e.written = true
+	e.batch = e.batch[:0]
+	return nil
+}
+
+// StreamDecoder reads values written by StreamEncoder or serialized with SerializeChunks and SerializeDeltas,
+// delta header (if present) is skipped
+type StreamDecoder[T Chunk | Delta] struct {
+	r   io.Reader
+	dec *gob.Decoder
+}
+
+func NewStreamDecoder[T Chunk | Delta](r io.Reader) *StreamDecoder[T] {
+	return &StreamDecoder[T]{
+		r: r,
+	}
+}
+
+// Decode calls handle for every value until end of stream
+func (d *StreamDecoder[T]) Decode(handle func(T) error) error {
+	if d.dec == nil {
+		var r io.Reader
+		var err error
+		switch any(*new(T)).(type) {
+		case Chunk:
+			_, r, err = ReadSignatureHeader(d.r)
+		default:
+			_, r, err = ReadDeltaHeader(d.r)
+		}
+		if err != nil {
+			return err
+		}
+		d.dec = gob.NewDecoder(r)
+	}
+
+	first := true
+	for {
+		batch := []T{}
+		err := d.dec.Decode(&batch)
+		if err == io.EOF && !first {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+		first = false
+
+		for _, value := range batch {
+			if err := handle(value); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package sync
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_StreamEncoderOutputCanBeDeserialized(t *testing.T) {
+	deltas := []Delta{}
+	for i := 0; i < 2*streamBatchSize+10; i++ {
+		deltas = append(deltas, Delta{Id: uint32(i), Operation: NewData, Data: []byte{byte(i)}})
+	}
+
+	buffer := bytes.Buffer{}
+	enc := NewStreamEncoder[Delta](&buffer)
+	for _, d := range deltas {
+		require.Nil(t, enc.Encode(d))
+	}
+	require.Nil(t, enc.Flush())
+
+	readDeltas, err := DeserializeDelta(&buffer)
+	require.Nil(t, err)
+	require.Equal(t, deltas, readDeltas)
+}
+
+func Test_StreamDecoderReadsSerializedChunks(t *testing.T) {
+	chunks := []Chunk{
+		{Id: 0, RollingHash: 1, StrongHash: []byte{1}},
+		{Id: 1, RollingHash: 2, StrongHash: []byte{2}},
+	}
+
+	serialized, err := SerializeChunks(chunks)
+	require.Nil(t, err)
+
+	readChunks := []Chunk{}
+	err = NewStreamDecoder[Chunk](serialized).Decode(func(c Chunk) error {
+		readChunks = append(readChunks, c)
+		return nil
+	})
+	require.Nil(t, err)
+	require.Equal(t, chunks, readChunks)
+}
+
+func Test_EmptyStreamCanBeDeserialized(t *testing.T) {
+	buffer := bytes.Buffer{}
+	require.Nil(t, NewStreamEncoder[Chunk](&buffer).Flush())
+
+	chunks, err := DeserializeChunks(&buffer)
+	require.Nil(t, err)
+	require.Empty(t, chunks)
+
+	_, err = DeserializeChunks(&bytes.Buffer{})
+	require.NotNil(t, err)
+}
+
+func Test_DeserializeChunksReadsAllBatchesOfStream(t *testing.T) {
+	chunks := []Chunk{}
+	for i := 0; i < streamBatchSize+1; i++ {
+		chunks = append(chunks, Chunk{Id: uint32(i), RollingHash: uint32(i), StrongHash: []byte{byte(i)}})
+	}
+
+	buffer := bytes.Buffer{}
+	enc := NewStreamEncoder[Chunk](&buffer)
+	for _, c := range chunks {
+		require.Nil(t, enc.Encode(c))
+	}
+	require.Nil(t, enc.Flush())
+
+	// single gob decode would return only first batch
+	readChunks, err := DeserializeChunks(&buffer)
+	require.Nil(t, err)
+	require.Equal(t, chunks, readChunks)
+}
@@ -18,7 +18,9 @@ const defaultBufferMultiplier = 3
 
 type sync struct {
 	chunkSizeInBytes int
-	hasher           hash.Hash
+	// matchTarget enables TargetCopy deltas, see SetTargetMatching
+	matchTarget bool
+	hasher      hash.Hash
 	// instead of relaying on struct we should expect interface as rollingHash
 	// so in future we could easily replace implementation
 	rHash *rollinghash.RollingHash
@@ -32,13 +34,43 @@ type Chunk struct {
 
 type ChunkHandler func(Chunk)
 
+// ChunkDataHandler receives chunk together with its data, data is valid only during call
+type ChunkDataHandler func(Chunk, []byte)
+
 type Operation byte
 
 const (
 	NewData Operation = iota
+	// ExistingData copies chunk of basis, Data contains chunk id or basis id and chunk id for multi-basis delta
 	ExistingData
+	// Hole is range of zeros which is not stored on disk, Data contains its size
+	Hole
+	// CompressedData is compressed literal, first byte of Data is Codec
+	CompressedData
+	// CopyRange copies bytes from any offset of basis, Data contains offset and length.
+	// Like ExistingData it stops at the end of basis
+	CopyRange
+	// TargetCopy copies bytes which were already written to new file, Data contains offset in new file and length like CopyRange
+	TargetCopy
 )
 
+var operationNames = map[Operation]string{
+	NewData:        "new",
+	ExistingData:   "existing",
+	Hole:           "hole",
+	CompressedData: "compressed",
+	CopyRange:      "range",
+	TargetCopy:     "target",
+}
+
+func (o Operation) String() string {
+	if name, ok := operationNames[o]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(%d)", byte(o))
+}
+
 type Delta struct {
 	Id        uint32
 	Operation Operation
@@ -47,15 +79,40 @@ type Delta struct {
 
 type DeltaHandler func(Delta)
 
+// DefaultChunkSize returns size of data described by single chunk of signature created by New
+func DefaultChunkSize() int {
+	return defaultChunkSize
+}
+
 func New() sync {
+	return NewWithChunkSize(defaultChunkSize)
+}
+
+// NewWithChunkSize creates sync with custom chunk size, signatures and deltas
+// of different chunk sizes are not compatible. Deltas and patches of files use defaultChunkSize
+func NewWithChunkSize(chunkSize int) sync {
 	return sync{
-		chunkSizeInBytes: defaultChunkSize,
+		chunkSizeInBytes: chunkSize,
 		hasher:           crypto.MD4.New(),
-		rHash:            rollinghash.New(uint32(defaultChunkSize)),
+		rHash:            rollinghash.New(uint32(chunkSize)),
 	}
 }
 
+// SetTargetMatching makes delta look for chunks also in already processed part of new file,
+// repeated data is then copied from earlier part of new file with TargetCopy instead of being sent again.
+// Only data within last targetWindowSize bytes is matched, as patch has to keep it in memory
+func (r *sync) SetTargetMatching(enabled bool) {
+	r.matchTarget = enabled
+}
+
 func (r *sync) Signature(data io.Reader, handleChunks ChunkHandler) error {
+	return r.SignatureWithData(data, func(c Chunk, _ []byte) {
+		handleChunks(c)
+	})
+}
+
+// SignatureWithData works like Signature, but passes data of every chunk to handler
+func (r *sync) SignatureWithData(data io.Reader, handleChunks ChunkDataHandler) error {
 	// we will read more bytes
 	// but hashing will take into consideration only r.chunkSizeInBytes
 	fullBufferSize := defaultBufferMultiplier * r.chunkSizeInBytes
@@ -68,14 +125,17 @@ func (r *sync) Signature(data io.Reader, handleChunks ChunkHandler) error {
 	total := 0
 	for {
 		n, err := data.Read(buffer[bytesLeft:])
+		if err != nil && err != io.EOF {
+			return err
+		}
 
-		if n == 0 || err == io.EOF {
+		if n == 0 && err == io.EOF {
 			break
 		}
 
-		if err != nil {
-			return err
-		}
+		// leftover from previous read is processed together with new bytes,
+		// so chunks do not depend on how reader splits data
+		n = bytesLeft + n
 
 		// iterate until we run out of data
 		i := 0
@@ -101,7 +161,7 @@ func (r *sync) Signature(data io.Reader, handleChunks ChunkHandler) error {
 	return nil
 }
 
-func (r *sync) processChunk(chunkIndex uint32, rollingChunk []byte, handleChunks ChunkHandler) {
+func (r *sync) processChunk(chunkIndex uint32, rollingChunk []byte, handleChunks ChunkDataHandler) {
 	rHash := r.rHash.AddBuffer(rollingChunk).Hash()
 
 	r.hasher.Write(rollingChunk)
@@ -109,30 +169,58 @@ func (r *sync) processChunk(chunkIndex uint32, rollingChunk []byte, handleChunks
 		Id:          chunkIndex,
 		RollingHash: rHash,
 		StrongHash:  r.hasher.Sum(nil),
-	})
+	}, rollingChunk)
 	r.hasher.Reset()
 }
 
 func (r *sync) Delta(data io.Reader, chunksReader io.Reader, handleDeltas DeltaHandler) error {
+	return r.DeltaFrom(data, chunksReader, Checkpoint{}, handleDeltas, nil)
+}
+
+// DeltaFrom continues delta calculation from checkpoint,
+// data reader should already point to checkpoint.InputOffset.
+// handleCheckpoint (if not nil) is called after each processed part of data
+func (r *sync) DeltaFrom(
+	data io.Reader, chunksReader io.Reader, from Checkpoint, handleDeltas DeltaHandler, handleCheckpoint CheckpointHandler,
+) error {
 	chunksList, err := DeserializeChunks(chunksReader)
 	if err != nil {
 		return fmt.Errorf("unable to deserialize signature file. %w", err)
 	}
+
+	return r.DeltaFromChunks(data, chunksList, from, handleDeltas, handleCheckpoint)
+}
+
+// DeltaFromChunks works like DeltaFrom, but with already deserialized signature
+func (r *sync) DeltaFromChunks(
+	data io.Reader, chunksList []Chunk, from Checkpoint, handleDeltas DeltaHandler, handleCheckpoint CheckpointHandler,
+) error {
 	chunks := chunksListToMap(chunksList)
 
 	fullBufferSize := defaultBufferMultiplier * r.chunkSizeInBytes
 	buffer := make([]byte, fullBufferSize)
 	r.hasher.Reset()
-	r.rHash.Reset()
+	r.rHash.Restore(from.RollingHash)
 
 	// we read in chunks, it maybe that we cannot proce
 	bytesLeft := 0
 
-	var deltaIndex uint32 = 0
+	deltaIndex := from.DeltaIndex
+	inputOffset := from.InputOffset
+
+	var target *targetIndex
+	if r.matchTarget {
+		target = newTargetIndex(r.chunkSizeInBytes, inputOffset)
+	}
 
-	firstIter := true
+	firstIter := from.RollingHash.AddOperationsCount == 0
 	for {
-		n, err := data.Read(buffer[bytesLeft:])
+		// short read is not the end of data, buffer is filled so deltas do not depend on how reader splits data
+		n, err := io.ReadFull(data, buffer[bytesLeft:])
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+
 		if err != io.EOF && err != nil {
 			return err
 		}
@@ -164,24 +252,40 @@ func (r *sync) Delta(data io.Reader, chunksReader io.Reader, handleDeltas DeltaH
 				buffer = buffer[len(buffer)-n:]
 			}
 
-			existingDataFound := r.processBytesForDelta(chunks, deltaIndex, buffer, handleDeltas)
+			existingDataFound := r.processBytesForDelta(chunks, target, deltaIndex, buffer, handleDeltas)
 
+			advance := 1
 			if existingDataFound {
-				i += chunkSize
-			} else {
-				i += 1
+				advance = chunkSize
+			}
+
+			if target != nil {
+				target.add(buffer[:advance])
 			}
+			i += advance
 
 			deltaIndex += 1
 		}
 
 		bytesLeft = n - i
+		inputOffset += int64(i)
 
 		// reading data, this is last iteration and there is leftover
 		for j := 0; j < bytesLeft; j++ {
 			buffer[j] = buffer[i+j]
 		}
 
+		if handleCheckpoint != nil && i > 0 {
+			err := handleCheckpoint(Checkpoint{
+				InputOffset: inputOffset,
+				DeltaIndex:  deltaIndex,
+				RollingHash: r.rHash.State(),
+			})
+			if err != nil {
+				return fmt.Errorf("unable to handle checkpoint. %w", err)
+			}
+		}
+
 		if n == 0 || err == io.EOF {
 			if bytesLeft == 0 {
 				return nil
@@ -191,23 +295,56 @@ func (r *sync) Delta(data io.Reader, chunksReader io.Reader, handleDeltas DeltaH
 
 }
 
+// BasisSignature is signature of one of basis files of multi-basis delta
+type BasisSignature struct {
+	Basis  uint32
+	Chunks []Chunk
+}
+
+// DeltaFromBases calculates delta which copies data from several basis files,
+// chunks are looked up in all signatures and ExistingData deltas name basis from which chunk is copied.
+// When the same data is in many bases the earliest of them is used
+func (r *sync) DeltaFromBases(data io.Reader, bases []BasisSignature, handleDeltas DeltaHandler) error {
+	// chunks of all bases are renumbered for combined index, so matches have to be translated back
+	type basisChunk struct {
+		basis uint32
+		chunk uint32
+	}
+
+	chunks := []Chunk{}
+	origins := []basisChunk{}
+	for _, basis := range bases {
+		for _, c := range basis.Chunks {
+			origins = append(origins, basisChunk{basis: basis.Basis, chunk: c.Id})
+			c.Id = uint32(len(chunks))
+			chunks = append(chunks, c)
+		}
+	}
+
+	return r.DeltaFromChunks(data, chunks, Checkpoint{}, func(d Delta) {
+		if d.Operation == ExistingData {
+			_, id := ExistingDataOf(d)
+			d = ExistingDataDelta(d.Id, origins[id].basis, origins[id].chunk)
+		}
+		handleDeltas(d)
+	}, nil)
+}
+
 func chunksListToMap(chunks []Chunk) map[uint32][]Chunk {
 	mappedChunks := map[uint32][]Chunk{}
 
+	// chunks with the same rolling hash are kept together, strong hash decides which one matches
 	for _, chunk := range chunks {
-		listOfChunks, ok := mappedChunks[chunk.RollingHash]
-		if !ok {
-			mappedChunks[chunk.RollingHash] = append(listOfChunks, chunk)
-		} else {
-			mappedChunks[chunk.RollingHash] = []Chunk{chunk}
-		}
+		mappedChunks[chunk.RollingHash] = append(mappedChunks[chunk.RollingHash], chunk)
 	}
 
 	return mappedChunks
 }
 
+// processBytesForDelta emits delta for buffer, it returns true when whole buffer was matched.
+// target (when not nil) is searched when basis does not contain buffer
 func (r *sync) processBytesForDelta(
-	chunks map[uint32][]Chunk, deltaIndex uint32, buffer []byte, handleDeltas DeltaHandler,
+	chunks map[uint32][]Chunk, target *targetIndex, deltaIndex uint32, buffer []byte, handleDeltas DeltaHandler,
 ) bool {
 	fromChunks, ok := chunks[r.rHash.Hash()]
 
@@ -219,16 +356,19 @@ func (r *sync) processBytesForDelta(
 		for _, chunk := range fromChunks {
 			// if strong hash match then send that original file contains data
 			if bytes.Equal(strongHash, chunk.StrongHash) {
-				handleDeltas(Delta{
-					Id:        deltaIndex,
-					Operation: ExistingData,
-					Data:      uint32ToBytes(chunk.Id),
-				})
+				handleDeltas(ExistingDataDelta(deltaIndex, 0, chunk.Id))
 				return true
 			}
 		}
 	}
 
+	if target != nil && len(buffer) == r.chunkSizeInBytes {
+		if offset, ok := target.find(r.rHash.Hash(), buffer); ok {
+			handleDeltas(TargetCopyDelta(deltaIndex, offset, int64(len(buffer))))
+			return true
+		}
+	}
+
 	// if no match then send new bytes to be added to file
 	// to we send one byte, but we should 'collect' them
 	// and send only when Operation type changes (from NewData to ExistingData)
@@ -6,6 +6,7 @@ import (
 	"math"
 	"math/rand"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/require"
 )
@@ -71,6 +72,59 @@ func Test_Signature(t *testing.T) {
 	}
 }
 
+func Test_SignatureDoesNotDependOnReadSizes(t *testing.T) {
+	data, _ := dataGenerateRandom(1000)
+
+	signature := func(reader io.Reader) []Chunk {
+		s := New()
+		chunks := []Chunk{}
+		err := s.Signature(reader, func(c Chunk) {
+			chunks = append(chunks, c)
+		})
+		require.Nil(t, err)
+		return chunks
+	}
+
+	expected := signature(bytes.NewReader(data))
+	require.Len(t, expected, 63)
+	require.Equal(t, expected, signature(iotest.OneByteReader(bytes.NewReader(data))))
+	require.Equal(t, expected, signature(iotest.HalfReader(bytes.NewReader(data))))
+	require.Equal(t, expected, signature(iotest.DataErrReader(bytes.NewReader(data))))
+}
+
+func Test_DeltaDoesNotDependOnReadSizes(t *testing.T) {
+	oldData, _ := dataGenerateRandom(1000)
+	newData := append(append([]byte{}, oldData[:500]...), append([]byte("inserted"), oldData[500:]...)...)
+
+	s := New()
+	chunks := []Chunk{}
+	require.Nil(t, s.Signature(bytes.NewReader(oldData), func(c Chunk) {
+		chunks = append(chunks, c)
+	}))
+
+	delta := func(reader io.Reader) []Delta {
+		deltas := []Delta{}
+		err := s.DeltaFromChunks(reader, chunks, Checkpoint{}, func(d Delta) {
+			deltas = append(deltas, d)
+		}, nil)
+		require.Nil(t, err)
+		return deltas
+	}
+
+	expected := delta(bytes.NewReader(newData))
+	literals := 0
+	for _, d := range expected {
+		if d.Operation == NewData {
+			literals += len(d.Data)
+		}
+	}
+	require.Less(t, literals, 30)
+
+	require.Equal(t, expected, delta(iotest.OneByteReader(bytes.NewReader(newData))))
+	require.Equal(t, expected, delta(iotest.HalfReader(bytes.NewReader(newData))))
+	require.Equal(t, expected, delta(iotest.DataErrReader(bytes.NewReader(newData))))
+}
+
 func Test_DeltaReturnsNoChangesWhenNewFileIsTheSameAsOld(t *testing.T) {
 	// dataSize := 20002
 	dataSize := 50
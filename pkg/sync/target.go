@@ -0,0 +1,99 @@
+package sync
+
+import (
+	"bytes"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/rollinghash"
+)
+
+// targetWindowSize is how far back in new file TargetCopy can reach
+const targetWindowSize = 1024 * 1024
+
+func TargetCopyDelta(id uint32, offset int64, length int64) Delta {
+	d := CopyRangeDelta(id, offset, length)
+	d.Operation = TargetCopy
+	return d
+}
+
+// targetIndex keeps last targetWindowSize bytes of processed new file
+// and offsets of its chunks (aligned to chunk size) by their rolling hashes
+type targetIndex struct {
+	chunkSize int
+	rHash     *rollinghash.RollingHash
+	data      []byte
+	// start is offset in new file of first byte of data
+	start int64
+	// indexed is offset up to which chunks are indexed
+	indexed int64
+	chunks  map[uint32][]int64
+}
+
+func newTargetIndex(chunkSize int, start int64) *targetIndex {
+	return &targetIndex{
+		chunkSize: chunkSize,
+		rHash:     rollinghash.New(uint32(chunkSize)),
+		start:     start,
+		indexed:   start,
+		chunks:    map[uint32][]int64{},
+	}
+}
+
+// add appends processed data of new file
+func (t *targetIndex) add(data []byte) {
+	t.data = append(t.data, data...)
+
+	end := t.start + int64(len(t.data))
+	for t.indexed+int64(t.chunkSize) <= end {
+		from := t.indexed - t.start
+		hash := t.rHash.AddBuffer(t.data[from : from+int64(t.chunkSize)]).Hash()
+		t.chunks[hash] = append(t.chunks[hash], t.indexed)
+		t.indexed += int64(t.chunkSize)
+	}
+
+	if len(t.data) > 2*targetWindowSize {
+		t.trim()
+	}
+}
+
+// trim drops data and chunks which are out of window
+func (t *targetIndex) trim() {
+	drop := len(t.data) - targetWindowSize
+	t.data = append(t.data[:0], t.data[drop:]...)
+	t.start += int64(drop)
+
+	for hash, offsets := range t.chunks {
+		kept := offsets[:0]
+		for _, offset := range offsets {
+			if offset >= t.start {
+				kept = append(kept, offset)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(t.chunks, hash)
+		} else {
+			t.chunks[hash] = kept
+		}
+	}
+}
+
+// find returns offset of earlier chunk with the same data, latest one is preferred.
+// Data kept by index is longer than window, but patch keeps only last targetWindowSize bytes
+// before current position, so older chunks are skipped
+func (t *targetIndex) find(hash uint32, data []byte) (int64, bool) {
+	position := t.start + int64(len(t.data))
+	offsets := t.chunks[hash]
+	for i := len(offsets) - 1; i >= 0; i-- {
+		if offsets[i] < position-targetWindowSize {
+			break
+		}
+
+		from := offsets[i] - t.start
+
+		if bytes.Equal(t.data[from:from+int64(len(data))], data) {
+			return offsets[i], true
+		}
+	}
+
+	return 0, false
+}
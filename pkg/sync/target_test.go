@@ -0,0 +1,94 @@
+package sync
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func targetDeltasFor(t *testing.T, oldData []byte, newData []byte) []Delta {
+	s := New()
+	s.SetTargetMatching(true)
+
+	deltas := []Delta{}
+	err := s.DeltaFromChunks(bytes.NewReader(newData), signatureOf(t, &s, oldData), Checkpoint{}, func(d Delta) {
+		deltas = append(deltas, d)
+	}, nil)
+	require.Nil(t, err)
+
+	return deltas
+}
+
+func Test_TargetMatchingRecreatesNewFile(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		random := rand.New(rand.NewSource(seed))
+		basis := randomBytes(random, random.Intn(3000))
+		block := randomBytes(random, random.Intn(200))
+
+		// new data repeats blocks which are not in basis
+		newData := randomEdits(random, basis)
+		for i := random.Intn(5); i > 0; i-- {
+			newData = append(newData, block...)
+			newData = append(newData, randomBytes(random, random.Intn(20))...)
+		}
+
+		deltas := targetDeltasFor(t, basis, newData)
+
+		s := New()
+		out := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader(basis), deltas, &out))
+		require.Equal(t, newData, append([]byte{}, out.Bytes()...), "seed %d", seed)
+	}
+}
+
+func Test_TargetMatchingCopiesRepeatedData(t *testing.T) {
+	line := []byte("repeated log line with some text\n")
+	newData := bytes.Repeat(line, 100)
+
+	deltas := targetDeltasFor(t, []byte{}, newData)
+
+	literals := 0
+	for _, d := range deltas {
+		if d.Operation == NewData {
+			literals += len(d.Data)
+		}
+	}
+	// only first line and partial chunks which do not start at repeated offsets are sent
+	require.Less(t, literals, 3*len(line))
+	// second line starts with copy of first one
+	require.Equal(t, TargetCopyDelta(uint32(len(line)), 0, defaultChunkSize), deltas[len(line)])
+
+	s := New()
+	out := bytes.Buffer{}
+	require.Nil(t, s.Patch(bytes.NewReader([]byte{}), deltas, &out))
+	require.Equal(t, newData, out.Bytes())
+}
+
+func Test_PatchFailsWhenTargetCopyIsNotWrittenYet(t *testing.T) {
+	s := New()
+	err := s.Patch(bytes.NewReader([]byte{}), []Delta{
+		{Operation: NewData, Data: []byte("abc")},
+		TargetCopyDelta(1, 2, 5),
+	}, &bytes.Buffer{})
+	require.NotNil(t, err)
+}
+
+func Test_TargetCopiesStayWithinWindowOfPatch(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	newData := randomBytes(random, 2*1024*1024+20000)
+	// marker is far behind at the end, but still in data kept by index
+	marker := newData[targetWindowSize+16 : targetWindowSize+32]
+	newData = append(newData, marker...)
+
+	s := New()
+	s.SetTargetMatching(true)
+	for _, codec := range []Codec{NoCompression, Zstd} {
+		deltas := compressedDeltasFor(t, &s, codec, []byte{}, newData)
+
+		out := bytes.Buffer{}
+		require.Nil(t, s.Patch(bytes.NewReader([]byte{}), deltas, &out), "codec %s", codec)
+		require.Equal(t, newData, out.Bytes(), "codec %s", codec)
+	}
+}
@@ -0,0 +1,153 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Metadata describes file attributes which are restored on patch side
+type Metadata struct {
+	Mode    fs.FileMode
+	ModTime time.Time
+	// Owner is nil when file system does not provide it
+	Owner *Owner
+	// LinkTarget is set only for symbolic links
+	LinkTarget string
+	Xattrs     map[string][]byte
+}
+
+type Owner struct {
+	Uid int
+	Gid int
+}
+
+// inode identifies file on disk, it is used to find hard links
+type inode struct {
+	dev uint64
+	ino uint64
+}
+
+// MetadataFS is file system which provides metadata that is not available in fs.FileInfo
+type MetadataFS interface {
+	fs.FS
+	Metadata(path string) (Metadata, error)
+}
+
+// PatchOptions allow to skip restoring metadata which requires special privileges
+type PatchOptions struct {
+	NoOwner  bool
+	NoXattrs bool
+}
+
+type dirFS struct {
+	fs.FS
+	dir string
+}
+
+// DirFS returns file system for dir, which also reads owners, symbolic link targets and extended attributes
+func DirFS(dir string) fs.FS {
+	return dirFS{
+		FS:  os.DirFS(dir),
+		dir: dir,
+	}
+}
+
+func (d dirFS) Metadata(path string) (Metadata, error) {
+	fullPath := filepath.Join(d.dir, filepath.FromSlash(path))
+
+	info, err := os.Lstat(fullPath)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	metadata := Metadata{
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+		Owner:   fileOwner(info),
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		metadata.LinkTarget, err = os.Readlink(fullPath)
+		if err != nil {
+			return metadata, fmt.Errorf("unable to read link '%s'. %w", path, err)
+		}
+	}
+
+	metadata.Xattrs, err = readXattrs(fullPath)
+	if err != nil {
+		return metadata, fmt.Errorf("unable to read extended attributes of '%s'. %w", path, err)
+	}
+
+	return metadata, nil
+}
+
+func readMetadata(fsys fs.FS, path string, info fs.FileInfo) (Metadata, error) {
+	if metadataFS, ok := fsys.(MetadataFS); ok {
+		return metadataFS.Metadata(path)
+	}
+
+	return Metadata{
+		Mode:    info.Mode(),
+		ModTime: info.ModTime(),
+	}, nil
+}
+
+func (m Metadata) isSymlink() bool {
+	return m.Mode&fs.ModeSymlink != 0
+}
+
+func (m Metadata) Equal(other Metadata) bool {
+	if m.Mode != other.Mode || !m.ModTime.Equal(other.ModTime) || m.LinkTarget != other.LinkTarget {
+		return false
+	}
+
+	if (m.Owner == nil) != (other.Owner == nil) || (m.Owner != nil && *m.Owner != *other.Owner) {
+		return false
+	}
+
+	if len(m.Xattrs) != len(other.Xattrs) {
+		return false
+	}
+
+	for name, value := range m.Xattrs {
+		otherValue, ok := other.Xattrs[name]
+		if !ok || !bytes.Equal(value, otherValue) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyMetadata restores metadata of file or symbolic link,
+// owner is set before mode because changing owner clears setuid and setgid bits
+func applyMetadata(path string, metadata Metadata, options PatchOptions) error {
+	if metadata.Owner != nil && !options.NoOwner {
+		if err := os.Lchown(path, metadata.Owner.Uid, metadata.Owner.Gid); err != nil {
+			return fmt.Errorf("unable to set owner (use no owner option when not running as root). %w", err)
+		}
+	}
+
+	if !metadata.isSymlink() {
+		mode := metadata.Mode & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+		if err := os.Chmod(path, mode); err != nil {
+			return fmt.Errorf("unable to set mode. %w", err)
+		}
+	}
+
+	if !options.NoXattrs {
+		if err := writeXattrs(path, metadata.Xattrs); err != nil {
+			return fmt.Errorf("unable to set extended attributes. %w", err)
+		}
+	}
+
+	if err := setModTime(path, metadata.ModTime); err != nil {
+		return fmt.Errorf("unable to set modification time. %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package tree
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func fileOwner(info fs.FileInfo) *Owner {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	return &Owner{Uid: int(stat.Uid), Gid: int(stat.Gid)}
+}
+
+func readXattrs(path string) (map[string][]byte, error) {
+	names, err := listXattrs(path)
+	if err != nil || len(names) == 0 {
+		return nil, err
+	}
+
+	xattrs := map[string][]byte{}
+	for _, name := range names {
+		size, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		value := make([]byte, size)
+		size, err = unix.Lgetxattr(path, name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		xattrs[name] = value[:size]
+	}
+
+	return xattrs, nil
+}
+
+// writeXattrs sets given attributes and removes the ones that are not on the list
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	names, err := listXattrs(path)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, ok := xattrs[name]; ok {
+			continue
+		}
+
+		if err := unix.Lremovexattr(path, name); err != nil {
+			return err
+		}
+	}
+
+	for name, value := range xattrs {
+		if err := unix.Lsetxattr(path, name, value, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func listXattrs(path string) ([]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if errors.Is(err, unix.ENOTSUP) {
+		return nil, nil
+	}
+
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	buffer := make([]byte, size)
+	size, err = unix.Llistxattr(path, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, name := range bytes.Split(buffer[:size], []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+
+	return names, nil
+}
+
+// setModTime does not follow symbolic links
+func setModTime(path string, modTime time.Time) error {
+	ts := unix.NsecToTimespec(modTime.UnixNano())
+	return unix.UtimesNanoAt(unix.AT_FDCWD, path, []unix.Timespec{ts, ts}, unix.AT_SYMLINK_NOFOLLOW)
+}
+
+// fileInode returns identity of file which has more than one hard link
+func fileInode(info fs.FileInfo) (inode, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok || stat.Nlink < 2 {
+		return inode{}, false
+	}
+
+	return inode{dev: uint64(stat.Dev), ino: stat.Ino}, true
+}
@@ -0,0 +1,180 @@
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func Test_PatchRestoresMetadata(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{
+		"same.txt":      randomData(30, 1),
+		"link_to_file":  randomData(20, 2),
+		"modified.txt":  randomData(60, 3),
+		"old_link_file": randomData(10, 4),
+	})
+	require.Nil(t, os.Symlink("same.txt", filepath.Join(oldDir, "link")))
+	require.Nil(t, os.Symlink("same.txt", filepath.Join(oldDir, "file_to_link")))
+
+	newDir := t.TempDir()
+	writeTree(t, newDir, map[string][]byte{
+		"same.txt":      randomData(30, 1),
+		"file_to_link":  randomData(15, 5),
+		"modified.txt":  append(randomData(60, 3), 1, 2),
+		"old_link_file": randomData(10, 4),
+	})
+	require.Nil(t, os.Symlink("modified.txt", filepath.Join(newDir, "link")))
+	require.Nil(t, os.Symlink("same.txt", filepath.Join(newDir, "link_to_file")))
+	require.Nil(t, os.Symlink("../outside", filepath.Join(newDir, "added_link")))
+
+	modTime := time.Date(2021, 5, 6, 7, 8, 9, 0, time.UTC)
+	require.Nil(t, os.Chmod(filepath.Join(newDir, "same.txt"), 0640))
+	require.Nil(t, os.Chtimes(filepath.Join(newDir, "same.txt"), modTime, modTime))
+	require.Nil(t, unix.Lsetxattr(filepath.Join(newDir, "modified.txt"), "user.origin", []byte("test"), 0))
+	require.Nil(t, unix.Lsetxattr(filepath.Join(oldDir, "old_link_file"), "user.stale", []byte("x"), 0))
+
+	manifest, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	treeDelta, err := Delta(DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	operations := map[string]FileOperation{}
+	for _, file := range treeDelta.Files {
+		operations[file.Path] = file.Operation
+	}
+	require.Equal(t, FileMetadataChanged, operations["same.txt"])
+	require.Equal(t, FileMetadataChanged, operations["old_link_file"])
+	require.Equal(t, FileAdded, operations["link"])
+	require.Equal(t, FileAdded, operations["added_link"])
+
+	err = Patch(oldDir, treeDelta, PatchOptions{})
+	require.Nil(t, err)
+
+	newManifest, err := Signature(DirFS(newDir), nil)
+	require.Nil(t, err)
+	patchedManifest, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+	require.Equal(t, newManifest, patchedManifest)
+
+	target, err := os.Readlink(filepath.Join(oldDir, "added_link"))
+	require.Nil(t, err)
+	require.Equal(t, "../outside", target)
+
+	info, err := os.Stat(filepath.Join(oldDir, "same.txt"))
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0640), info.Mode().Perm())
+	require.True(t, modTime.Equal(info.ModTime()))
+}
+
+func Test_PatchSkipsOwnerAndXattrsWhenRequested(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{"a.txt": randomData(30, 1)})
+
+	manifest, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	uid, gid := os.Getuid()+1, os.Getgid()+1
+	treeDelta := TreeDelta{Files: []FileDelta{{
+		Path:      "a.txt",
+		Operation: FileMetadataChanged,
+		Metadata: Metadata{
+			Mode:    0600,
+			ModTime: manifest.Files[0].Metadata.ModTime,
+			Owner:   &Owner{Uid: uid, Gid: gid},
+			Xattrs:  map[string][]byte{"user.skipped": []byte("1")},
+		},
+	}}}
+
+	err = Patch(oldDir, treeDelta, PatchOptions{NoOwner: true, NoXattrs: true})
+	require.Nil(t, err)
+
+	patched, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0600), patched.Files[0].Metadata.Mode)
+	require.Equal(t, manifest.Files[0].Metadata.Owner, patched.Files[0].Metadata.Owner)
+	require.Empty(t, patched.Files[0].Metadata.Xattrs)
+}
+
+func Test_PatchRecreatesHardLinks(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{
+		"a.txt":        randomData(50, 1),
+		"unlinked.txt": randomData(40, 2),
+	})
+	require.Nil(t, os.Link(filepath.Join(oldDir, "a.txt"), filepath.Join(oldDir, "b.txt")))
+	require.Nil(t, os.Link(filepath.Join(oldDir, "unlinked.txt"), filepath.Join(oldDir, "was_link.txt")))
+
+	manifest, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+	require.Equal(t, "a.txt", manifest.Files[1].HardLinkTo)
+	require.Empty(t, manifest.Files[1].Chunks)
+
+	newDir := t.TempDir()
+	writeTree(t, newDir, map[string][]byte{
+		"a.txt":        append(randomData(50, 1), 7, 8, 9),
+		"unlinked.txt": randomData(40, 2),
+		"was_link.txt": randomData(40, 2),
+	})
+	require.Nil(t, os.Link(filepath.Join(newDir, "a.txt"), filepath.Join(newDir, "b.txt")))
+	require.Nil(t, os.MkdirAll(filepath.Join(newDir, "dir"), 0755))
+	require.Nil(t, os.Link(filepath.Join(newDir, "a.txt"), filepath.Join(newDir, "dir/c.txt")))
+
+	treeDelta, err := Delta(DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	err = Patch(oldDir, treeDelta, PatchOptions{})
+	require.Nil(t, err)
+
+	require.Equal(t, readTree(t, newDir), readTree(t, oldDir))
+	requireSameFile(t, filepath.Join(oldDir, "a.txt"), filepath.Join(oldDir, "b.txt"), true)
+	requireSameFile(t, filepath.Join(oldDir, "a.txt"), filepath.Join(oldDir, "dir/c.txt"), true)
+	requireSameFile(t, filepath.Join(oldDir, "unlinked.txt"), filepath.Join(oldDir, "was_link.txt"), false)
+}
+
+func Test_PatchKeepsHolesOfSparseFiles(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{"disk.img": randomData(100, 1)})
+
+	manifest, err := Signature(DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	newDir := t.TempDir()
+	for _, name := range []string{"disk.img", "added.img"} {
+		file, err := os.Create(filepath.Join(newDir, name))
+		require.Nil(t, err)
+		_, err = file.WriteAt(randomData(100, 1), 1024*1024)
+		require.Nil(t, err)
+		require.Nil(t, file.Truncate(2*1024*1024))
+		require.Nil(t, file.Close())
+	}
+
+	treeDelta, err := Delta(DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	err = Patch(oldDir, treeDelta, PatchOptions{})
+	require.Nil(t, err)
+
+	require.Equal(t, readTree(t, newDir), readTree(t, oldDir))
+	for _, name := range []string{"disk.img", "added.img"} {
+		info, err := os.Stat(filepath.Join(oldDir, name))
+		require.Nil(t, err)
+		require.Less(t, info.Sys().(*syscall.Stat_t).Blocks*512, info.Size(), "file '%s' should be sparse", name)
+	}
+}
+
+func requireSameFile(t *testing.T, path1 string, path2 string, expected bool) {
+	info1, err := os.Stat(path1)
+	require.Nil(t, err)
+	info2, err := os.Stat(path2)
+	require.Nil(t, err)
+
+	require.Equal(t, expected, os.SameFile(info1, info2), "unexpected hard link state of '%s' and '%s'", path1, path2)
+}
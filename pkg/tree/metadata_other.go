@@ -0,0 +1,35 @@
+//go:build !linux
+
+package tree
+
+import (
+	"io/fs"
+	"os"
+	"time"
+)
+
+func fileOwner(info fs.FileInfo) *Owner {
+	return nil
+}
+
+func readXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func writeXattrs(path string, xattrs map[string][]byte) error {
+	return nil
+}
+
+// setModTime skips symbolic links, as those can be only changed with platform specific calls
+func setModTime(path string, modTime time.Time) error {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&fs.ModeSymlink != 0 {
+		return err
+	}
+
+	return os.Chtimes(path, modTime, modTime)
+}
+
+func fileInode(info fs.FileInfo) (inode, bool) {
+	return inode{}, false
+}
@@ -0,0 +1,305 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+const tmpSuffix = ".sync-tmp"
+
+// Patch applies tree delta to dir which contains previous version of tree.
+// New content of modified files and renamed files are first moved to temporary files,
+// so renames and modifications do not overwrite files which are still needed.
+// Delta can come from untrusted source, so paths leaving dir are rejected before anything is changed.
+func Patch(dir string, treeDelta TreeDelta, options PatchOptions) error {
+	if err := checkPaths(treeDelta); err != nil {
+		return err
+	}
+
+	// index of file -> temporary file
+	staged := map[int]string{}
+	defer func() {
+		for _, tmpPath := range staged {
+			os.Remove(tmpPath)
+		}
+	}()
+
+	for i, file := range treeDelta.Files {
+		switch file.Operation {
+		case FileModified:
+			path, err := resolvePath(dir, file.Path)
+			if err != nil {
+				return err
+			}
+
+			tmpPath, err := createTemp(path)
+			if err != nil {
+				return err
+			}
+			staged[i] = tmpPath
+
+			if err := patchFile(path, file.Deltas, tmpPath); err != nil {
+				return err
+			}
+		case FileRenamed:
+			oldPath, err := resolvePath(dir, file.OldPath)
+			if err != nil {
+				return err
+			}
+
+			tmpPath, err := createTemp(oldPath)
+			if err != nil {
+				return err
+			}
+			staged[i] = tmpPath
+
+			if err := os.Rename(oldPath, tmpPath); err != nil {
+				return fmt.Errorf("unable to move renamed file '%s'. %w", file.OldPath, err)
+			}
+		}
+	}
+
+	for _, file := range treeDelta.Files {
+		if file.Operation != FileRemoved {
+			continue
+		}
+
+		path, err := resolvePath(dir, file.Path)
+		if err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to remove file '%s'. %w", file.Path, err)
+		}
+		removeEmptyParents(dir, path)
+	}
+
+	for i, file := range treeDelta.Files {
+		if file.Operation == FileRemoved || file.Operation == FileHardLinked {
+			continue
+		}
+
+		path, err := resolvePath(dir, file.Path)
+		if err != nil {
+			return err
+		}
+
+		switch file.Operation {
+		case FileModified, FileRenamed:
+			tmpPath := staged[i]
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("unable to create directory for '%s'. %w", file.Path, err)
+			}
+
+			if err := removeDirectory(path); err != nil {
+				return fmt.Errorf("unable to replace directory '%s'. %w", file.Path, err)
+			}
+
+			if err := os.Rename(tmpPath, path); err != nil {
+				return fmt.Errorf("unable to move file '%s'. %w", file.Path, err)
+			}
+			delete(staged, i)
+			// renamed file could leave its directory empty
+			removeEmptyParents(dir, tmpPath)
+		case FileAdded:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("unable to create directory for '%s'. %w", file.Path, err)
+			}
+
+			// previous version could be of different type, so it has to be removed first
+			if err := removeDirectory(path); err != nil {
+				return fmt.Errorf("unable to replace directory '%s'. %w", file.Path, err)
+			}
+
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("unable to replace file '%s'. %w", file.Path, err)
+			}
+
+			if file.Metadata.isSymlink() {
+				if err := os.Symlink(file.Metadata.LinkTarget, path); err != nil {
+					return fmt.Errorf("unable to create link '%s'. %w", file.Path, err)
+				}
+			} else if err := patchFile("", file.Deltas, path); err != nil {
+				return err
+			}
+		case FileMetadataChanged:
+		default:
+			continue
+		}
+
+		if err := applyMetadata(path, file.Metadata, options); err != nil {
+			return fmt.Errorf("unable to restore metadata of '%s'. %w", file.Path, err)
+		}
+	}
+
+	// hard links are created at the end, when files they point to are already in place
+	for _, file := range treeDelta.Files {
+		if file.Operation != FileHardLinked {
+			continue
+		}
+
+		path, err := resolvePath(dir, file.Path)
+		if err != nil {
+			return err
+		}
+
+		target, err := resolvePath(dir, file.OldPath)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("unable to create directory for '%s'. %w", file.Path, err)
+		}
+
+		if err := removeDirectory(path); err != nil {
+			return fmt.Errorf("unable to replace directory '%s'. %w", file.Path, err)
+		}
+
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("unable to replace file '%s'. %w", file.Path, err)
+		}
+
+		if err := os.Link(target, path); err != nil {
+			return fmt.Errorf("unable to create hard link '%s'. %w", file.Path, err)
+		}
+	}
+
+	return nil
+}
+
+// checkPaths validates all paths of delta, so invalid delta does not leave tree partially patched
+func checkPaths(treeDelta TreeDelta) error {
+	for _, file := range treeDelta.Files {
+		if err := checkPath(file.Path); err != nil {
+			return err
+		}
+
+		if file.Operation == FileRenamed || file.Operation == FileHardLinked {
+			if err := checkPath(file.OldPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPath accepts only relative slash separated paths which stay inside of tree
+func checkPath(path string) error {
+	if !fs.ValidPath(path) || path == "." || filepath.IsAbs(filepath.FromSlash(path)) {
+		return fmt.Errorf("invalid path '%s' in delta", path)
+	}
+
+	return nil
+}
+
+// resolvePath returns path of file from delta in dir. Path cannot leave dir,
+// also through symbolic link in one of its parent directories
+func resolvePath(dir string, path string) (string, error) {
+	if err := checkPath(path); err != nil {
+		return "", err
+	}
+
+	root := filepath.Clean(dir)
+	resolved := filepath.Join(root, filepath.FromSlash(path))
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid path '%s' in delta, it is outside of '%s'", path, dir)
+	}
+
+	for parent := filepath.Dir(resolved); parent != root; parent = filepath.Dir(parent) {
+		info, err := os.Lstat(parent)
+		if err != nil {
+			continue
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("invalid path '%s' in delta, its parent directory is symbolic link", path)
+		}
+	}
+
+	return resolved, nil
+}
+
+// createTemp creates temporary file next to path, its name cannot collide with existing file
+func createTemp(path string) (string, error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*"+tmpSuffix)
+	if err != nil {
+		return "", fmt.Errorf("unable to create temporary file for '%s'. %w", path, err)
+	}
+
+	return file.Name(), file.Close()
+}
+
+// patchFile writes result of applying deltas to basisPath into outPath,
+// empty basisPath means that file has no previous version
+func patchFile(basisPath string, deltas []sync.Delta, outPath string) error {
+	var basis io.ReadSeeker = bytes.NewReader([]byte{})
+	if basisPath != "" {
+		basisFile, err := os.Open(basisPath)
+		if err != nil {
+			return fmt.Errorf("unable to open basis file. %w", err)
+		}
+		defer basisFile.Close()
+		basis = basisFile
+	}
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("unable to create file. %w", err)
+	}
+	defer out.Close()
+
+	writer, err := sync.NewSparseFile(out)
+	if err != nil {
+		return err
+	}
+
+	s := sync.New()
+	if err := s.Patch(basis, deltas, writer); err != nil {
+		return fmt.Errorf("unable to patch file '%s'. %w", outPath, err)
+	}
+
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
+
+// removeDirectory removes directory which is in place of file from delta with all its content,
+// previous version of tree could have directory there with files which are not part of tree (like excluded ones)
+func removeDirectory(path string) error {
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return nil
+	}
+
+	return os.RemoveAll(path)
+}
+
+// removeEmptyParents removes directories left empty after removing or moving file, up to tree root
+func removeEmptyParents(root string, path string) {
+	for dir := filepath.Dir(path); dir != filepath.Clean(root); dir = filepath.Dir(dir) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+	}
+}
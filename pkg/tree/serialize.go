@@ -0,0 +1,55 @@
+package tree
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io"
+)
+
+func DeserializeManifest(manifestReader io.Reader) (Manifest, error) {
+	manifest := Manifest{}
+
+	enc := gob.NewDecoder(manifestReader)
+	err := enc.Decode(&manifest)
+	if err != nil {
+		return manifest, err
+	}
+
+	return manifest, nil
+}
+
+func SerializeManifest(manifest Manifest) (io.Reader, error) {
+	var buffer bytes.Buffer
+	enc := gob.NewEncoder(&buffer)
+
+	err := enc.Encode(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buffer, nil
+}
+
+func DeserializeTreeDelta(treeDeltaReader io.Reader) (TreeDelta, error) {
+	treeDelta := TreeDelta{}
+
+	enc := gob.NewDecoder(treeDeltaReader)
+	err := enc.Decode(&treeDelta)
+	if err != nil {
+		return treeDelta, err
+	}
+
+	return treeDelta, nil
+}
+
+func SerializeTreeDelta(treeDelta TreeDelta) (io.Reader, error) {
+	var buffer bytes.Buffer
+	enc := gob.NewEncoder(&buffer)
+
+	err := enc.Encode(treeDelta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &buffer, nil
+}
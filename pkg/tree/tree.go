@@ -0,0 +1,449 @@
+package tree
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// FileSignature describes single file or symbolic link from directory tree
+type FileSignature struct {
+	// Path is slash separated and relative to tree root
+	Path     string
+	Size     int64
+	Metadata Metadata
+	Chunks   []sync.Chunk
+	// HardLinkTo is path of earlier file from tree which shares the same data (hard link),
+	// such file has no chunks
+	HardLinkTo string
+}
+
+// Manifest is signature of whole directory tree
+type Manifest struct {
+	Files []FileSignature
+}
+
+type FileOperation byte
+
+const (
+	FileAdded FileOperation = iota
+	FileRemoved
+	FileRenamed
+	FileModified
+	FileMetadataChanged
+	FileHardLinked
+)
+
+// contentDeltaSize is maximum size of NewData delta with content of added file
+const contentDeltaSize = 64 * 1024
+
+// FileDelta describes change of single file,
+// for added files Deltas contain whole file content as NewData and Hole deltas
+// (symbolic links are described only by Metadata).
+// For hard links OldPath is path of file to which link should point.
+type FileDelta struct {
+	Path      string
+	OldPath   string
+	Operation FileOperation
+	Metadata  Metadata
+	Deltas    []sync.Delta
+}
+
+type TreeDelta struct {
+	Files []FileDelta
+}
+
+// Filter decides which paths are skipped while walking tree, nil filter skips nothing
+type Filter interface {
+	Excluded(path string, isDir bool) (bool, error)
+}
+
+// Signature walks whole tree and calculates signature of every regular file and symbolic link
+func Signature(fsys fs.FS, filter Filter) (Manifest, error) {
+	manifest := Manifest{Files: []FileSignature{}}
+
+	err := walkFiles(fsys, filter, func(file FileSignature) error {
+		manifest.Files = append(manifest.Files, file)
+		return nil
+	})
+
+	return manifest, err
+}
+
+// Delta compares tree with manifest calculated for previous version of tree,
+// files excluded by filter are not reported as removed
+func Delta(fsys fs.FS, manifest Manifest, filter Filter) (TreeDelta, error) {
+	treeDelta := TreeDelta{Files: []FileDelta{}}
+
+	oldFiles := map[string]FileSignature{}
+	for _, file := range manifest.Files {
+		oldFiles[file.Path] = file
+	}
+
+	newFiles := map[string]bool{}
+	addedFiles := []FileSignature{}
+	linkedFiles := []FileSignature{}
+
+	err := walkFiles(fsys, filter, func(file FileSignature) error {
+		newFiles[file.Path] = true
+
+		if file.HardLinkTo != "" {
+			linkedFiles = append(linkedFiles, file)
+			return nil
+		}
+
+		oldFile, ok := oldFiles[file.Path]
+		wasLinked := ok && oldFile.HardLinkTo != ""
+		if wasLinked {
+			// data has to be compared with file which link pointed to
+			linkedTo := oldFiles[oldFile.HardLinkTo]
+			oldFile.Size = linkedTo.Size
+			oldFile.Chunks = linkedTo.Chunks
+		}
+
+		switch {
+		case !ok:
+			addedFiles = append(addedFiles, file)
+		case sameContent(oldFile, file) && !wasLinked:
+			if !oldFile.Metadata.Equal(file.Metadata) {
+				treeDelta.Files = append(treeDelta.Files, FileDelta{
+					Path:      file.Path,
+					Operation: FileMetadataChanged,
+					Metadata:  file.Metadata,
+				})
+			}
+		case oldFile.Metadata.isSymlink() || file.Metadata.isSymlink():
+			// file type changed, so there is nothing to calculate delta against
+			added, err := addedFileDelta(fsys, file)
+			if err != nil {
+				return err
+			}
+			treeDelta.Files = append(treeDelta.Files, added)
+		default:
+			deltas, err := fileDeltas(fsys, file.Path, oldFile.Chunks)
+			if err != nil {
+				return err
+			}
+
+			treeDelta.Files = append(treeDelta.Files, FileDelta{
+				Path:      file.Path,
+				Operation: FileModified,
+				Metadata:  file.Metadata,
+				Deltas:    deltas,
+			})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return treeDelta, err
+	}
+
+	removedFiles := []FileSignature{}
+	for _, file := range manifest.Files {
+		if newFiles[file.Path] {
+			continue
+		}
+
+		excluded, err := isExcluded(filter, file.Path, false)
+		if err != nil {
+			return treeDelta, err
+		}
+
+		if !excluded {
+			removedFiles = append(removedFiles, file)
+		}
+	}
+
+	for _, file := range addedFiles {
+		renamedFrom := -1
+		for i, removed := range removedFiles {
+			if sameContent(removed, file) {
+				renamedFrom = i
+				break
+			}
+		}
+
+		if renamedFrom >= 0 {
+			treeDelta.Files = append(treeDelta.Files, FileDelta{
+				Path:      file.Path,
+				OldPath:   removedFiles[renamedFrom].Path,
+				Operation: FileRenamed,
+				Metadata:  file.Metadata,
+			})
+			removedFiles = append(removedFiles[:renamedFrom], removedFiles[renamedFrom+1:]...)
+			continue
+		}
+
+		added, err := addedFileDelta(fsys, file)
+		if err != nil {
+			return treeDelta, err
+		}
+		treeDelta.Files = append(treeDelta.Files, added)
+	}
+
+	for _, file := range removedFiles {
+		treeDelta.Files = append(treeDelta.Files, FileDelta{
+			Path:      file.Path,
+			Operation: FileRemoved,
+		})
+	}
+
+	// hard link has to be recreated also when data of file it points to was replaced
+	replaced := map[string]bool{}
+	for _, file := range treeDelta.Files {
+		if file.Operation == FileAdded || file.Operation == FileModified || file.Operation == FileRenamed {
+			replaced[file.Path] = true
+		}
+	}
+
+	for _, file := range linkedFiles {
+		oldFile, ok := oldFiles[file.Path]
+		if ok && oldFile.HardLinkTo == file.HardLinkTo && !replaced[file.HardLinkTo] {
+			continue
+		}
+
+		treeDelta.Files = append(treeDelta.Files, FileDelta{
+			Path:      file.Path,
+			OldPath:   file.HardLinkTo,
+			Operation: FileHardLinked,
+			Metadata:  file.Metadata,
+		})
+	}
+
+	return treeDelta, nil
+}
+
+func addedFileDelta(fsys fs.FS, file FileSignature) (FileDelta, error) {
+	added := FileDelta{
+		Path:      file.Path,
+		Operation: FileAdded,
+		Metadata:  file.Metadata,
+	}
+
+	if file.Metadata.isSymlink() {
+		return added, nil
+	}
+
+	data, err := fsys.Open(file.Path)
+	if err != nil {
+		return added, fmt.Errorf("unable to open file '%s'. %w", file.Path, err)
+	}
+	defer data.Close()
+
+	added.Deltas = []sync.Delta{}
+	handleDeltas := func(d sync.Delta) {
+		d.Id = uint32(len(added.Deltas))
+		added.Deltas = append(added.Deltas, d)
+	}
+
+	if osFile, ok := data.(*os.File); ok {
+		err = sparseContent(osFile, handleDeltas)
+	} else {
+		err = contentDeltas(data, handleDeltas)
+	}
+
+	if err != nil {
+		return added, fmt.Errorf("unable to read file '%s'. %w", file.Path, err)
+	}
+
+	return added, nil
+}
+
+// sparseContent passes content of file to handleDeltas as NewData deltas, with holes sent as Hole deltas
+func sparseContent(file *os.File, handleDeltas sync.DeltaHandler) error {
+	holes, err := sync.Holes(file)
+	if err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	offset := int64(0)
+	for _, hole := range append(holes, sync.Range{Offset: size}) {
+		if hole.Offset > offset {
+			if err := contentDeltas(io.NewSectionReader(file, offset, hole.Offset-offset), handleDeltas); err != nil {
+				return err
+			}
+		}
+
+		if hole.Size > 0 {
+			handleDeltas(sync.HoleDelta(0, hole.Size))
+		}
+		offset = hole.Offset + hole.Size
+	}
+
+	return nil
+}
+
+// contentDeltas reads data in parts of contentDeltaSize and passes them to handleDeltas as NewData deltas
+func contentDeltas(data io.Reader, handleDeltas sync.DeltaHandler) error {
+	buffer := make([]byte, contentDeltaSize)
+	for {
+		n, err := io.ReadFull(data, buffer)
+		if n > 0 {
+			handleDeltas(sync.Delta{Operation: sync.NewData, Data: append([]byte{}, buffer[:n]...)})
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type fileHandler func(file FileSignature) error
+
+// walkFiles calls handleFile for every regular file and symbolic link in tree which is not excluded by filter,
+// other entries are skipped. Symbolic links are skipped also when file system is not able to read their targets.
+// Chunks are not calculated for hard links to already visited files.
+func walkFiles(fsys fs.FS, filter Filter, handleFile fileHandler) error {
+	links := map[inode]string{}
+
+	return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != "." {
+			excluded, err := isExcluded(filter, path, d.IsDir())
+			if err != nil {
+				return err
+			}
+
+			if excluded && d.IsDir() {
+				return fs.SkipDir
+			}
+
+			if excluded {
+				return nil
+			}
+		}
+
+		if !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0 {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		metadata, err := readMetadata(fsys, path, info)
+		if err != nil {
+			return err
+		}
+
+		file := FileSignature{
+			Path:     path,
+			Metadata: metadata,
+		}
+
+		if metadata.isSymlink() {
+			if metadata.LinkTarget == "" {
+				return nil
+			}
+			return handleFile(file)
+		}
+
+		file.Size = info.Size()
+		if id, ok := fileInode(info); ok {
+			if linkedTo, found := links[id]; found {
+				file.HardLinkTo = linkedTo
+				return handleFile(file)
+			}
+			links[id] = path
+		}
+
+		file.Chunks, err = fileChunks(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		return handleFile(file)
+	})
+}
+
+func isExcluded(filter Filter, path string, isDir bool) (bool, error) {
+	if filter == nil {
+		return false, nil
+	}
+
+	return filter.Excluded(path, isDir)
+}
+
+func fileChunks(fsys fs.FS, path string) ([]sync.Chunk, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file '%s'. %w", path, err)
+	}
+	defer file.Close()
+
+	s := sync.New()
+	chunks := []sync.Chunk{}
+	err = s.Signature(file, func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate signature of '%s'. %w", path, err)
+	}
+
+	return chunks, nil
+}
+
+func fileDeltas(fsys fs.FS, path string, chunks []sync.Chunk) ([]sync.Delta, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open file '%s'. %w", path, err)
+	}
+	defer file.Close()
+
+	s := sync.New()
+	deltas := []sync.Delta{}
+	handleDeltas := func(d sync.Delta) {
+		deltas = append(deltas, d)
+	}
+
+	if osFile, ok := file.(*os.File); ok {
+		err = s.SparseDelta(osFile, chunks, handleDeltas)
+	} else {
+		err = s.DeltaFromChunks(file, chunks, sync.Checkpoint{}, handleDeltas, nil)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to calculate delta of '%s'. %w", path, err)
+	}
+
+	return deltas, nil
+}
+
+// sameContent compares data of files, or targets of symbolic links
+func sameContent(a FileSignature, b FileSignature) bool {
+	if a.Metadata.isSymlink() || b.Metadata.isSymlink() {
+		return a.Metadata.isSymlink() == b.Metadata.isSymlink() && a.Metadata.LinkTarget == b.Metadata.LinkTarget
+	}
+
+	if a.Size != b.Size || len(a.Chunks) != len(b.Chunks) {
+		return false
+	}
+
+	for i := range a.Chunks {
+		if a.Chunks[i].RollingHash != b.Chunks[i].RollingHash || !bytes.Equal(a.Chunks[i].StrongHash, b.Chunks[i].StrongHash) {
+			return false
+		}
+	}
+
+	return true
+}
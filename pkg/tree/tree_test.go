@@ -0,0 +1,344 @@
+package tree
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SignatureContainsAllRegularFiles(t *testing.T) {
+	modTime := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"a.txt":        {Data: []byte("hello"), Mode: 0644, ModTime: modTime},
+		"dir/b.txt":    {Data: randomData(40, 1), Mode: 0600, ModTime: modTime},
+		"dir/sub/link": {Data: []byte("a.txt"), Mode: fs.ModeSymlink},
+	}
+
+	manifest, err := Signature(fsys, nil)
+	require.Nil(t, err)
+
+	require.Len(t, manifest.Files, 2)
+	require.Equal(t, "a.txt", manifest.Files[0].Path)
+	require.Equal(t, int64(5), manifest.Files[0].Size)
+	require.Equal(t, fs.FileMode(0644), manifest.Files[0].Metadata.Mode)
+	require.Equal(t, modTime, manifest.Files[0].Metadata.ModTime)
+	require.Len(t, manifest.Files[0].Chunks, 1)
+
+	require.Equal(t, "dir/b.txt", manifest.Files[1].Path)
+	require.Len(t, manifest.Files[1].Chunks, 3)
+}
+
+func Test_DeltaDetectsChangedFiles(t *testing.T) {
+	old := fstest.MapFS{
+		"same.txt":     {Data: randomData(50, 1)},
+		"modified.txt": {Data: randomData(50, 2)},
+		"removed.txt":  {Data: randomData(50, 3)},
+		"old/name.txt": {Data: randomData(50, 4)},
+	}
+
+	manifest, err := Signature(old, nil)
+	require.Nil(t, err)
+
+	modified := append(randomData(50, 2), 1, 2, 3)
+	new := fstest.MapFS{
+		"same.txt":     {Data: randomData(50, 1)},
+		"modified.txt": {Data: modified},
+		"new/name.txt": {Data: randomData(50, 4)},
+		"added.txt":    {Data: randomData(10, 5)},
+	}
+
+	treeDelta, err := Delta(new, manifest, nil)
+	require.Nil(t, err)
+
+	operations := map[string]FileDelta{}
+	for _, file := range treeDelta.Files {
+		operations[file.Path] = file
+	}
+
+	require.Len(t, operations, 4)
+	require.Equal(t, FileModified, operations["modified.txt"].Operation)
+	require.Equal(t, FileRenamed, operations["new/name.txt"].Operation)
+	require.Equal(t, "old/name.txt", operations["new/name.txt"].OldPath)
+	require.Equal(t, FileAdded, operations["added.txt"].Operation)
+	require.Equal(t, randomData(10, 5), operations["added.txt"].Deltas[0].Data)
+	require.Equal(t, FileRemoved, operations["removed.txt"].Operation)
+}
+
+func Test_AddedFileContentIsSplitIntoDeltas(t *testing.T) {
+	data := randomData(2*contentDeltaSize+10, 1)
+	dir := t.TempDir()
+	writeTree(t, dir, map[string][]byte{"big.bin": data})
+
+	for name, fsys := range map[string]fs.FS{
+		"map":       fstest.MapFS{"big.bin": {Data: data}},
+		"directory": os.DirFS(dir),
+	} {
+		t.Run(name, func(t *testing.T) {
+			treeDelta, err := Delta(fsys, Manifest{}, nil)
+			require.Nil(t, err)
+			require.Len(t, treeDelta.Files, 1)
+
+			deltas := treeDelta.Files[0].Deltas
+			require.Len(t, deltas, 3)
+
+			content := []byte{}
+			for i, d := range deltas {
+				require.Equal(t, uint32(i), d.Id)
+				require.Equal(t, sync.NewData, d.Operation)
+				require.LessOrEqual(t, len(d.Data), contentDeltaSize)
+				content = append(content, d.Data...)
+			}
+			require.Equal(t, data, content)
+		})
+	}
+}
+
+func Test_PatchRecreatesNewTree(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{
+		"same.txt":          randomData(100, 1),
+		"modified.txt":      randomData(300, 2),
+		"removed/file.txt":  randomData(20, 3),
+		"renamed/from.txt":  randomData(70, 4),
+		"swap/a.txt":        randomData(40, 5),
+		"swap/b.txt":        randomData(45, 6),
+		"empty_to_data.txt": {},
+	})
+
+	manifest, err := Signature(os.DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	newDir := t.TempDir()
+	modified := randomData(300, 2)
+	modified = append(append(append([]byte{}, modified[:120]...), []byte("inserted")...), modified[120:]...)
+	newTree := map[string][]byte{
+		"same.txt":          randomData(100, 1),
+		"modified.txt":      modified,
+		"renamed/to.txt":    randomData(70, 4),
+		"swap/a.txt":        randomData(45, 6),
+		"swap/b.txt":        randomData(40, 5),
+		"added/deep/x.txt":  randomData(33, 7),
+		"empty_to_data.txt": []byte("data"),
+	}
+	writeTree(t, newDir, newTree)
+	require.Nil(t, os.Chmod(filepath.Join(newDir, "added/deep/x.txt"), 0600))
+
+	treeDelta, err := Delta(os.DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	serialized, err := SerializeTreeDelta(treeDelta)
+	require.Nil(t, err)
+	treeDelta, err = DeserializeTreeDelta(serialized)
+	require.Nil(t, err)
+
+	err = Patch(oldDir, treeDelta, PatchOptions{})
+	require.Nil(t, err)
+
+	require.Equal(t, newTree, readTree(t, oldDir))
+
+	info, err := os.Stat(filepath.Join(oldDir, "added/deep/x.txt"))
+	require.Nil(t, err)
+	require.Equal(t, fs.FileMode(0600), info.Mode().Perm())
+
+	_, err = os.Stat(filepath.Join(oldDir, "removed"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func Test_PatchReplacesDirectoriesWithFiles(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{
+		"added/a.txt": randomData(20, 1),
+		"from.txt":    randomData(30, 2),
+	})
+
+	manifest, err := Signature(os.DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	newDir := t.TempDir()
+	newTree := map[string][]byte{
+		"added":   randomData(40, 3),
+		"renamed": randomData(30, 2),
+	}
+	writeTree(t, newDir, newTree)
+
+	treeDelta, err := Delta(os.DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	// directories keep files which are not part of tree, so they are not removed with removed files
+	writeTree(t, oldDir, map[string][]byte{
+		"added/untracked.txt":   randomData(10, 4),
+		"renamed/untracked.txt": randomData(10, 5),
+	})
+
+	require.Nil(t, Patch(oldDir, treeDelta, PatchOptions{}))
+	require.Equal(t, newTree, readTree(t, oldDir))
+}
+
+func Test_PatchRejectsPathsOutsideOfTree(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "tree")
+	writeTree(t, dir, map[string][]byte{"a.txt": []byte("a")})
+	require.Nil(t, os.Symlink(root, filepath.Join(dir, "link")))
+
+	tests := []struct {
+		name string
+		file FileDelta
+	}{
+		{"parent directory", FileDelta{Path: "../escaped.txt", Operation: FileAdded}},
+		{"parent directory inside path", FileDelta{Path: "sub/../../escaped.txt", Operation: FileAdded}},
+		{"absolute path", FileDelta{Path: filepath.ToSlash(filepath.Join(root, "escaped.txt")), Operation: FileAdded}},
+		{"renamed from outside", FileDelta{Path: "b.txt", OldPath: "../escaped.txt", Operation: FileRenamed}},
+		{"hard link to outside", FileDelta{Path: "b.txt", OldPath: "../escaped.txt", Operation: FileHardLinked}},
+		{"through symbolic link", FileDelta{Path: "link/escaped.txt", Operation: FileAdded}},
+		{"empty path", FileDelta{Path: "", Operation: FileRemoved}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.file.Deltas = []sync.Delta{{Operation: sync.NewData, Data: []byte("escaped")}}
+			err := Patch(dir, TreeDelta{Files: []FileDelta{test.file}}, PatchOptions{})
+			require.ErrorContains(t, err, "invalid path")
+
+			_, err = os.Stat(filepath.Join(root, "escaped.txt"))
+			require.True(t, os.IsNotExist(err))
+			data, err := os.ReadFile(filepath.Join(dir, "a.txt"))
+			require.Nil(t, err)
+			require.Equal(t, []byte("a"), data)
+
+			_, err = os.Stat(filepath.Join(dir, "b.txt"))
+			require.True(t, os.IsNotExist(err))
+		})
+	}
+}
+
+func Test_PatchDoesNotOverwriteFilesNamedLikeTemporaryFiles(t *testing.T) {
+	oldDir := t.TempDir()
+	writeTree(t, oldDir, map[string][]byte{
+		"modified.txt": randomData(100, 1),
+		"from.txt":     randomData(50, 2),
+	})
+
+	manifest, err := Signature(os.DirFS(oldDir), nil)
+	require.Nil(t, err)
+
+	newDir := t.TempDir()
+	newTree := map[string][]byte{
+		"modified.txt": append(randomData(100, 1), 1, 2, 3),
+		"to.txt":       randomData(50, 2),
+	}
+	writeTree(t, newDir, newTree)
+
+	treeDelta, err := Delta(os.DirFS(newDir), manifest, nil)
+	require.Nil(t, err)
+
+	// files which are not part of synced tree, earlier versions staged files as <index>.sync-tmp in root of tree
+	userFiles := map[string][]byte{}
+	for i := range treeDelta.Files {
+		userFiles[fmt.Sprintf("%d.sync-tmp", i)] = []byte("user data")
+		newTree[fmt.Sprintf("%d.sync-tmp", i)] = []byte("user data")
+	}
+	writeTree(t, oldDir, userFiles)
+
+	require.Nil(t, Patch(oldDir, treeDelta, PatchOptions{}))
+	require.Equal(t, newTree, readTree(t, oldDir))
+}
+
+func Test_ManifestSerializationShouldWorkBothWays(t *testing.T) {
+	manifest, err := Signature(fstest.MapFS{
+		"a.txt": {Data: randomData(20, 1), Mode: 0644, ModTime: time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+	}, nil)
+	require.Nil(t, err)
+
+	reader, err := SerializeManifest(manifest)
+	require.Nil(t, err)
+
+	readManifest, err := DeserializeManifest(reader)
+	require.Nil(t, err)
+	require.Equal(t, manifest, readManifest)
+}
+
+func Test_FilteredFilesAreSkippedAndNotRemoved(t *testing.T) {
+	old := fstest.MapFS{
+		"src/main.go":    {Data: randomData(30, 1)},
+		"build/out.bin":  {Data: randomData(30, 2)},
+		"src/debug.log":  {Data: randomData(30, 3)},
+		"src/remove.txt": {Data: randomData(30, 4)},
+	}
+
+	manifest, err := Signature(old, nil)
+	require.Nil(t, err)
+
+	new := fstest.MapFS{
+		"src/main.go":   {Data: randomData(30, 1)},
+		"build/new.bin": {Data: randomData(30, 5)},
+	}
+
+	filter := excludeFilter{"build": true, "src/debug.log": true}
+	filtered, err := Signature(old, filter)
+	require.Nil(t, err)
+	require.Len(t, filtered.Files, 2)
+
+	treeDelta, err := Delta(new, manifest, filter)
+	require.Nil(t, err)
+
+	require.Len(t, treeDelta.Files, 1)
+	require.Equal(t, "src/remove.txt", treeDelta.Files[0].Path)
+	require.Equal(t, FileRemoved, treeDelta.Files[0].Operation)
+}
+
+// excludeFilter excludes listed paths and everything inside listed directories
+type excludeFilter map[string]bool
+
+func (f excludeFilter) Excluded(path string, isDir bool) (bool, error) {
+	for excluded := range f {
+		if path == excluded || strings.HasPrefix(path, excluded+"/") {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func writeTree(t *testing.T, dir string, files map[string][]byte) {
+	for path, data := range files {
+		fullPath := filepath.Join(dir, filepath.FromSlash(path))
+		require.Nil(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.Nil(t, os.WriteFile(fullPath, data, 0644))
+	}
+}
+
+func readTree(t *testing.T, dir string) map[string][]byte {
+	files := map[string][]byte{}
+
+	err := fs.WalkDir(os.DirFS(dir), ".", func(path string, d fs.DirEntry, err error) error {
+		require.Nil(t, err)
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		require.Nil(t, err)
+		files[path] = data
+		return nil
+	})
+	require.Nil(t, err)
+
+	return files
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	random := rand.New(rand.NewSource(seed))
+	random.Read(buffer)
+
+	return buffer
+}
@@ -0,0 +1,153 @@
+package vcdiff
+
+// instruction types of VCDIFF
+const (
+	noop byte = iota
+	add
+	run
+	copyInst
+)
+
+// address modes of default address cache
+const (
+	selfMode byte = 0
+	hereMode byte = 1
+	nearSize      = 4
+	sameSize      = 3
+	// firstSameMode is first mode which uses same cache, modes before it use near cache
+	firstSameMode = 2 + nearSize
+	maxMode       = firstSameMode + sameSize - 1
+)
+
+// codeEntry describes pair of instructions encoded by single opcode,
+// size 0 means that size is stored separately in instructions section
+type codeEntry struct {
+	inst1, size1, mode1 byte
+	inst2, size2, mode2 byte
+}
+
+// defaultCodeTable is code table from section 5.6 of RFC 3284
+var defaultCodeTable = newDefaultCodeTable()
+
+func newDefaultCodeTable() [256]codeEntry {
+	table := [256]codeEntry{}
+	i := 0
+	next := func(entry codeEntry) {
+		table[i] = entry
+		i++
+	}
+
+	next(codeEntry{inst1: run})
+
+	for size := byte(0); size <= 17; size++ {
+		next(codeEntry{inst1: add, size1: size})
+	}
+
+	for mode := byte(0); mode <= maxMode; mode++ {
+		next(codeEntry{inst1: copyInst, mode1: mode})
+		for size := byte(4); size <= 18; size++ {
+			next(codeEntry{inst1: copyInst, size1: size, mode1: mode})
+		}
+	}
+
+	for mode := byte(0); mode < firstSameMode; mode++ {
+		for addSize := byte(1); addSize <= 4; addSize++ {
+			for copySize := byte(4); copySize <= 6; copySize++ {
+				next(codeEntry{inst1: add, size1: addSize, inst2: copyInst, size2: copySize, mode2: mode})
+			}
+		}
+	}
+
+	for mode := byte(firstSameMode); mode <= maxMode; mode++ {
+		for addSize := byte(1); addSize <= 4; addSize++ {
+			next(codeEntry{inst1: add, size1: addSize, inst2: copyInst, size2: 4, mode2: mode})
+		}
+	}
+
+	for mode := byte(0); mode <= maxMode; mode++ {
+		next(codeEntry{inst1: copyInst, size1: 4, mode1: mode, inst2: add, size2: 1})
+	}
+
+	return table
+}
+
+// opcodes of single instructions with size stored separately
+const (
+	runOpcode  = 0
+	addOpcode  = 1
+	copyOpcode = 19
+	// copyModeOpcodes is number of copy opcodes for each mode
+	copyModeOpcodes = 16
+)
+
+// addressCache keeps recently used addresses, so copies can refer to them with small numbers
+type addressCache struct {
+	near     [nearSize]int64
+	nextSlot int
+	same     [sameSize * 256]int64
+}
+
+func (c *addressCache) reset() {
+	*c = addressCache{}
+}
+
+func (c *addressCache) update(address int64) {
+	c.near[c.nextSlot] = address
+	c.nextSlot = (c.nextSlot + 1) % nearSize
+	c.same[address%int64(len(c.same))] = address
+}
+
+// encode chooses mode for which address is stored in smallest number of bytes,
+// for same modes value is single byte, for others it is varint
+func (c *addressCache) encode(address int64, here int64) (byte, int64) {
+	mode, value := selfMode, address
+
+	if distance := here - address; varintLen(distance) < varintLen(value) {
+		mode, value = hereMode, distance
+	}
+
+	for i, near := range c.near {
+		if distance := address - near; distance >= 0 && varintLen(distance) < varintLen(value) {
+			mode, value = byte(2+i), distance
+		}
+	}
+
+	slot := address % int64(len(c.same))
+	if c.same[slot] == address && varintLen(value) > 1 {
+		mode, value = byte(firstSameMode)+byte(slot/256), slot%256
+	}
+
+	c.update(address)
+	return mode, value
+}
+
+// decode reads address stored with mode, readVarint and readByte read from addresses section
+func (c *addressCache) decode(mode byte, here int64, readVarint func() (int64, error), readByte func() (byte, error)) (int64, error) {
+	var address int64
+
+	switch {
+	case mode >= firstSameMode:
+		b, err := readByte()
+		if err != nil {
+			return 0, err
+		}
+		address = c.same[int64(mode-firstSameMode)*256+int64(b)]
+	default:
+		value, err := readVarint()
+		if err != nil {
+			return 0, err
+		}
+
+		switch mode {
+		case selfMode:
+			address = value
+		case hereMode:
+			address = here - value
+		default:
+			address = c.near[mode-2] + value
+		}
+	}
+
+	c.update(address)
+	return address, nil
+}
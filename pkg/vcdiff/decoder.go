@@ -0,0 +1,272 @@
+package vcdiff
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+)
+
+// maxDecodedWindowSize limits memory used for single window of decoded file
+const maxDecodedWindowSize = 256 << 20
+
+// Decode applies VCDIFF delta to source file and writes target file to out.
+// Secondary compression and custom code tables are not supported, windows copying from target (VCD_TARGET)
+// can use only last MaxTargetHistory bytes of target.
+func Decode(source io.ReaderAt, delta io.Reader, out io.Writer) error {
+	in := bufio.NewReader(delta)
+
+	magic := make([]byte, len(header))
+	if _, err := io.ReadFull(in, magic); err != nil || !bytes.Equal(magic, header) {
+		return fmt.Errorf("not a VCDIFF file")
+	}
+
+	indicator, err := in.ReadByte()
+	if err != nil {
+		return fmt.Errorf("unable to read header. %w", err)
+	}
+
+	if indicator&(vcdDecompress|vcdCodeTable) != 0 {
+		return fmt.Errorf("secondary compression and custom code tables are not supported")
+	}
+
+	if indicator&vcdAppHeader != 0 {
+		length, err := readVarint(in)
+		if err != nil {
+			return fmt.Errorf("unable to read application header. %w", err)
+		}
+
+		if _, err := io.CopyN(io.Discard, in, length); err != nil {
+			return fmt.Errorf("unable to read application header. %w", err)
+		}
+	}
+
+	history := &targetHistory{}
+	for window := 0; ; window++ {
+		indicator, err := in.ReadByte()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := decodeWindow(indicator, source, history, in, out); err != nil {
+			return fmt.Errorf("unable to decode window %d. %w", window, err)
+		}
+	}
+}
+
+func decodeWindow(indicator byte, source io.ReaderAt, history *targetHistory, in *bufio.Reader, out io.Writer) error {
+	if indicator&vcdSource != 0 && indicator&vcdTarget != 0 {
+		return fmt.Errorf("window cannot copy from both source and target")
+	}
+
+	segment := []byte{}
+	if indicator&(vcdSource|vcdTarget) != 0 {
+		length, err := readVarint(in)
+		if err != nil {
+			return err
+		}
+
+		position, err := readVarint(in)
+		if err != nil {
+			return err
+		}
+
+		if length > maxDecodedWindowSize {
+			return fmt.Errorf("source segment of %d bytes is too big", length)
+		}
+
+		if indicator&vcdTarget != 0 {
+			if segment, err = history.segment(position, length); err != nil {
+				return err
+			}
+		} else {
+			segment = make([]byte, length)
+			if _, err := source.ReadAt(segment, position); err != nil {
+				return fmt.Errorf("unable to read source segment at %d. %w", position, err)
+			}
+		}
+	}
+
+	// length of delta encoding is not needed, as all sections have their own lengths
+	if _, err := readVarint(in); err != nil {
+		return err
+	}
+
+	lengths := [4]int64{}
+	for i := range lengths {
+		if i == 1 {
+			deltaIndicator, err := in.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			if deltaIndicator != 0 {
+				return fmt.Errorf("secondary compression is not supported")
+			}
+		}
+
+		length, err := readVarint(in)
+		if err != nil {
+			return err
+		}
+		lengths[i] = length
+	}
+
+	targetSize := lengths[0]
+	if targetSize > maxDecodedWindowSize {
+		return fmt.Errorf("target window of %d bytes is too big", targetSize)
+	}
+
+	var checksum []byte
+	if indicator&vcdAdler32 != 0 {
+		checksum = make([]byte, 4)
+		if _, err := io.ReadFull(in, checksum); err != nil {
+			return err
+		}
+	}
+
+	sections := [3][]byte{}
+	for i := range sections {
+		if lengths[i+1] > maxDecodedWindowSize {
+			return fmt.Errorf("section of %d bytes is too big", lengths[i+1])
+		}
+
+		sections[i] = make([]byte, lengths[i+1])
+		if _, err := io.ReadFull(in, sections[i]); err != nil {
+			return fmt.Errorf("unable to read window sections. %w", err)
+		}
+	}
+
+	target, err := decodeInstructions(segment, targetSize, sections[0], sections[1], sections[2])
+	if err != nil {
+		return err
+	}
+
+	if checksum != nil && adler32.Checksum(target) != uint32(checksum[0])<<24|uint32(checksum[1])<<16|uint32(checksum[2])<<8|uint32(checksum[3]) {
+		return fmt.Errorf("checksum of target window does not match")
+	}
+
+	history.write(target)
+	_, err = out.Write(target)
+	return err
+}
+
+// targetHistory keeps last decoded windows, they are source of target segments
+type targetHistory struct {
+	data []byte
+	// offset in target of first kept byte
+	start int64
+}
+
+func (h *targetHistory) write(target []byte) {
+	h.data = append(h.data, target...)
+	if len(h.data) > 2*MaxTargetHistory {
+		dropped := len(h.data) - MaxTargetHistory
+		h.data = append(h.data[:0], h.data[dropped:]...)
+		h.start += int64(dropped)
+	}
+}
+
+func (h *targetHistory) segment(position int64, length int64) ([]byte, error) {
+	if position < h.start || position+length > h.start+int64(len(h.data)) {
+		return nil, fmt.Errorf("target segment at %d of %d bytes was not decoded or is not kept", position, length)
+	}
+
+	return h.data[position-h.start : position-h.start+length], nil
+}
+
+var errInvalidWindow = errors.New("instructions do not match window")
+
+func decodeInstructions(segment []byte, targetSize int64, data []byte, instructions []byte, addresses []byte) ([]byte, error) {
+	target := make([]byte, 0, targetSize)
+	dataReader := bytes.NewReader(data)
+	instReader := bytes.NewReader(instructions)
+	addrReader := bytes.NewReader(addresses)
+	cache := addressCache{}
+	segmentLen := int64(len(segment))
+
+	execute := func(inst byte, size byte, mode byte) error {
+		if inst == noop {
+			return nil
+		}
+
+		length := int64(size)
+		if size == 0 {
+			var err error
+			if length, err = readVarint(instReader); err != nil {
+				return err
+			}
+		}
+
+		if int64(len(target))+length > targetSize {
+			return errInvalidWindow
+		}
+
+		switch inst {
+		case add:
+			start := int64(len(data)) - int64(dataReader.Len())
+			if length > int64(dataReader.Len()) {
+				return errInvalidWindow
+			}
+			target = append(target, data[start:start+length]...)
+			dataReader.Seek(length, io.SeekCurrent)
+		case run:
+			b, err := dataReader.ReadByte()
+			if err != nil {
+				return errInvalidWindow
+			}
+			for i := int64(0); i < length; i++ {
+				target = append(target, b)
+			}
+		case copyInst:
+			here := segmentLen + int64(len(target))
+			address, err := cache.decode(mode, here, func() (int64, error) {
+				return readVarint(addrReader)
+			}, addrReader.ReadByte)
+			if err != nil {
+				return err
+			}
+
+			if address < 0 || address >= here {
+				return errInvalidWindow
+			}
+
+			// copy from target may overlap with data which is being produced, so it is done byte by byte
+			for i := int64(0); i < length; i++ {
+				position := address + i
+				if position < segmentLen {
+					target = append(target, segment[position])
+				} else {
+					target = append(target, target[position-segmentLen])
+				}
+			}
+		}
+
+		return nil
+	}
+
+	for instReader.Len() > 0 {
+		opcode, _ := instReader.ReadByte()
+		entry := defaultCodeTable[opcode]
+
+		if err := execute(entry.inst1, entry.size1, entry.mode1); err != nil {
+			return nil, err
+		}
+
+		if err := execute(entry.inst2, entry.size2, entry.mode2); err != nil {
+			return nil, err
+		}
+	}
+
+	if int64(len(target)) != targetSize {
+		return nil, errInvalidWindow
+	}
+
+	return target, nil
+}
@@ -0,0 +1,303 @@
+package vcdiff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+)
+
+// magic and version of VCDIFF file
+var header = []byte{0xd6, 0xc3, 0xc4, 0x00}
+
+// chunkSize is size of data described by ExistingData delta
+var chunkSize = int64(sync.DefaultChunkSize())
+
+const (
+	// window indicator bits
+	vcdSource  = 0x01
+	vcdTarget  = 0x02
+	vcdAdler32 = 0x04
+
+	// header indicator bits
+	vcdDecompress = 0x01
+	vcdCodeTable  = 0x02
+	vcdAppHeader  = 0x04
+)
+
+// MaxWindowSize is maximum size of target window created by Encoder
+const MaxWindowSize = 1 << 20
+
+// MaxTargetHistory is number of last bytes of target kept by Decode for target segments
+const MaxTargetHistory = 4 * MaxWindowSize
+
+type instruction struct {
+	inst byte
+	size int64
+	// address in segment of window for copy
+	address int64
+	// copy from target data decoded earlier in the same window, address is relative to start of window
+	inWindow bool
+	// data for add, single byte for run
+	data []byte
+}
+
+// Encoder turns stream of deltas into VCDIFF instructions, deltas are split into windows of MaxWindowSize.
+// Copy instructions refer to source segment of window, which covers only data copied in that window.
+// TargetCopy deltas are copies from target data of the same window, or from target segment (VCD_TARGET)
+// when copied data was decoded in earlier windows. Target segment can start at most MaxTargetHistory
+// bytes before copy, and window with target segment does not copy from source file.
+type Encoder struct {
+	out        io.Writer
+	targetSize int64
+	position   int64
+	window     []instruction
+	windowSize int64
+	// segment is vcdSource or vcdTarget when window copies from its segment
+	segment byte
+	cache   addressCache
+}
+
+// NewEncoder creates encoder for deltas of file with targetSize bytes,
+// size is needed to find out length of last chunk copied from basis
+func NewEncoder(out io.Writer, targetSize int64) (*Encoder, error) {
+	if _, err := out.Write(append(header, 0)); err != nil {
+		return nil, err
+	}
+
+	return &Encoder{out: out, targetSize: targetSize}, nil
+}
+
+func (e *Encoder) Write(d sync.Delta) error {
+	switch d.Operation {
+	case sync.NewData:
+		return e.addData(add, d.Data, int64(len(d.Data)))
+	case sync.Hole:
+		return e.addData(run, []byte{0}, sync.HoleSize(d))
+	case sync.ExistingData:
+		if len(d.Data) != 4 {
+			return fmt.Errorf("invalid chunk id in delta %d", d.Id)
+		}
+
+		address := int64(d.Data[0])<<24 | int64(d.Data[1])<<16 | int64(d.Data[2])<<8 | int64(d.Data[3])
+		return e.addCopy(vcdSource, address*chunkSize, e.copyLength(chunkSize))
+	case sync.CopyRange:
+		address, length := sync.CopyRangeOf(d)
+		return e.addCopy(vcdSource, address, e.copyLength(length))
+	case sync.TargetCopy:
+		address, length := sync.CopyRangeOf(d)
+		if address < 0 || address >= e.position || e.position-address > MaxTargetHistory {
+			return fmt.Errorf("target copy from offset %d is not possible at offset %d", address, e.position)
+		}
+		return e.addCopy(vcdTarget, address, e.copyLength(length))
+	case sync.CompressedData:
+		return fmt.Errorf("compressed data cannot be encoded, delta has to be created without compression")
+	}
+
+	return fmt.Errorf("unknown operation %d", d.Operation)
+}
+
+// Close writes last window, it does not close underlying writer
+func (e *Encoder) Close() error {
+	return e.flush()
+}
+
+// copyLength returns length of copy, only copy at the end of target can be shorter
+func (e *Encoder) copyLength(length int64) int64 {
+	if left := e.targetSize - e.position; left < length {
+		return left
+	}
+
+	return length
+}
+
+// addData adds add or run instruction, splitting it between windows when needed
+func (e *Encoder) addData(inst byte, data []byte, size int64) error {
+	for size > 0 {
+		if e.windowSize == MaxWindowSize {
+			if err := e.flush(); err != nil {
+				return err
+			}
+		}
+
+		part := size
+		if free := MaxWindowSize - e.windowSize; part > free {
+			part = free
+		}
+
+		partData := data
+		if inst == add {
+			partData = data[:part]
+			data = data[part:]
+		}
+
+		last := len(e.window) - 1
+		if last >= 0 && e.window[last].inst == inst && (inst == add || e.window[last].data[0] == partData[0]) {
+			e.window[last].size += part
+			if inst == add {
+				e.window[last].data = append(e.window[last].data, partData...)
+			}
+		} else {
+			e.window = append(e.window, instruction{inst: inst, size: part, data: append([]byte{}, partData...)})
+		}
+
+		e.windowSize += part
+		e.position += part
+		size -= part
+	}
+
+	return nil
+}
+
+// addCopy adds copy from source file or from target file, splitting it between windows when needed.
+// Copy from target is encoded as copy from window target data when it was decoded in the same window,
+// otherwise as copy from target segment, segments of windows come either from source or from target.
+func (e *Encoder) addCopy(from byte, address int64, length int64) error {
+	if length <= 0 {
+		return fmt.Errorf("copy at offset %d is outside of target of size %d", e.position, e.targetSize)
+	}
+
+	for length > 0 {
+		if e.windowSize == MaxWindowSize {
+			if err := e.flush(); err != nil {
+				return err
+			}
+		}
+
+		part := length
+		if free := MaxWindowSize - e.windowSize; part > free {
+			part = free
+		}
+
+		windowStart := e.position - e.windowSize
+		inst := instruction{inst: copyInst, address: address}
+		switch {
+		case from == vcdTarget && address >= windowStart:
+			inst.inWindow = true
+			inst.address = address - windowStart
+		case e.segment != 0 && e.segment != from:
+			if err := e.flush(); err != nil {
+				return err
+			}
+			continue
+		case from == vcdTarget && address+part > windowStart:
+			// target segment has to be decoded before window, rest is copied from window
+			part = windowStart - address
+		}
+
+		if !inst.inWindow {
+			e.segment = from
+		}
+		inst.size = part
+
+		last := len(e.window) - 1
+		if last >= 0 && e.window[last].inst == copyInst && e.window[last].inWindow == inst.inWindow && e.window[last].address+e.window[last].size == inst.address {
+			e.window[last].size += part
+		} else {
+			e.window = append(e.window, inst)
+		}
+
+		e.windowSize += part
+		e.position += part
+		address += part
+		length -= part
+	}
+
+	return nil
+}
+
+// flush encodes collected instructions as single window
+func (e *Encoder) flush() error {
+	if len(e.window) == 0 {
+		return nil
+	}
+
+	segmentStart, segmentEnd := int64(-1), int64(0)
+	for _, inst := range e.window {
+		if inst.inst != copyInst || inst.inWindow {
+			continue
+		}
+
+		if segmentStart < 0 || inst.address < segmentStart {
+			segmentStart = inst.address
+		}
+
+		if end := inst.address + inst.size; end > segmentEnd {
+			segmentEnd = end
+		}
+	}
+
+	indicator := byte(0)
+	segmentLen := int64(0)
+	if segmentStart >= 0 {
+		indicator = e.segment
+		segmentLen = segmentEnd - segmentStart
+	}
+
+	data, instructions, addresses := []byte{}, []byte{}, []byte{}
+	e.cache.reset()
+	here := segmentLen
+
+	for _, inst := range e.window {
+		switch inst.inst {
+		case add:
+			data = append(data, inst.data...)
+			if inst.size <= 17 {
+				instructions = append(instructions, byte(addOpcode+inst.size))
+			} else {
+				instructions = append(instructions, addOpcode)
+				instructions = appendVarint(instructions, inst.size)
+			}
+		case run:
+			data = append(data, inst.data[0])
+			instructions = append(instructions, runOpcode)
+			instructions = appendVarint(instructions, inst.size)
+		case copyInst:
+			address := inst.address - segmentStart
+			if inst.inWindow {
+				address = segmentLen + inst.address
+			}
+
+			mode, value := e.cache.encode(address, here)
+			if mode >= firstSameMode {
+				addresses = append(addresses, byte(value))
+			} else {
+				addresses = appendVarint(addresses, value)
+			}
+
+			opcode := copyOpcode + int(mode)*copyModeOpcodes
+			if inst.size >= 4 && inst.size <= 18 {
+				instructions = append(instructions, byte(opcode+int(inst.size)-3))
+			} else {
+				instructions = append(instructions, byte(opcode))
+				instructions = appendVarint(instructions, inst.size)
+			}
+		}
+		here += inst.size
+	}
+
+	encoding := appendVarint([]byte{}, e.windowSize)
+	encoding = append(encoding, 0)
+	encoding = appendVarint(encoding, int64(len(data)))
+	encoding = appendVarint(encoding, int64(len(instructions)))
+	encoding = appendVarint(encoding, int64(len(addresses)))
+
+	window := []byte{indicator}
+	if indicator != 0 {
+		window = appendVarint(window, segmentLen)
+		window = appendVarint(window, segmentStart)
+	}
+	window = appendVarint(window, int64(len(encoding)+len(data)+len(instructions)+len(addresses)))
+	window = append(window, encoding...)
+
+	for _, part := range [][]byte{window, data, instructions, addresses} {
+		if _, err := e.out.Write(part); err != nil {
+			return err
+		}
+	}
+
+	e.window = e.window[:0]
+	e.windowSize = 0
+	e.segment = 0
+	return nil
+}
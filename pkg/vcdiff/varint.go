@@ -0,0 +1,51 @@
+package vcdiff
+
+import (
+	"errors"
+	"io"
+)
+
+var errVarintOverflow = errors.New("integer is too big")
+
+// appendVarint encodes value as big endian base 128 integer, all bytes except last one have highest bit set
+func appendVarint(data []byte, value int64) []byte {
+	buffer := [10]byte{}
+	i := len(buffer) - 1
+	buffer[i] = byte(value & 0x7f)
+
+	for value >>= 7; value > 0; value >>= 7 {
+		i--
+		buffer[i] = byte(value&0x7f) | 0x80
+	}
+
+	return append(data, buffer[i:]...)
+}
+
+func varintLen(value int64) int {
+	length := 1
+	for value >>= 7; value > 0; value >>= 7 {
+		length++
+	}
+
+	return length
+}
+
+func readVarint(r io.ByteReader) (int64, error) {
+	var value int64
+
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		if value > (1<<63-1)>>7 {
+			return 0, errVarintOverflow
+		}
+
+		value = value<<7 | int64(b&0x7f)
+		if b&0x80 == 0 {
+			return value, nil
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package vcdiff
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/adler32"
+	"math/rand"
+	"testing"
+
+	"github.com/piotrjaromin/rolling-hash-algorithm/pkg/sync"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EncoderWritesWindowWithSourceSegment(t *testing.T) {
+	out := &bytes.Buffer{}
+	encoder, err := NewEncoder(out, 34)
+	require.Nil(t, err)
+
+	for _, d := range []sync.Delta{
+		{Id: 0, Operation: sync.ExistingData, Data: []byte{0, 0, 0, 0}},
+		{Id: 1, Operation: sync.NewData, Data: []byte("X")},
+		{Id: 2, Operation: sync.NewData, Data: []byte("Y")},
+		{Id: 3, Operation: sync.ExistingData, Data: []byte{0, 0, 0, 1}},
+	} {
+		require.Nil(t, encoder.Write(d))
+	}
+	require.Nil(t, encoder.Close())
+
+	require.Equal(t, []byte{
+		0xd6, 0xc3, 0xc4, 0x00, 0x00,
+		// window with source segment of 32 bytes at 0, 12 bytes of delta encoding
+		0x01, 0x20, 0x00, 0x0c,
+		// target size, delta indicator, lengths of data, instructions and addresses
+		0x22, 0x00, 0x02, 0x03, 0x02,
+		'X', 'Y',
+		// copy 16 (mode self), add 2, copy 16 (mode self)
+		0x20, 0x03, 0x20,
+		0x00, 0x10,
+	}, out.Bytes())
+}
+
+func Test_DecoderHandlesCacheModesTargetCopiesAndChecksums(t *testing.T) {
+	source := []byte("abcdefghijklmnop")
+	target := []byte("abcdwxyzwxyzwxyzefgh")
+
+	checksum := make([]byte, 4)
+	binary.BigEndian.PutUint32(checksum, adler32.Checksum(target))
+
+	delta := []byte{0xd6, 0xc3, 0xc4, 0x00, 0x04, 0x03, 'a', 'p', 'p'}
+	delta = append(delta, 0x05, 0x10, 0x00, 0x14, 0x14, 0x00, 0x04, 0x04, 0x03)
+	delta = append(delta, checksum...)
+	// copy 4 (self), add 4, copy 8 from target (here), copy 4 (near)
+	delta = append(delta, 'w', 'x', 'y', 'z', 20, 5, 40, 52, 0x00, 0x04, 0x04)
+
+	out := &bytes.Buffer{}
+	require.Nil(t, Decode(bytes.NewReader(source), bytes.NewReader(delta), out))
+	require.Equal(t, target, out.Bytes())
+
+	delta[len(delta)-11] = 'W'
+	err := Decode(bytes.NewReader(source), bytes.NewReader(delta), &bytes.Buffer{})
+	require.ErrorContains(t, err, "checksum of target window does not match")
+}
+
+func Test_EncodedDeltasRecreateNewFile(t *testing.T) {
+	oldData := randomData(3*MaxWindowSize+5, 1)
+	newData := append([]byte{}, oldData[100:]...)
+	newData = append(newData[:MaxWindowSize], append([]byte("inserted"), newData[MaxWindowSize:]...)...)
+	newData = append(newData, randomData(1001, 2)...)
+	// file ends with last chunks of basis, last one is shorter than chunk size
+	newData = append(newData, oldData[len(oldData)-21:]...)
+
+	s := sync.New()
+	chunks := []sync.Chunk{}
+	s.Signature(bytes.NewReader(oldData), func(c sync.Chunk) {
+		chunks = append(chunks, c)
+	})
+
+	out := &bytes.Buffer{}
+	encoder, err := NewEncoder(out, int64(len(newData)))
+	require.Nil(t, err)
+
+	var writeErr error
+	err = s.DeltaFromChunks(bytes.NewReader(newData), chunks, sync.Checkpoint{}, func(d sync.Delta) {
+		if writeErr == nil {
+			writeErr = encoder.Write(d)
+		}
+	}, nil)
+	require.Nil(t, err)
+	require.Nil(t, writeErr)
+	require.Nil(t, encoder.Close())
+	require.Less(t, out.Len(), 10000)
+
+	patched := &bytes.Buffer{}
+	require.Nil(t, Decode(bytes.NewReader(oldData), out, patched))
+	require.Equal(t, newData, patched.Bytes())
+}
+
+func Test_HolesAreEncodedAsRuns(t *testing.T) {
+	out := &bytes.Buffer{}
+	encoder, err := NewEncoder(out, MaxWindowSize+11)
+	require.Nil(t, err)
+
+	require.Nil(t, encoder.Write(sync.Delta{Id: 0, Operation: sync.NewData, Data: []byte("a")}))
+	require.Nil(t, encoder.Write(sync.HoleDelta(1, MaxWindowSize+10)))
+	require.Nil(t, encoder.Close())
+	require.Less(t, out.Len(), 50)
+
+	patched := &bytes.Buffer{}
+	require.Nil(t, Decode(bytes.NewReader(nil), out, patched))
+	require.Equal(t, append([]byte("a"), make([]byte, MaxWindowSize+10)...), patched.Bytes())
+}
+
+func Test_CopiesAreSplitBetweenWindows(t *testing.T) {
+	oldData := randomData(2*MaxWindowSize+100, 3)
+	newData := append([]byte("abc"), oldData[10:2*MaxWindowSize+10]...)
+	// target copy of data decoded in the same and in previous windows
+	newData = append(newData, newData[len(newData)-100:]...)
+	newData = append(newData, newData[5:MaxWindowSize+50]...)
+
+	deltas := []sync.Delta{
+		{Id: 0, Operation: sync.NewData, Data: []byte("abc")},
+		sync.CopyRangeDelta(1, 10, 2*MaxWindowSize),
+		sync.TargetCopyDelta(2, 2*MaxWindowSize+3-100, 100),
+		sync.TargetCopyDelta(3, 5, MaxWindowSize+45),
+	}
+
+	out := &bytes.Buffer{}
+	encoder, err := NewEncoder(out, int64(len(newData)))
+	require.Nil(t, err)
+	for _, d := range deltas {
+		require.Nil(t, encoder.Write(d))
+	}
+	require.Nil(t, encoder.Close())
+	require.Less(t, out.Len(), 200)
+
+	patched := &bytes.Buffer{}
+	require.Nil(t, Decode(bytes.NewReader(oldData), out, patched))
+	require.Equal(t, newData, patched.Bytes())
+}
+
+func Test_OverlappingTargetCopyRepeatsData(t *testing.T) {
+	out := &bytes.Buffer{}
+	encoder, err := NewEncoder(out, 12)
+	require.Nil(t, err)
+
+	require.Nil(t, encoder.Write(sync.Delta{Id: 0, Operation: sync.NewData, Data: []byte("ab")}))
+	require.Nil(t, encoder.Write(sync.TargetCopyDelta(1, 0, 10)))
+	require.Nil(t, encoder.Close())
+
+	patched := &bytes.Buffer{}
+	require.Nil(t, Decode(bytes.NewReader(nil), out, patched))
+	require.Equal(t, "abababababab", patched.String())
+}
+
+func Test_EncoderRejectsUnsupportedDeltas(t *testing.T) {
+	encoder, err := NewEncoder(&bytes.Buffer{}, 100)
+	require.Nil(t, err)
+
+	err = encoder.Write(sync.TargetCopyDelta(0, 0, 10))
+	require.ErrorContains(t, err, "target copy from offset 0 is not possible at offset 0")
+
+	err = encoder.Write(sync.Delta{Id: 0, Operation: sync.CompressedData, Data: []byte{1, 2}})
+	require.ErrorContains(t, err, "compressed data cannot be encoded")
+}
+
+func Test_InvalidDeltaIsRejected(t *testing.T) {
+	err := Decode(bytes.NewReader(nil), bytes.NewReader([]byte("not vcdiff")), &bytes.Buffer{})
+	require.ErrorContains(t, err, "not a VCDIFF file")
+
+	// copy from address which was not decoded yet
+	delta := []byte{0xd6, 0xc3, 0xc4, 0x00, 0x00, 0x00, 0x06, 0x04, 0x00, 0x00, 0x01, 0x01, 20, 0x05}
+	err = Decode(bytes.NewReader(nil), bytes.NewReader(delta), &bytes.Buffer{})
+	require.ErrorContains(t, err, "instructions do not match window")
+}
+
+func Test_DefaultCodeTable(t *testing.T) {
+	require.Equal(t, codeEntry{inst1: run}, defaultCodeTable[0])
+	require.Equal(t, codeEntry{inst1: add, size1: 17}, defaultCodeTable[18])
+	require.Equal(t, codeEntry{inst1: copyInst, size1: 18, mode1: 8}, defaultCodeTable[162])
+	require.Equal(t, codeEntry{inst1: add, size1: 1, inst2: copyInst, size2: 4}, defaultCodeTable[163])
+	require.Equal(t, codeEntry{inst1: add, size1: 4, inst2: copyInst, size2: 6, mode2: 5}, defaultCodeTable[234])
+	require.Equal(t, codeEntry{inst1: add, size1: 4, inst2: copyInst, size2: 4, mode2: 8}, defaultCodeTable[246])
+	require.Equal(t, codeEntry{inst1: copyInst, size1: 4, mode1: 8, inst2: add, size2: 1}, defaultCodeTable[255])
+}
+
+func randomData(size int, seed int64) []byte {
+	buffer := make([]byte, size)
+	rand.New(rand.NewSource(seed)).Read(buffer)
+
+	return buffer
+}